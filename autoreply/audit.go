@@ -0,0 +1,96 @@
+package autoreply
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Action is what the responder did about a matched message
+type Action string
+
+// Actions
+const (
+	// ActionNone means no rule matched; it is not audited
+	ActionNone Action = "none"
+
+	// ActionSent means the reply was sent
+	ActionSent Action = "sent"
+
+	// ActionDryRun means the reply would have been sent
+	ActionDryRun Action = "dry_run"
+
+	// ActionSkipped means a rule matched but the reply was held back by
+	// quiet hours, the rate cap or the rule's cooldown
+	ActionSkipped Action = "skipped"
+
+	// ActionFailed means sending the reply failed
+	ActionFailed Action = "failed"
+)
+
+// AuditEntry records a decision about a guest message
+type AuditEntry struct {
+	Time           time.Time `json:"time"`
+	ConversationID string    `json:"conversation_id"`
+	MessageID      string    `json:"message_id"`
+	Rule           string    `json:"rule"`
+	Action         Action    `json:"action"`
+	Reply          string    `json:"reply,omitempty"`
+	Reason         string    `json:"reason,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// AuditLog stores audit entries
+type AuditLog interface {
+	Record(ctx context.Context, entry AuditEntry) error
+}
+
+// JSONAuditLog writes each entry as a line of JSON, e.g. to a file
+type JSONAuditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONAuditLog creates an audit log that writes to w
+func NewJSONAuditLog(w io.Writer) *JSONAuditLog {
+	return &JSONAuditLog{w: w}
+}
+
+// Record implements AuditLog
+func (l *JSONAuditLog) Record(ctx context.Context, entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("autoreply: failed to encode audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("autoreply: failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// MemoryAuditLog keeps audit entries in memory
+type MemoryAuditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+// Record implements AuditLog
+func (l *MemoryAuditLog) Record(ctx context.Context, entry AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+// Entries returns the recorded entries, in order
+func (l *MemoryAuditLog) Entries() []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]AuditEntry(nil), l.entries...)
+}
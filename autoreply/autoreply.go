@@ -0,0 +1,430 @@
+// Package autoreply answers repetitive guest questions automatically.
+//
+// A Responder checks each guest message against an ordered list of rules.
+// Rules match on the message text (keywords or a regular expression), the
+// channel, the property and the guest's stay phase, and the first match
+// replies through SendMessage with a templated text. The stay phase follows
+// the dates of the reservation linked to the conversation:
+//
+//	r, err := autoreply.New(client, autoreply.Options{
+//		Rules: []autoreply.Rule{{
+//			Name:     "wifi",
+//			Keywords: []string{"wifi", "wi-fi", "internet"},
+//			Reply:    "Hi {{.Guest.Name}}, the wifi network is SeasideGuest, password on the fridge.",
+//			Cooldown: 24 * time.Hour,
+//		}},
+//		QuietHours:                &autoreply.QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour},
+//		MaxRepliesPerConversation: 3,
+//		Audit:                     autoreply.NewJSONAuditLog(auditFile),
+//	})
+//
+// Plug it into a watch.ConversationWatcher with OnMessage: r.OnGuestMessage.
+// Every reply, dry-run reply and held-back reply is written to the audit log.
+// Messages that arrive during quiet hours are reported again by the watcher,
+// which treats them as deferred rather than failed, and answered once the
+// quiet period is over. They are audited as held back only once.
+package autoreply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/watch"
+)
+
+// DefaultRateWindow is the default period MaxRepliesPerConversation applies to
+const DefaultRateWindow = 24 * time.Hour
+
+// phaseLookback and phaseLookahead bound the stays looked up to find a
+// conversation's reservation: check-out at most phaseLookback days ago and
+// check-in at most phaseLookahead days ahead
+const (
+	phaseLookback  = 30
+	phaseLookahead = 365
+)
+
+// ErrQuietHours is returned for a reply held back by quiet hours, so that
+// the message is handled again later. It wraps watch.ErrDeferred, so a
+// watcher does not log it as a failure.
+var ErrQuietHours = fmt.Errorf("autoreply: quiet hours: %w", watch.ErrDeferred)
+
+// Service is the part of the API a Responder uses
+type Service interface {
+	hostex.ConversationService
+	hostex.ReservationService
+}
+
+// QuietHours is a daily period in which no replies are sent. Start and End
+// are times of day as offsets from midnight; the period wraps around
+// midnight when End is before Start.
+type QuietHours struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// contains reports whether t falls in the quiet period
+func (q QuietHours) contains(t time.Time) bool {
+	year, month, day := t.Date()
+	offset := t.Sub(time.Date(year, month, day, 0, 0, 0, 0, t.Location()))
+	if q.Start <= q.End {
+		return offset >= q.Start && offset < q.End
+	}
+	return offset >= q.Start || offset < q.End
+}
+
+// Options configures a Responder
+type Options struct {
+	// Rules are tried in order; the first match replies
+	Rules []Rule
+
+	// Location is the time zone for stay phases and quiet hours (optional,
+	// defaults to UTC)
+	Location *time.Location
+
+	// QuietHours holds back replies during a daily period. Handle returns
+	// ErrQuietHours for them, so the watcher reports the message again
+	// (optional)
+	QuietHours *QuietHours
+
+	// MaxRepliesPerConversation caps the replies sent to one conversation
+	// within RateWindow (optional, 0 means no cap)
+	MaxRepliesPerConversation int
+
+	// RateWindow is the period MaxRepliesPerConversation applies to
+	// (optional, defaults to DefaultRateWindow)
+	RateWindow time.Duration
+
+	// DryRun decides and audits replies without sending them. Dry-run
+	// replies do not count towards the rate cap or cooldowns.
+	DryRun bool
+
+	// Audit records every reply and held-back reply (optional)
+	Audit AuditLog
+
+	// Now returns the current time (optional, defaults to time.Now)
+	Now func() time.Time
+}
+
+// Decision is the outcome of handling a message
+type Decision struct {
+	Action Action
+
+	// Rule is the name of the matched rule
+	Rule string
+
+	// Reply is the rendered reply
+	Reply string
+
+	// Reason explains why a reply was skipped
+	Reason string
+}
+
+// cooldownKey identifies a rule's cooldown in one conversation
+type cooldownKey struct {
+	conversationID string
+	rule           string
+}
+
+// Responder answers guest messages according to its rules. It is safe for
+// concurrent use.
+type Responder struct {
+	svc   Service
+	opts  Options
+	rules []Rule
+
+	mu        sync.Mutex
+	replies   map[string][]time.Time
+	cooldowns map[cooldownKey]time.Time
+
+	// held holds the IDs of messages audited as held back by quiet hours,
+	// so that the watcher's retries are not audited again
+	held map[string]bool
+}
+
+// New creates a Responder, parsing the reply templates of the rules
+func New(svc Service, opts Options) (*Responder, error) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.RateWindow <= 0 {
+		opts.RateWindow = DefaultRateWindow
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	rules := slices.Clone(opts.Rules)
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if strings.TrimSpace(rule.Reply) == "" {
+			return nil, fmt.Errorf("autoreply: %s has no reply", rule.Name)
+		}
+		tmpl, err := template.New(rule.Name).Option("missingkey=error").Parse(rule.Reply)
+		if err != nil {
+			return nil, fmt.Errorf("autoreply: invalid reply template for %s: %w", rule.Name, err)
+		}
+		rule.tmpl = tmpl
+		// A conservative check: a false positive only costs a lookup
+		rule.usesPhase = len(rule.Phases) > 0 || strings.Contains(rule.Reply, "Phase")
+	}
+
+	return &Responder{
+		svc:       svc,
+		opts:      opts,
+		rules:     rules,
+		replies:   make(map[string][]time.Time),
+		cooldowns: make(map[cooldownKey]time.Time),
+		held:      make(map[string]bool),
+	}, nil
+}
+
+// OnGuestMessage handles a message found by a watch.ConversationWatcher. It
+// only returns an error if the reply may not have been sent or is held back
+// by quiet hours, so the watcher reports the message again.
+func (r *Responder) OnGuestMessage(ctx context.Context, m watch.GuestMessage) error {
+	decision, err := r.Handle(ctx, m.Conversation, m.Message)
+	if decision.Action == ActionSent {
+		return nil
+	}
+	return err
+}
+
+// Handle decides whether to answer a message and sends the reply. Messages
+// not sent by the guest are ignored. If the reply was sent, Action is
+// ActionSent even when writing the audit entry fails. A reply held back by
+// quiet hours is ActionSkipped with ErrQuietHours, and is only audited the
+// first time it is held back. The reservation linked to the conversation is
+// only looked up for rules that use the stay phase.
+func (r *Responder) Handle(ctx context.Context, conv hostex.Conversation, msg hostex.Message) (Decision, error) {
+	if msg.SenderRole != hostex.SenderGuest {
+		return Decision{Action: ActionNone}, nil
+	}
+
+	now := r.opts.Now().In(r.opts.Location)
+	quiet := r.opts.QuietHours != nil && r.opts.QuietHours.contains(now)
+	if !quiet {
+		r.unhold(msg.ID)
+	}
+
+	var phase Phase
+	lookup := func() (Phase, error) {
+		if phase == "" {
+			p, err := r.phase(ctx, conv, hostex.DateOf(now))
+			if err != nil {
+				return "", err
+			}
+			phase = p
+		}
+		return phase, nil
+	}
+
+	var rule *Rule
+	for i := range r.rules {
+		candidate := &r.rules[i]
+		if !candidate.matches(conv, msg) {
+			continue
+		}
+		if len(candidate.Phases) > 0 {
+			p, err := lookup()
+			if err != nil {
+				return Decision{Action: ActionFailed}, err
+			}
+			if !slices.Contains(candidate.Phases, p) {
+				continue
+			}
+		}
+		rule = candidate
+		break
+	}
+	if rule == nil {
+		return Decision{Action: ActionNone}, nil
+	}
+	decision := Decision{Rule: rule.Name}
+	if rule.usesPhase {
+		if _, err := lookup(); err != nil {
+			return Decision{Action: ActionFailed, Rule: rule.Name}, err
+		}
+	}
+
+	var reply strings.Builder
+	if err := rule.tmpl.Execute(&reply, Data{Guest: conv.Guest, Conversation: conv, Message: msg, Phase: phase}); err != nil {
+		decision.Action = ActionFailed
+		err = fmt.Errorf("autoreply: failed to render reply for %s: %w", rule.Name, err)
+		return decision, errors.Join(err, r.audit(ctx, now, conv, msg, decision, err))
+	}
+	decision.Reply = reply.String()
+
+	if quiet {
+		decision.Action = ActionSkipped
+		decision.Reason = "quiet hours"
+		if !r.hold(msg.ID) {
+			return decision, ErrQuietHours
+		}
+		return decision, errors.Join(ErrQuietHours, r.audit(ctx, now, conv, msg, decision, nil))
+	}
+
+	if reason := r.reserve(conv.ID, rule, now, !r.opts.DryRun); reason != "" {
+		decision.Action = ActionSkipped
+		decision.Reason = reason
+		return decision, r.audit(ctx, now, conv, msg, decision, nil)
+	}
+
+	if r.opts.DryRun {
+		decision.Action = ActionDryRun
+		return decision, r.audit(ctx, now, conv, msg, decision, nil)
+	}
+
+	if err := r.svc.SendMessage(ctx, conv.ID, hostex.SendMessageData{Message: decision.Reply}); err != nil {
+		r.release(conv.ID, rule, now)
+		decision.Action = ActionFailed
+		return decision, errors.Join(err, r.audit(ctx, now, conv, msg, decision, err))
+	}
+
+	decision.Action = ActionSent
+	return decision, r.audit(ctx, now, conv, msg, decision, nil)
+}
+
+// reserve checks the rate cap and the rule's cooldown and, if a reply may be
+// sent and count is set, counts it. It returns why the reply is held back.
+func (r *Responder) reserve(conversationID string, rule *Rule, now time.Time, count bool) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sent := slices.DeleteFunc(r.replies[conversationID], func(t time.Time) bool {
+		return !t.After(now.Add(-r.opts.RateWindow))
+	})
+	r.replies[conversationID] = sent
+	if limit := r.opts.MaxRepliesPerConversation; limit > 0 && len(sent) >= limit {
+		return fmt.Sprintf("rate cap of %d replies per %s reached", limit, r.opts.RateWindow)
+	}
+
+	key := cooldownKey{conversationID, rule.Name}
+	if until, ok := r.cooldowns[key]; ok && now.Before(until) {
+		return "rule cooldown until " + until.Format(time.RFC3339)
+	}
+
+	if !count {
+		return ""
+	}
+	r.replies[conversationID] = append(sent, now)
+	if rule.Cooldown > 0 {
+		r.cooldowns[key] = now.Add(rule.Cooldown)
+	}
+	return ""
+}
+
+// hold records a message as held back by quiet hours and reports whether it
+// was not already
+func (r *Responder) hold(messageID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.held[messageID] {
+		return false
+	}
+	r.held[messageID] = true
+	return true
+}
+
+// unhold forgets a message held back by quiet hours once it is handled
+func (r *Responder) unhold(messageID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.held, messageID)
+}
+
+// release undoes reserve after a failed send
+func (r *Responder) release(conversationID string, rule *Rule, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replies[conversationID] = slices.DeleteFunc(r.replies[conversationID], func(t time.Time) bool {
+		return t.Equal(now)
+	})
+	delete(r.cooldowns, cooldownKey{conversationID, rule.Name})
+}
+
+// phase returns the stay phase of the reservation linked to a conversation.
+// Conversations without a property or without a current, upcoming or recent
+// reservation fall back to their own dates.
+func (r *Responder) phase(ctx context.Context, conv hostex.Conversation, today hostex.Date) (Phase, error) {
+	if conv.PropertyID == 0 {
+		return PhaseOn(conv, today), nil
+	}
+	params := &hostex.ListReservationsParams{
+		PropertyID:        conv.PropertyID,
+		StartCheckOutDate: today.AddDays(-phaseLookback),
+		EndCheckInDate:    today.AddDays(phaseLookahead),
+	}
+
+	var linked *hostex.Reservation
+	for res, err := range r.svc.Reservations(ctx, params) {
+		if err != nil {
+			return "", fmt.Errorf("autoreply: failed to look up the reservation of %s: %w", conv.ID, err)
+		}
+		if res.ConversationID != conv.ID || res.Status == hostex.ReservationStatusCancelled {
+			continue
+		}
+		if linked == nil || closerStay(res, *linked, today) {
+			linked = &res
+		}
+	}
+	if linked == nil {
+		return PhaseOn(conv, today), nil
+	}
+	return phaseOf(linked.CheckInDate, linked.CheckOutDate, today), nil
+}
+
+// closerStay reports whether a is the more relevant stay on today: a stay in
+// progress, then the next upcoming one, then the latest past one
+func closerStay(a, b hostex.Reservation, today hostex.Date) bool {
+	rank := func(res hostex.Reservation) int {
+		switch phaseOf(res.CheckInDate, res.CheckOutDate, today) {
+		case PhaseDuringStay:
+			return 0
+		case PhaseBeforeCheckIn:
+			return 1
+		default:
+			return 2
+		}
+	}
+	switch ra, rb := rank(a), rank(b); {
+	case ra != rb:
+		return ra < rb
+	case ra == 1:
+		return a.CheckInDate.Before(b.CheckInDate)
+	default:
+		return a.CheckOutDate.After(b.CheckOutDate)
+	}
+}
+
+// audit records a decision if an audit log is configured
+func (r *Responder) audit(ctx context.Context, now time.Time, conv hostex.Conversation, msg hostex.Message, d Decision, err error) error {
+	if r.opts.Audit == nil {
+		return nil
+	}
+
+	entry := AuditEntry{
+		Time:           now,
+		ConversationID: conv.ID,
+		MessageID:      msg.ID,
+		Rule:           d.Rule,
+		Action:         d.Action,
+		Reply:          d.Reply,
+		Reason:         d.Reason,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return r.opts.Audit.Record(ctx, entry)
+}
@@ -0,0 +1,414 @@
+package autoreply_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/autoreply"
+	"github.com/keithah/hostex-go/hostexmock"
+	"github.com/keithah/hostex-go/watch"
+)
+
+var conv = hostex.Conversation{
+	ID:           "conv-1",
+	ChannelType:  hostex.ChannelAirbnb,
+	Guest:        hostex.Guest{Name: "Ada"},
+	PropertyID:   1001,
+	CheckInDate:  hostex.MustParseDate("2024-07-01"),
+	CheckOutDate: hostex.MustParseDate("2024-07-05"),
+}
+
+// booking is the reservation linked to conv
+var booking = hostex.Reservation{
+	ReservationCode: "HMABC123",
+	PropertyID:      1001,
+	Status:          hostex.ReservationStatusAccepted,
+	CheckInDate:     hostex.MustParseDate("2024-07-01"),
+	CheckOutDate:    hostex.MustParseDate("2024-07-05"),
+	ConversationID:  "conv-1",
+}
+
+// listing returns a ListReservationsFunc serving the given reservations
+func listing(reservations ...hostex.Reservation) func(context.Context, *hostex.ListReservationsParams) (*hostex.ReservationsResponse, error) {
+	return func(ctx context.Context, params *hostex.ListReservationsParams) (*hostex.ReservationsResponse, error) {
+		return &hostex.ReservationsResponse{Reservations: reservations, Total: len(reservations)}, nil
+	}
+}
+
+func guest(id, content string) hostex.Message {
+	return hostex.Message{ID: id, SenderRole: hostex.SenderGuest, Content: content}
+}
+
+// sender is a mock client that records sent replies
+func sender(sent *[]string) *hostexmock.Client {
+	return &hostexmock.Client{
+		SendMessageFunc: func(ctx context.Context, conversationID string, data hostex.SendMessageData) error {
+			*sent = append(*sent, data.Message)
+			return nil
+		},
+		ListReservationsFunc: listing(booking),
+	}
+}
+
+// clock returns a Now func fixed at the given time, which can be moved
+func clock(t time.Time) (func() time.Time, func(time.Time)) {
+	return func() time.Time { return t }, func(next time.Time) { t = next }
+}
+
+var rules = []autoreply.Rule{
+	{
+		Name:     "wifi",
+		Keywords: []string{"wifi", "wi-fi"},
+		Reply:    "Hi {{.Guest.Name}}, the wifi password is seaside123.",
+	},
+	{
+		Name:    "parking",
+		Pattern: regexp.MustCompile(`(?i)\bpark(ing)?\b`),
+		Phases:  []autoreply.Phase{autoreply.PhaseBeforeCheckIn},
+		Reply:   "Parking is in the driveway.",
+	},
+	{
+		Name:         "checkin-vrbo",
+		Keywords:     []string{"check-in"},
+		ChannelTypes: []hostex.ChannelType{hostex.ChannelVrbo},
+		Reply:        "Check-in is at 4pm.",
+	},
+	{
+		Name:        "checkin",
+		Keywords:    []string{"check-in"},
+		PropertyIDs: []int{1001},
+		Reply:       "Check-in is at 3pm ({{.Phase}}).",
+	},
+}
+
+func TestResponder_Rules(t *testing.T) {
+	var sent []string
+	now, _ := clock(time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC))
+	r, err := autoreply.New(sender(&sent), autoreply.Options{Rules: rules, Now: now})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		content string
+		rule    string
+		reply   string
+	}{
+		{"What's the WiFi?", "wifi", "Hi Ada, the wifi password is seaside123."},
+		{"Can we park a car?", "parking", "Parking is in the driveway."},
+		{"What time is check-in?", "checkin", "Check-in is at 3pm (before_check_in)."},
+		{"Is the spark plug fine?", "", ""},
+	}
+	for _, tt := range tests {
+		d, err := r.Handle(ctx, conv, guest("m", tt.content))
+		if err != nil {
+			t.Fatalf("Handle(%q) failed: %v", tt.content, err)
+		}
+		if d.Rule != tt.rule || d.Reply != tt.reply {
+			t.Errorf("Handle(%q) = %+v, want rule %q reply %q", tt.content, d, tt.rule, tt.reply)
+		}
+	}
+	if len(sent) != 3 {
+		t.Errorf("Expected 3 replies, got %v", sent)
+	}
+
+	// Host messages are never answered
+	if d, _ := r.Handle(ctx, conv, hostex.Message{SenderRole: hostex.SenderHost, Content: "wifi"}); d.Action != autoreply.ActionNone {
+		t.Errorf("Expected host message to be ignored, got %+v", d)
+	}
+}
+
+func TestPhaseOn(t *testing.T) {
+	tests := []struct {
+		date  string
+		phase autoreply.Phase
+	}{
+		{"2024-06-30", autoreply.PhaseBeforeCheckIn},
+		{"2024-07-01", autoreply.PhaseDuringStay},
+		{"2024-07-05", autoreply.PhaseDuringStay},
+		{"2024-07-06", autoreply.PhaseAfterCheckOut},
+	}
+	for _, tt := range tests {
+		if got := autoreply.PhaseOn(conv, hostex.MustParseDate(tt.date)); got != tt.phase {
+			t.Errorf("PhaseOn(%s) = %s, want %s", tt.date, got, tt.phase)
+		}
+	}
+	if got := autoreply.PhaseOn(hostex.Conversation{}, hostex.MustParseDate("2024-07-01")); got != autoreply.PhaseUnknown {
+		t.Errorf("Expected unknown phase without dates, got %s", got)
+	}
+}
+
+func TestResponder_PhaseFromReservation(t *testing.T) {
+	var sent []string
+	mock := sender(&sent)
+	now, _ := clock(time.Date(2024, 7, 2, 12, 0, 0, 0, time.UTC))
+	r, err := autoreply.New(mock, autoreply.Options{
+		Rules: []autoreply.Rule{{Name: "stay", Phases: []autoreply.Phase{autoreply.PhaseDuringStay}, Reply: "Enjoy your stay!"}},
+		Now:   now,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// The conversation still lists the guest's first stay; the reservation
+	// linked to it is in progress
+	repeat := conv
+	repeat.CheckInDate, repeat.CheckOutDate = hostex.MustParseDate("2024-05-01"), hostex.MustParseDate("2024-05-03")
+	earlier := booking
+	earlier.ReservationCode, earlier.CheckInDate, earlier.CheckOutDate = "HMOLD001", repeat.CheckInDate, repeat.CheckOutDate
+	other := booking
+	other.ReservationCode, other.ConversationID = "HMXYZ789", "conv-2"
+	mock.ListReservationsFunc = listing(earlier, other, booking)
+
+	if d, err := r.Handle(ctx, repeat, guest("m", "hi")); err != nil || d.Action != autoreply.ActionSent {
+		t.Errorf("Expected the in-progress stay to match, got %+v, %v", d, err)
+	}
+
+	// Without a linked reservation the conversation's dates are used
+	mock.ListReservationsFunc = listing(other)
+	if d, err := r.Handle(ctx, repeat, guest("m", "hi")); err != nil || d.Action != autoreply.ActionNone {
+		t.Errorf("Expected the conversation's past stay to be used, got %+v, %v", d, err)
+	}
+
+	// Rules without phases do not look the reservation up, and the lookup
+	// is limited to the conversation's property and stays around today
+	var lookups []hostex.ListReservationsParams
+	mock.ListReservationsFunc = func(ctx context.Context, params *hostex.ListReservationsParams) (*hostex.ReservationsResponse, error) {
+		lookups = append(lookups, *params)
+		return listing(booking)(ctx, params)
+	}
+	plain, err := autoreply.New(mock, autoreply.Options{Rules: []autoreply.Rule{{Name: "any", Reply: "Thanks!"}}, Now: now})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if d, err := plain.Handle(ctx, conv, guest("m", "hi")); err != nil || d.Action != autoreply.ActionSent {
+		t.Errorf("Expected a reply, got %+v, %v", d, err)
+	}
+	if len(lookups) != 0 {
+		t.Errorf("Expected no lookup without phases, got %+v", lookups)
+	}
+	r.Handle(ctx, conv, guest("m", "hi"))
+	if len(lookups) != 1 || lookups[0].PropertyID != 1001 || lookups[0].StartCheckOutDate.IsZero() || lookups[0].EndCheckInDate.IsZero() {
+		t.Errorf("Expected one lookup bounded by property and dates, got %+v", lookups)
+	}
+	noProperty := conv
+	noProperty.PropertyID = 0
+	r.Handle(ctx, noProperty, guest("m", "hi"))
+	if len(lookups) != 1 {
+		t.Errorf("Expected no lookup without a property, got %+v", lookups[1:])
+	}
+
+	// A failed lookup is retried by the watcher
+	fail := errors.New("lookup failed")
+	mock.ListReservationsFunc = func(ctx context.Context, params *hostex.ListReservationsParams) (*hostex.ReservationsResponse, error) {
+		return nil, fail
+	}
+	if err := r.OnGuestMessage(ctx, watch.GuestMessage{Conversation: conv, Message: guest("m", "hi")}); !errors.Is(err, fail) {
+		t.Errorf("Expected the lookup error, got %v", err)
+	}
+}
+
+func TestResponder_Limits(t *testing.T) {
+	var sent []string
+	now, setNow := clock(time.Date(2024, 6, 20, 23, 30, 0, 0, time.UTC))
+	audit := &autoreply.MemoryAuditLog{}
+
+	r, err := autoreply.New(sender(&sent), autoreply.Options{
+		Rules: []autoreply.Rule{
+			{Name: "wifi", Keywords: []string{"wifi"}, Reply: "Password: seaside123", Cooldown: time.Hour},
+			{Name: "any", Reply: "Thanks, we'll get back to you."},
+		},
+		QuietHours:                &autoreply.QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour},
+		MaxRepliesPerConversation: 2,
+		Audit:                     audit,
+		Now:                       now,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+	handle := func(content string) autoreply.Decision {
+		t.Helper()
+		d, err := r.Handle(ctx, conv, guest("m", content))
+		if err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		return d
+	}
+
+	// Quiet hours hold the reply back and make the watcher report the
+	// message again later
+	d, err := r.Handle(ctx, conv, guest("m", "wifi?"))
+	if !errors.Is(err, autoreply.ErrQuietHours) || d.Action != autoreply.ActionSkipped || d.Reason != "quiet hours" {
+		t.Errorf("Expected quiet hours to hold the reply back, got %+v, %v", d, err)
+	}
+	if err := r.OnGuestMessage(ctx, watch.GuestMessage{Conversation: conv, Message: guest("m", "wifi?")}); !errors.Is(err, autoreply.ErrQuietHours) {
+		t.Errorf("Expected OnGuestMessage to return ErrQuietHours, got %v", err)
+	}
+
+	setNow(time.Date(2024, 6, 21, 9, 0, 0, 0, time.UTC))
+	if d := handle("wifi?"); d.Action != autoreply.ActionSent {
+		t.Errorf("Expected reply after quiet hours, got %+v", d)
+	}
+	if d := handle("wifi again?"); d.Action != autoreply.ActionSkipped || !strings.Contains(d.Reason, "cooldown") {
+		t.Errorf("Expected rule cooldown, got %+v", d)
+	}
+	if d := handle("hello"); d.Action != autoreply.ActionSent {
+		t.Errorf("Expected second reply, got %+v", d)
+	}
+	if d := handle("hello?"); d.Action != autoreply.ActionSkipped || !strings.Contains(d.Reason, "rate cap") {
+		t.Errorf("Expected rate cap, got %+v", d)
+	}
+
+	// The cap resets once the window has passed
+	setNow(time.Date(2024, 6, 22, 9, 30, 0, 0, time.UTC))
+	if d := handle("hello?"); d.Action != autoreply.ActionSent {
+		t.Errorf("Expected reply in the next window, got %+v", d)
+	}
+
+	if len(sent) != 3 {
+		t.Errorf("Expected 3 replies, got %v", sent)
+	}
+	var actions []string
+	for _, e := range audit.Entries() {
+		actions = append(actions, string(e.Action))
+	}
+	// The held-back message is audited once, however often it is retried
+	if strings.Join(actions, ",") != "skipped,sent,skipped,sent,skipped,sent" {
+		t.Errorf("Unexpected audit log: %v", actions)
+	}
+}
+
+func TestResponder_QuietHoursAcrossPolls(t *testing.T) {
+	var sent []string
+	mock := sender(&sent)
+	inbox := conv
+	inbox.LastMessageAt = time.Date(2024, 6, 20, 23, 0, 0, 0, time.UTC)
+	mock.ListConversationsFunc = func(ctx context.Context, params *hostex.ListConversationsParams) (*hostex.ConversationsResponse, error) {
+		return &hostex.ConversationsResponse{Conversations: []hostex.Conversation{inbox}, Total: 1}, nil
+	}
+	mock.GetConversationFunc = func(ctx context.Context, id string) (*hostex.ConversationDetails, error) {
+		msg := guest("msg-1", "wifi?")
+		msg.CreatedAt = inbox.LastMessageAt
+		return &hostex.ConversationDetails{Messages: []hostex.Message{msg}}, nil
+	}
+
+	now, setNow := clock(time.Date(2024, 6, 20, 23, 30, 0, 0, time.UTC))
+	audit := &autoreply.MemoryAuditLog{}
+	r, err := autoreply.New(mock, autoreply.Options{
+		Rules:      rules,
+		QuietHours: &autoreply.QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour},
+		Audit:      audit,
+		Now:        now,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	w, err := watch.NewConversationWatcher(mock, watch.ConversationWatcherOptions{
+		Since:     inbox.LastMessageAt.Add(-time.Hour),
+		OnMessage: r.OnGuestMessage,
+	})
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// Every poll during quiet hours defers the message
+	for range 3 {
+		if _, err := w.Poll(ctx); !errors.Is(err, autoreply.ErrQuietHours) || !errors.Is(err, watch.ErrDeferred) {
+			t.Fatalf("Expected the message to be deferred, got %v", err)
+		}
+	}
+	if len(sent) != 0 || len(audit.Entries()) != 1 {
+		t.Fatalf("Expected one audit entry and no reply, got %v, %+v", sent, audit.Entries())
+	}
+
+	setNow(time.Date(2024, 6, 21, 7, 0, 0, 0, time.UTC))
+	if _, err := w.Poll(ctx); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	var actions []string
+	for _, e := range audit.Entries() {
+		actions = append(actions, string(e.Action))
+	}
+	if len(sent) != 1 || strings.Join(actions, ",") != "skipped,sent" {
+		t.Errorf("Expected one reply after quiet hours, got %v, audit %v", sent, actions)
+	}
+}
+
+func TestResponder_DryRunAndAudit(t *testing.T) {
+	mock := &hostexmock.Client{ListReservationsFunc: listing(booking)}
+	var buf bytes.Buffer
+
+	dryRules := append([]autoreply.Rule{{Name: "wifi", Keywords: []string{"wifi"}, Reply: "Password: seaside123", Cooldown: time.Hour}}, rules...)
+	r, err := autoreply.New(mock, autoreply.Options{
+		Rules:                     dryRules,
+		MaxRepliesPerConversation: 1,
+		DryRun:                    true,
+		Audit:                     autoreply.NewJSONAuditLog(&buf),
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// Dry-run replies count towards neither the cap nor the cooldown
+	for range 2 {
+		d, err := r.Handle(context.Background(), conv, guest("msg-7", "wifi please"))
+		if err != nil || d.Action != autoreply.ActionDryRun {
+			t.Fatalf("Expected dry run, got %+v, %v", d, err)
+		}
+	}
+	if mock.CallCount("SendMessage") != 0 {
+		t.Error("Expected no message to be sent in dry-run mode")
+	}
+	if line := buf.String(); !strings.Contains(line, `"action":"dry_run"`) || !strings.Contains(line, `"message_id":"msg-7"`) {
+		t.Errorf("Unexpected audit line: %s", line)
+	}
+}
+
+func TestResponder_SendFailure(t *testing.T) {
+	fail := errors.New("send failed")
+	calls := 0
+	mock := &hostexmock.Client{
+		SendMessageFunc: func(ctx context.Context, id string, data hostex.SendMessageData) error {
+			calls++
+			if calls == 1 {
+				return fail
+			}
+			return nil
+		},
+		ListReservationsFunc: listing(booking),
+	}
+
+	r, err := autoreply.New(mock, autoreply.Options{
+		Rules:                     []autoreply.Rule{{Name: "wifi", Keywords: []string{"wifi"}, Reply: "Password: seaside123", Cooldown: time.Hour}},
+		MaxRepliesPerConversation: 1,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// A failed send is not counted, so the watcher's retry can reply
+	msg := watch.GuestMessage{Conversation: conv, Message: guest("m", "wifi")}
+	if err := r.OnGuestMessage(context.Background(), msg); !errors.Is(err, fail) {
+		t.Fatalf("Expected send error, got %v", err)
+	}
+	if err := r.OnGuestMessage(context.Background(), msg); err != nil {
+		t.Errorf("Expected retry to succeed, got %v", err)
+	}
+}
+
+func TestNew_InvalidTemplate(t *testing.T) {
+	_, err := autoreply.New(&hostexmock.Client{}, autoreply.Options{Rules: []autoreply.Rule{{Name: "broken", Reply: "Hi {{.Guest.Name"}}})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Expected template error naming the rule, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package autoreply
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/keithah/hostex-go"
+)
+
+// Phase is where a guest is in their stay
+type Phase string
+
+// Stay phases
+const (
+	// PhaseUnknown is used for conversations without stay dates
+	PhaseUnknown Phase = "unknown"
+
+	// PhaseBeforeCheckIn lasts until the day before check-in
+	PhaseBeforeCheckIn Phase = "before_check_in"
+
+	// PhaseDuringStay runs from the check-in date to the check-out date,
+	// inclusive
+	PhaseDuringStay Phase = "during_stay"
+
+	// PhaseAfterCheckOut starts the day after check-out
+	PhaseAfterCheckOut Phase = "after_check_out"
+)
+
+// PhaseOn returns the stay phase on a date according to a conversation's
+// stay dates
+func PhaseOn(conv hostex.Conversation, today hostex.Date) Phase {
+	return phaseOf(conv.CheckInDate, conv.CheckOutDate, today)
+}
+
+// phaseOf returns the stay phase of a stay on a date
+func phaseOf(checkIn, checkOut, today hostex.Date) Phase {
+	switch {
+	case checkIn.IsZero() || checkOut.IsZero():
+		return PhaseUnknown
+	case today.Before(checkIn):
+		return PhaseBeforeCheckIn
+	case !today.After(checkOut):
+		return PhaseDuringStay
+	default:
+		return PhaseAfterCheckOut
+	}
+}
+
+// Rule describes messages to answer and the reply to send. Unset filters
+// match everything.
+type Rule struct {
+	// Name identifies the rule in the audit log
+	Name string
+
+	// Keywords match messages containing any of them, ignoring case
+	Keywords []string
+
+	// Pattern matches messages the regular expression matches. A message
+	// matches a rule with both Keywords and Pattern if either matches.
+	Pattern *regexp.Regexp
+
+	// ChannelTypes limits the rule to conversations on these channels
+	ChannelTypes []hostex.ChannelType
+
+	// PropertyIDs limits the rule to conversations about these properties
+	PropertyIDs []int
+
+	// Phases limits the rule to guests in these stay phases
+	Phases []Phase
+
+	// Reply is the text/template for the reply, executed with Data
+	Reply string
+
+	// Cooldown keeps the rule from answering the same conversation again
+	// within the duration (optional)
+	Cooldown time.Duration
+
+	tmpl *template.Template
+
+	// usesPhase is set when the rule needs the stay phase, for Phases or
+	// in its reply
+	usesPhase bool
+}
+
+// Data is the template data for a reply
+type Data struct {
+	Guest        hostex.Guest
+	Conversation hostex.Conversation
+	Message      hostex.Message
+	Phase        Phase
+}
+
+// matches reports whether the rule applies to a message, apart from Phases,
+// which need a lookup
+func (r *Rule) matches(conv hostex.Conversation, msg hostex.Message) bool {
+	if len(r.ChannelTypes) > 0 && !slices.Contains(r.ChannelTypes, conv.ChannelType) {
+		return false
+	}
+	if len(r.PropertyIDs) > 0 && !slices.Contains(r.PropertyIDs, conv.PropertyID) {
+		return false
+	}
+	return r.matchesContent(msg.Content)
+}
+
+// matchesContent checks the message text against Keywords and Pattern
+func (r *Rule) matchesContent(content string) bool {
+	if len(r.Keywords) == 0 && r.Pattern == nil {
+		return true
+	}
+
+	lower := strings.ToLower(content)
+	for _, kw := range r.Keywords {
+		if kw != "" && strings.Contains(lower, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return r.Pattern != nil && r.Pattern.MatchString(content)
+}
@@ -10,7 +10,7 @@ import (
 type AvailabilitiesResponse struct {
 	Listings []struct {
 		ID             int            `json:"id"`
-		ChannelType    string         `json:"channel_type"`
+		ChannelType    ChannelType    `json:"channel_type"`
 		ListingID      string         `json:"listing_id"`
 		Availabilities []Availability `json:"availabilities,omitempty"`
 	} `json:"listings"`
@@ -19,18 +19,31 @@ type AvailabilitiesResponse struct {
 // ListAvailabilitiesParams contains required parameters for listing availabilities
 type ListAvailabilitiesParams struct {
 	PropertyIDs string // Comma-separated property IDs
-	StartDate   string // YYYY-MM-DD
-	EndDate     string // YYYY-MM-DD
+	StartDate   Date
+	EndDate     Date
 }
 
 // ListAvailabilities retrieves availability information for properties
 func (c *Client) ListAvailabilities(ctx context.Context, params ListAvailabilitiesParams) (*AvailabilitiesResponse, error) {
+	if err := c.validate(params); err != nil {
+		return nil, err
+	}
+
 	urlParams := url.Values{}
 	urlParams.Set("property_ids", params.PropertyIDs)
-	urlParams.Set("start_date", params.StartDate)
-	urlParams.Set("end_date", params.EndDate)
+	if !params.StartDate.IsZero() {
+		urlParams.Set("start_date", params.StartDate.String())
+	}
+	if !params.EndDate.IsZero() {
+		urlParams.Set("end_date", params.EndDate.String())
+	}
 
-	resp, err := c.doRequest(ctx, "GET", "/availabilities", urlParams, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "ListAvailabilities",
+		Method:    "GET",
+		Endpoint:  "/availabilities",
+		Params:    urlParams,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +58,15 @@ func (c *Client) ListAvailabilities(ctx context.Context, params ListAvailabiliti
 
 // UpdateAvailabilities updates property availability status
 func (c *Client) UpdateAvailabilities(ctx context.Context, data UpdateAvailabilitiesData) error {
-	_, err := c.doRequest(ctx, "POST", "/availabilities", nil, data)
+	if err := c.validate(data); err != nil {
+		return err
+	}
+
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "UpdateAvailabilities",
+		Method:    "POST",
+		Endpoint:  "/availabilities",
+		Body:      data,
+	})
 	return err
 }
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
@@ -28,6 +29,11 @@ type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	token      string
+	roundTrip  RoundTrip
+	maxItems   int
+	images     *ImageOptions
+
+	skipValidation bool
 }
 
 // Config holds client configuration options
@@ -43,6 +49,48 @@ type Config struct {
 
 	// Timeout is the HTTP request timeout (optional, defaults to DefaultTimeout)
 	Timeout time.Duration
+
+	// RetryPolicy controls retries of failed requests (optional, defaults to
+	// DefaultRetryPolicy). Use NoRetry to disable retries.
+	RetryPolicy *RetryPolicy
+
+	// RateLimit is the maximum number of requests per second (optional,
+	// unlimited when zero)
+	RateLimit float64
+
+	// RateBurst is the number of requests that may be sent at once before
+	// RateLimit applies (optional, defaults to 1)
+	RateBurst int
+
+	// RateLimiter is a limiter to wait on before every request (optional,
+	// overrides RateLimit and RateBurst). Pass the same limiter to several
+	// clients to make them share one request budget.
+	RateLimiter Limiter
+
+	// Middlewares wrap every API call, the first one being the outermost
+	// (optional). They run outside the built-in retry and rate limiting.
+	Middlewares []Middleware
+
+	// Logger receives a structured record for every request attempt
+	// (optional). Access tokens and guest contact details are redacted.
+	Logger *slog.Logger
+
+	// LogBodies adds debug level records with request and response bodies
+	// when Logger is set (optional)
+	LogBodies bool
+
+	// MaxItems caps the number of items returned by the All* collectors
+	// (optional, defaults to DefaultMaxItems)
+	MaxItems int
+
+	// DisableValidation skips the local Validate checks on request payloads
+	// so they are sent to the API as-is; EnsureWebhooks still checks its
+	// URLs (optional)
+	DisableValidation bool
+
+	// Images sets the size limits of images sent with SendImage (optional,
+	// defaults to DefaultMaxImageDimension and DefaultMaxImageBytes)
+	Images *ImageOptions
 }
 
 // NewClient creates a new Hostex API client
@@ -67,37 +115,84 @@ func NewClient(config Config) (*Client, error) {
 		}
 	}
 
-	return &Client{
+	retryPolicy := DefaultRetryPolicy
+	if config.RetryPolicy != nil {
+		retryPolicy = *config.RetryPolicy
+	}
+
+	limiter := config.RateLimiter
+	if limiter == nil && config.RateLimit > 0 {
+		limiter = NewRateLimiter(config.RateLimit, config.RateBurst)
+	}
+
+	maxItems := config.MaxItems
+	if maxItems == 0 {
+		maxItems = DefaultMaxItems
+	}
+
+	c := &Client{
 		baseURL:    baseURL,
 		httpClient: httpClient,
 		token:      config.AccessToken,
-	}, nil
+		maxItems:   maxItems,
+		images:     config.Images,
+
+		skipValidation: config.DisableValidation,
+	}
+
+	middlewares := append([]Middleware{}, config.Middlewares...)
+	if retryPolicy.MaxAttempts > 1 {
+		middlewares = append(middlewares, RetryMiddleware(retryPolicy))
+	}
+	if limiter != nil {
+		middlewares = append(middlewares, RateLimitMiddleware(limiter))
+	}
+	if config.Logger != nil {
+		middlewares = append(middlewares, LoggingMiddleware(config.Logger, LoggingOptions{LogBodies: config.LogBodies}))
+	}
+	c.roundTrip = chain(c.send, middlewares...)
+
+	return c, nil
 }
 
-// APIResponse represents a standard Hostex API response
+// APIResponse represents a standard Hostex API response. Data holds the raw
+// JSON payload, which is decoded once directly into the result type.
 type APIResponse struct {
-	RequestID string      `json:"request_id"`
-	ErrorCode int         `json:"error_code"`
-	ErrorMsg  string      `json:"error_msg"`
-	Data      interface{} `json:"data,omitempty"`
+	RequestID string          `json:"request_id"`
+	ErrorCode int             `json:"error_code"`
+	ErrorMsg  string          `json:"error_msg"`
+	Data      json.RawMessage `json:"data,omitempty"`
 }
 
-// doRequest executes an HTTP request to the Hostex API
-func (c *Client) doRequest(ctx context.Context, method, endpoint string, params url.Values, body interface{}) (*APIResponse, error) {
-	u, err := url.Parse(c.baseURL + endpoint)
+// doRequest executes a Hostex API call through the client's middleware chain
+func (c *Client) doRequest(ctx context.Context, req *Request) (*APIResponse, error) {
+	req.Idempotent = isIdempotent(req.Method, req.Endpoint)
+	req.Attempt = 1
+
+	resp, err := c.roundTrip(ctx, req)
+	if resp == nil {
+		return nil, err
+	}
+	return resp.API, err
+}
+
+// send performs a single HTTP round trip to the Hostex API
+func (c *Client) send(ctx context.Context, r *Request) (*Response, error) {
+	u, err := url.Parse(c.baseURL + r.Endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("invalid endpoint: %w", err)
 	}
 
 	// Add query parameters
-	if params != nil {
-		u.RawQuery = params.Encode()
+	if r.Params != nil {
+		u.RawQuery = r.Params.Encode()
 	}
 
-	// Prepare request body
+	// Prepare request body. It is encoded on every attempt so retries
+	// always send the full body.
 	var bodyReader io.Reader
-	if body != nil {
-		bodyBytes, err := json.Marshal(body)
+	if r.Body != nil {
+		bodyBytes, err := json.Marshal(r.Body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
@@ -105,7 +200,7 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, params
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, method, u.String(), bodyReader)
+	req, err := http.NewRequestWithContext(ctx, r.Method, u.String(), bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -114,42 +209,67 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, params
 	req.Header.Set("Hostex-Access-Token", c.token)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", UserAgent)
+	for key, values := range r.Header {
+		req.Header[key] = values
+	}
 
 	// Execute request
-	resp, err := c.httpClient.Do(req)
+	httpResp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	defer resp.Body.Close()
+	defer httpResp.Body.Close()
+
+	resp := &Response{
+		StatusCode: httpResp.StatusCode,
+		Header:     httpResp.Header,
+	}
 
 	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return resp, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	retryAfter := parseRetryAfter(httpResp.Header.Get("Retry-After"))
+
 	// Parse response
 	var apiResp APIResponse
 	if err := json.Unmarshal(respBody, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return resp, &APIError{
+			StatusCode: httpResp.StatusCode,
+			Method:     r.Method,
+			Endpoint:   r.Endpoint,
+			Body:       truncateBody(respBody),
+			RetryAfter: retryAfter,
+			Err:        fmt.Errorf("failed to parse response: %w", err),
+		}
 	}
+	resp.API = &apiResp
 
 	// Check for API errors
 	if apiResp.ErrorCode != 200 {
-		return &apiResp, fmt.Errorf("API error %d: %s", apiResp.ErrorCode, apiResp.ErrorMsg)
+		return resp, &APIError{
+			StatusCode: httpResp.StatusCode,
+			Method:     r.Method,
+			Endpoint:   r.Endpoint,
+			RequestID:  apiResp.RequestID,
+			ErrorCode:  apiResp.ErrorCode,
+			ErrorMsg:   apiResp.ErrorMsg,
+			RetryAfter: retryAfter,
+		}
 	}
 
-	return &apiResp, nil
+	return resp, nil
 }
 
-// Helper function to convert interface{} to specific type
-func unmarshalData(data interface{}, v interface{}) error {
-	dataBytes, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal data: %w", err)
+// unmarshalData decodes the raw data of an API response into v
+func unmarshalData(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return nil
 	}
 
-	if err := json.Unmarshal(dataBytes, v); err != nil {
+	if err := json.Unmarshal(data, v); err != nil {
 		return fmt.Errorf("failed to unmarshal data: %w", err)
 	}
 
@@ -39,7 +39,12 @@ func (c *Client) ListConversations(ctx context.Context, params *ListConversation
 	urlParams.Set("offset", strconv.Itoa(offset))
 	urlParams.Set("limit", strconv.Itoa(limit))
 
-	resp, err := c.doRequest(ctx, "GET", "/conversations", urlParams, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "ListConversations",
+		Method:    "GET",
+		Endpoint:  "/conversations",
+		Params:    urlParams,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -54,14 +59,18 @@ func (c *Client) ListConversations(ctx context.Context, params *ListConversation
 
 // ConversationDetails represents detailed conversation information
 type ConversationDetails struct {
-	Guest       Guest     `json:"guest"`
-	ChannelType string    `json:"channel_type"`
-	Messages    []Message `json:"messages"`
+	Guest       Guest       `json:"guest"`
+	ChannelType ChannelType `json:"channel_type"`
+	Messages    []Message   `json:"messages"`
 }
 
 // GetConversation retrieves detailed information about a specific conversation
 func (c *Client) GetConversation(ctx context.Context, conversationID string) (*ConversationDetails, error) {
-	resp, err := c.doRequest(ctx, "GET", "/conversations/"+conversationID, nil, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "GetConversation",
+		Method:    "GET",
+		Endpoint:  "/conversations/" + conversationID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +85,11 @@ func (c *Client) GetConversation(ctx context.Context, conversationID string) (*C
 
 // SendMessage sends a message to a conversation
 func (c *Client) SendMessage(ctx context.Context, conversationID string, data SendMessageData) error {
-	_, err := c.doRequest(ctx, "POST", "/conversations/"+conversationID, nil, data)
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "SendMessage",
+		Method:    "POST",
+		Endpoint:  "/conversations/" + conversationID,
+		Body:      data,
+	})
 	return err
 }
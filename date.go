@@ -0,0 +1,193 @@
+package hostex
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// DateLayout is the layout of dates in the Hostex API
+const DateLayout = "2006-01-02"
+
+// Date is a calendar date without a time of day or time zone, as used for
+// check-in and check-out dates, calendars and date filters. It is encoded as
+// "YYYY-MM-DD" in JSON and query parameters. The zero Date is omitted from
+// query parameters and from JSON fields tagged omitzero.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// NewDate returns the date for year, month and day. Out of range values are
+// normalized, so NewDate(2025, 1, 32) is February 1st, 2025.
+func NewDate(year int, month time.Month, day int) Date {
+	return DateOf(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// DateOf returns the date of t in t's location
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{Year: year, Month: month, Day: day}
+}
+
+// Today returns the current date in loc
+func Today(loc *time.Location) Date {
+	return DateOf(time.Now().In(loc))
+}
+
+// ParseDate parses a date in the strict "YYYY-MM-DD" form, so "2026-1-5" is rejected
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD", s)
+	}
+	return DateOf(t), nil
+}
+
+// MustParseDate is like ParseDate but panics on invalid input. It is
+// intended for constants and tests.
+func MustParseDate(s string) Date {
+	d, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// ParseDates parses each string with ParseDate, easing migration of code
+// that built date lists as strings
+func ParseDates(values ...string) ([]Date, error) {
+	dates := make([]Date, 0, len(values))
+	for _, s := range values {
+		d, err := ParseDate(s)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, d)
+	}
+	return dates, nil
+}
+
+// String returns the date in "YYYY-MM-DD" form, or "" for the zero Date
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// IsZero reports whether d is the zero Date
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// IsValid reports whether d is a real calendar date
+func (d Date) IsValid() bool {
+	return NewDate(d.Year, d.Month, d.Day) == d
+}
+
+// In returns the time at midnight at the start of d in loc
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// AddDays returns d plus n days, which may be negative
+func (d Date) AddDays(n int) Date {
+	return NewDate(d.Year, d.Month, d.Day+n)
+}
+
+// Compare returns -1 if d is before other, +1 if it is after and 0 if they are equal
+func (d Date) Compare(other Date) int {
+	switch {
+	case d.Year != other.Year:
+		return cmp.Compare(d.Year, other.Year)
+	case d.Month != other.Month:
+		return cmp.Compare(int(d.Month), int(other.Month))
+	default:
+		return cmp.Compare(d.Day, other.Day)
+	}
+}
+
+// Before reports whether d is before other
+func (d Date) Before(other Date) bool {
+	return d.Compare(other) < 0
+}
+
+// After reports whether d is after other
+func (d Date) After(other Date) bool {
+	return d.Compare(other) > 0
+}
+
+// DaysUntil returns the number of days from d to other, negative if other is earlier
+func (d Date) DaysUntil(other Date) int {
+	return int(other.In(time.UTC).Sub(d.In(time.UTC)).Hours() / 24)
+}
+
+// NightsBetween returns the number of nights of a stay from checkIn to
+// checkOut, or 0 if checkOut is not after checkIn
+func NightsBetween(checkIn, checkOut Date) int {
+	return max(checkIn.DaysUntil(checkOut), 0)
+}
+
+// DateRange returns an iterator over the dates from start to end, both inclusive
+func DateRange(start, end Date) iter.Seq[Date] {
+	return func(yield func(Date) bool) {
+		for d := start; !d.After(end); d = d.AddDays(1) {
+			if !yield(d) {
+				return
+			}
+		}
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Besides "YYYY-MM-DD"
+// it accepts an empty string as the zero Date and RFC 3339 timestamps, whose
+// date part is used.
+func (d *Date) UnmarshalText(text []byte) error {
+	s := string(text)
+	if s == "" {
+		*d = Date{}
+		return nil
+	}
+
+	if len(s) > len(DateLayout) {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			*d = DateOf(t)
+			return nil
+		}
+	}
+
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts null and the forms
+// accepted by UnmarshalText.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Date{}
+		return nil
+	}
+
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("invalid date %s: expected a string", data)
+	}
+	return d.UnmarshalText(data[1 : len(data)-1])
+}
@@ -0,0 +1,99 @@
+package hostex_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+)
+
+func TestParseDate(t *testing.T) {
+	d, err := hostex.ParseDate("2026-01-05")
+	if err != nil {
+		t.Fatalf("ParseDate failed: %v", err)
+	}
+	if d != hostex.NewDate(2026, time.January, 5) {
+		t.Errorf("Expected 2026-01-05, got %v", d)
+	}
+
+	for _, invalid := range []string{"2026-1-5", "2026-02-30", "05/01/2026", ""} {
+		if _, err := hostex.ParseDate(invalid); err == nil {
+			t.Errorf("Expected ParseDate(%q) to fail", invalid)
+		}
+	}
+}
+
+func TestDate_Arithmetic(t *testing.T) {
+	checkIn := hostex.MustParseDate("2024-02-27")
+	checkOut := checkIn.AddDays(4)
+
+	if checkOut.String() != "2024-03-02" {
+		t.Errorf("Expected 2024-03-02 across leap day, got %s", checkOut)
+	}
+	if nights := hostex.NightsBetween(checkIn, checkOut); nights != 4 {
+		t.Errorf("Expected 4 nights, got %d", nights)
+	}
+	if nights := hostex.NightsBetween(checkOut, checkIn); nights != 0 {
+		t.Errorf("Expected 0 nights for reversed dates, got %d", nights)
+	}
+	if !checkIn.Before(checkOut) || !checkOut.After(checkIn) || checkIn.Compare(checkIn) != 0 {
+		t.Error("Expected check-in to sort before check-out")
+	}
+
+	var dates []string
+	for d := range hostex.DateRange(checkIn, checkOut) {
+		dates = append(dates, d.String())
+	}
+	if len(dates) != 5 || dates[0] != "2024-02-27" || dates[2] != "2024-02-29" || dates[4] != "2024-03-02" {
+		t.Errorf("Unexpected range %v", dates)
+	}
+}
+
+func TestDate_JSON(t *testing.T) {
+	var r hostex.Reservation
+	err := json.Unmarshal([]byte(`{"check_in_date":"2025-07-01","check_out_date":"2025-07-07T00:00:00Z"}`), &r)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if r.CheckInDate.String() != "2025-07-01" || r.CheckOutDate.String() != "2025-07-07" {
+		t.Errorf("Unexpected dates %s %s", r.CheckInDate, r.CheckOutDate)
+	}
+
+	if err := json.Unmarshal([]byte(`{"check_in_date":"2025-7-1"}`), &r); err == nil {
+		t.Error("Expected malformed date to fail")
+	}
+
+	body, err := json.Marshal(hostex.UpdateAvailabilitiesData{
+		PropertyIDs: []int{1},
+		Dates:       []hostex.Date{hostex.MustParseDate("2025-12-25")},
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expected := `{"property_ids":[1],"dates":["2025-12-25"],"available":false}`
+	if string(body) != expected {
+		t.Errorf("Expected %s, got %s", expected, body)
+	}
+}
+
+func TestDate_QueryEncoding(t *testing.T) {
+	var query string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		okHandler(w, r)
+	})
+
+	_, err := client.ListReservations(context.Background(), &hostex.ListReservationsParams{
+		StartCheckInDate: hostex.NewDate(2025, time.September, 1),
+	})
+	if err != nil {
+		t.Fatalf("ListReservations failed: %v", err)
+	}
+
+	if query != "start_check_in_date=2025-09-01" {
+		t.Errorf("Expected only start_check_in_date to be sent, got %q", query)
+	}
+}
@@ -0,0 +1,230 @@
+package hostex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// legacyAPIResponse is the response envelope used before Data was kept as raw JSON
+type legacyAPIResponse struct {
+	RequestID string      `json:"request_id"`
+	ErrorCode int         `json:"error_code"`
+	ErrorMsg  string      `json:"error_msg"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// legacyDecode decodes a response the way the client did before: into a
+// generic envelope, then re-marshals and re-unmarshals the data into v
+func legacyDecode(body []byte, v interface{}) error {
+	var resp legacyAPIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// rawDecode decodes a response the way the client does now
+func rawDecode(body []byte, v interface{}) error {
+	var resp APIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return err
+	}
+	return unmarshalData(resp.Data, v)
+}
+
+// reservationsFixture builds a ListReservations response with n reservations
+func reservationsFixture(tb testing.TB, n int) []byte {
+	tb.Helper()
+
+	bookedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	reservations := make([]Reservation, n)
+	for i := range reservations {
+		reservations[i] = Reservation{
+			ReservationCode:  fmt.Sprintf("0-HM%08d-abcdef", i),
+			StayCode:         fmt.Sprintf("0-%08d-stay", i),
+			ChannelID:        fmt.Sprintf("HM%08d", i),
+			PropertyID:       100000 + i%50,
+			ChannelType:      "airbnb",
+			ListingID:        fmt.Sprintf("%d", 900000000000000000+i),
+			CheckInDate:      MustParseDate("2025-07-01"),
+			CheckOutDate:     MustParseDate("2025-07-07"),
+			NumberOfGuests:   4,
+			NumberOfAdults:   2,
+			NumberOfChildren: 2,
+			Status:           "accepted",
+			GuestName:        "Guest Name",
+			GuestPhone:       "+15555550100",
+			GuestEmail:       "guest@example.com",
+			BookedAt:         &bookedAt,
+			CreatedAt:        &bookedAt,
+			ConversationID:   fmt.Sprintf("conv-%d", i),
+			Tags:             []string{"vip", "returning"},
+			CustomFields:     map[string]interface{}{"door": "front", "parking": "2"},
+		}
+	}
+
+	return fixtureEnvelope(tb, ReservationsResponse{Reservations: reservations, Total: n})
+}
+
+// calendarFixture builds a GetListingCalendar response covering a year for the given number of listings
+func calendarFixture(tb testing.TB, listings int) []byte {
+	tb.Helper()
+
+	type day struct {
+		Date      string `json:"date"`
+		Price     int    `json:"price"`
+		Inventory int    `json:"inventory"`
+		Available bool   `json:"available"`
+		MinStay   int    `json:"min_stay"`
+		MaxStay   int    `json:"max_stay"`
+	}
+	type listing struct {
+		ChannelType string `json:"channel_type"`
+		ListingID   string `json:"listing_id"`
+		Calendar    []day  `json:"calendar"`
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := struct {
+		Listings []listing `json:"listings"`
+	}{}
+	for l := 0; l < listings; l++ {
+		calendar := make([]day, 365)
+		for d := range calendar {
+			calendar[d] = day{
+				Date:      start.AddDate(0, 0, d).Format("2006-01-02"),
+				Price:     15000 + d*10,
+				Inventory: 1,
+				Available: d%3 != 0,
+				MinStay:   2,
+				MaxStay:   30,
+			}
+		}
+		data.Listings = append(data.Listings, listing{
+			ChannelType: "airbnb",
+			ListingID:   fmt.Sprintf("%d", 900000000000000000+l),
+			Calendar:    calendar,
+		})
+	}
+
+	return fixtureEnvelope(tb, data)
+}
+
+func fixtureEnvelope(tb testing.TB, data interface{}) []byte {
+	tb.Helper()
+
+	body, err := json.Marshal(legacyAPIResponse{
+		RequestID: "req-bench",
+		ErrorCode: 200,
+		ErrorMsg:  "Done.",
+		Data:      data,
+	})
+	if err != nil {
+		tb.Fatalf("Failed to build fixture: %v", err)
+	}
+	return body
+}
+
+func TestDecode_MatchesLegacy(t *testing.T) {
+	body := reservationsFixture(t, 10)
+
+	var legacy, raw ReservationsResponse
+	if err := legacyDecode(body, &legacy); err != nil {
+		t.Fatalf("Legacy decode failed: %v", err)
+	}
+	if err := rawDecode(body, &raw); err != nil {
+		t.Fatalf("Raw decode failed: %v", err)
+	}
+
+	legacyJSON, _ := json.Marshal(legacy)
+	rawJSON, _ := json.Marshal(raw)
+	if string(legacyJSON) != string(rawJSON) {
+		t.Errorf("Decoded results differ:\nlegacy: %s\nraw:    %s", legacyJSON, rawJSON)
+	}
+}
+
+func TestDecode_PreservesLargeNumbers(t *testing.T) {
+	body := []byte(`{"request_id":"req-1","error_code":200,"error_msg":"Done.","data":{"custom_fields":{"id":9007199254740993}}}`)
+
+	var result struct {
+		CustomFields struct {
+			ID int64 `json:"id"`
+		} `json:"custom_fields"`
+	}
+	if err := rawDecode(body, &result); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if result.CustomFields.ID != 9007199254740993 {
+		t.Errorf("Expected 9007199254740993, got %d", result.CustomFields.ID)
+	}
+}
+
+func benchmarkDecode(b *testing.B, body []byte, newResult func() interface{}) {
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+		for i := 0; i < b.N; i++ {
+			if err := legacyDecode(body, newResult()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("raw", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+		for i := 0; i < b.N; i++ {
+			if err := rawDecode(body, newResult()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDecode_Reservations(b *testing.B) {
+	body := reservationsFixture(b, 1000)
+	benchmarkDecode(b, body, func() interface{} { return &ReservationsResponse{} })
+}
+
+func BenchmarkDecode_ListingCalendar(b *testing.B) {
+	body := calendarFixture(b, 50)
+	benchmarkDecode(b, body, func() interface{} { return &ListingCalendarResponse{} })
+}
+
+func BenchmarkClient_ListReservations(b *testing.B) {
+	body := reservationsFixture(b, 1000)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		AccessToken: "bench-token",
+		BaseURL:     server.URL,
+		RetryPolicy: &NoRetry,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.ListReservations(ctx, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package hostex
+
+// The enum types below are plain strings so that values added to the Hostex
+// API later decode without error and are preserved as-is. Use IsValid to
+// check whether a value is one of the known constants.
+
+// ChannelType identifies a booking channel
+type ChannelType string
+
+// Known channel types
+const (
+	ChannelAirbnb       ChannelType = "airbnb"
+	ChannelBookingSite  ChannelType = "booking_site"
+	ChannelAgoda        ChannelType = "agoda"
+	ChannelExpedia      ChannelType = "expedia"
+	ChannelVrbo         ChannelType = "vrbo"
+	ChannelTrip         ChannelType = "trip"
+	ChannelHostexDirect ChannelType = "hostex_direct"
+	ChannelCustom       ChannelType = "custom"
+)
+
+// String returns the channel type as sent to the API
+func (c ChannelType) String() string {
+	return string(c)
+}
+
+// IsValid reports whether c is a known channel type
+func (c ChannelType) IsValid() bool {
+	switch c {
+	case ChannelAirbnb, ChannelBookingSite, ChannelAgoda, ChannelExpedia,
+		ChannelVrbo, ChannelTrip, ChannelHostexDirect, ChannelCustom:
+		return true
+	}
+	return false
+}
+
+// ReservationStatus is the status of a reservation
+type ReservationStatus string
+
+// Known reservation statuses
+const (
+	ReservationStatusWaitAccept ReservationStatus = "wait_accept"
+	ReservationStatusWaitPay    ReservationStatus = "wait_pay"
+	ReservationStatusAccepted   ReservationStatus = "accepted"
+	ReservationStatusCancelled  ReservationStatus = "cancelled"
+	ReservationStatusDenied     ReservationStatus = "denied"
+	ReservationStatusTimeout    ReservationStatus = "timeout"
+)
+
+// String returns the status as sent to the API
+func (s ReservationStatus) String() string {
+	return string(s)
+}
+
+// IsValid reports whether s is a known reservation status
+func (s ReservationStatus) IsValid() bool {
+	switch s {
+	case ReservationStatusWaitAccept, ReservationStatusWaitPay, ReservationStatusAccepted,
+		ReservationStatusCancelled, ReservationStatusDenied, ReservationStatusTimeout:
+		return true
+	}
+	return false
+}
+
+// ReviewStatus is the review state of a reservation
+type ReviewStatus string
+
+// Known review statuses
+const (
+	ReviewStatusPending           ReviewStatus = "pending"
+	ReviewStatusPendingHostReview ReviewStatus = "pending_host_review"
+	ReviewStatusReviewed          ReviewStatus = "reviewed"
+	ReviewStatusExpired           ReviewStatus = "expired"
+)
+
+// String returns the status as sent to the API
+func (s ReviewStatus) String() string {
+	return string(s)
+}
+
+// IsValid reports whether s is a known review status
+func (s ReviewStatus) IsValid() bool {
+	switch s {
+	case ReviewStatusPending, ReviewStatusPendingHostReview, ReviewStatusReviewed, ReviewStatusExpired:
+		return true
+	}
+	return false
+}
+
+// SenderRole identifies who sent a conversation message
+type SenderRole string
+
+// Known sender roles
+const (
+	SenderHost  SenderRole = "host"
+	SenderGuest SenderRole = "guest"
+)
+
+// String returns the role as sent by the API
+func (r SenderRole) String() string {
+	return string(r)
+}
+
+// IsValid reports whether r is a known sender role
+func (r SenderRole) IsValid() bool {
+	return r == SenderHost || r == SenderGuest
+}
@@ -0,0 +1,64 @@
+package hostex_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+)
+
+func TestEnums_DecodeUnknownValues(t *testing.T) {
+	var r hostex.Reservation
+	err := json.Unmarshal([]byte(`{"channel_type":"new_channel","status":"accepted"}`), &r)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if r.ChannelType != "new_channel" {
+		t.Errorf("Expected unknown channel type to be preserved, got %q", r.ChannelType)
+	}
+	if r.ChannelType.IsValid() {
+		t.Error("Expected unknown channel type to be invalid")
+	}
+	if r.Status != hostex.ReservationStatusAccepted || !r.Status.IsValid() {
+		t.Errorf("Expected accepted status, got %q", r.Status)
+	}
+
+	var m hostex.Message
+	if err := json.Unmarshal([]byte(`{"sender_role":"guest"}`), &m); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if m.SenderRole != hostex.SenderGuest {
+		t.Errorf("Expected guest sender, got %q", m.SenderRole)
+	}
+}
+
+func TestEnums_ListParamsValidation(t *testing.T) {
+	called := false
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		okHandler(w, r)
+	})
+	ctx := context.Background()
+
+	_, err := client.ListReservations(ctx, &hostex.ListReservationsParams{Status: "acepted"})
+	if !errors.Is(err, hostex.ErrValidation) {
+		t.Errorf("Expected validation error for reservation status, got %v", err)
+	}
+
+	_, err = client.ListReviews(ctx, &hostex.ListReviewsParams{ReviewStatus: "done"})
+	if !errors.Is(err, hostex.ErrValidation) {
+		t.Errorf("Expected validation error for review status, got %v", err)
+	}
+
+	if called {
+		t.Error("Expected invalid params to fail before sending a request")
+	}
+
+	if _, err := client.ListReviews(ctx, &hostex.ListReviewsParams{ReviewStatus: hostex.ReviewStatusReviewed}); err != nil {
+		t.Errorf("Expected valid review status to be accepted, got %v", err)
+	}
+}
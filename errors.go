@@ -0,0 +1,119 @@
+package hostex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors for classifying API failures with errors.Is
+var (
+	// ErrNotFound indicates the requested resource does not exist
+	ErrNotFound = errors.New("hostex: not found")
+
+	// ErrUnauthorized indicates a missing, invalid or insufficient access token
+	ErrUnauthorized = errors.New("hostex: unauthorized")
+
+	// ErrRateLimited indicates the request was throttled by the API
+	ErrRateLimited = errors.New("hostex: rate limited")
+
+	// ErrValidation indicates the API rejected the request parameters or payload
+	ErrValidation = errors.New("hostex: validation failed")
+)
+
+// maxErrorBodyLength is the maximum number of body bytes kept on an APIError
+const maxErrorBodyLength = 512
+
+// APIError is returned when the Hostex API responds with an error, or with a
+// response that cannot be parsed as a standard API response
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Method is the HTTP method of the failed request
+	Method string
+
+	// Endpoint is the API endpoint of the failed request, e.g. "/reservations"
+	Endpoint string
+
+	// RequestID is the Hostex request ID, useful when contacting support
+	RequestID string
+
+	// ErrorCode is the Hostex error code from the response body
+	ErrorCode int
+
+	// ErrorMsg is the Hostex error message from the response body
+	ErrorMsg string
+
+	// Body is the truncated raw response body, set when the response could not be parsed
+	Body string
+
+	// RetryAfter is the delay requested by the server via the Retry-After header, if any
+	RetryAfter time.Duration
+
+	// Err is the underlying parse error, if any
+	Err error
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: ", e.Method, e.Endpoint)
+
+	if e.Err != nil {
+		fmt.Fprintf(&b, "unexpected response (HTTP %d): %v", e.StatusCode, e.Err)
+		if e.Body != "" {
+			fmt.Fprintf(&b, ": %q", e.Body)
+		}
+	} else {
+		fmt.Fprintf(&b, "API error %d: %s", e.ErrorCode, e.ErrorMsg)
+	}
+
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request_id: %s)", e.RequestID)
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the underlying parse error, if any
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error matches one of the sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.code() == http.StatusNotFound
+	case ErrUnauthorized:
+		code := e.code()
+		return code == http.StatusUnauthorized || code == http.StatusForbidden
+	case ErrRateLimited:
+		return e.code() == http.StatusTooManyRequests
+	case ErrValidation:
+		code := e.code()
+		return code == http.StatusBadRequest || code == http.StatusUnprocessableEntity
+	}
+	return false
+}
+
+// code returns the status used for classification. Hostex error codes mirror
+// HTTP status codes, so the error code is preferred when present.
+func (e *APIError) code() int {
+	if e.ErrorCode != 0 {
+		return e.ErrorCode
+	}
+	return e.StatusCode
+}
+
+// truncateBody shortens a response body for inclusion in an APIError
+func truncateBody(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > maxErrorBodyLength {
+		return s[:maxErrorBodyLength] + "..."
+	}
+	return s
+}
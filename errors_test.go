@@ -0,0 +1,131 @@
+package hostex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+)
+
+// newTestClient returns a client with retries disabled that talks to handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *hostex.Client {
+	t.Helper()
+	return newTestClientWithConfig(t, hostex.Config{RetryPolicy: &hostex.NoRetry}, handler)
+}
+
+// newTestClientWithConfig returns a client built from config that talks to handler
+func newTestClientWithConfig(t *testing.T, config hostex.Config, handler http.HandlerFunc) *hostex.Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config.AccessToken = "test-token"
+	config.BaseURL = server.URL
+
+	client, err := hostex.NewClient(config)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	return client
+}
+
+func TestAPIError_Classification(t *testing.T) {
+	tests := []struct {
+		name      string
+		errorCode int
+		sentinel  error
+	}{
+		{"not found", 404, hostex.ErrNotFound},
+		{"unauthorized", 401, hostex.ErrUnauthorized},
+		{"forbidden", 403, hostex.ErrUnauthorized},
+		{"rate limited", 429, hostex.ErrRateLimited},
+		{"validation", 400, hostex.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"request_id":"req-123","error_code":` + strconv.Itoa(tt.errorCode) + `,"error_msg":"boom"}`))
+			})
+
+			_, err := client.ListProperties(context.Background(), nil)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("Expected errors.Is(err, %v) to be true, got false for %v", tt.sentinel, err)
+			}
+
+			var apiErr *hostex.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected *hostex.APIError, got %T", err)
+			}
+
+			if apiErr.RequestID != "req-123" {
+				t.Errorf("Expected request ID 'req-123', got '%s'", apiErr.RequestID)
+			}
+			if apiErr.ErrorCode != tt.errorCode {
+				t.Errorf("Expected error code %d, got %d", tt.errorCode, apiErr.ErrorCode)
+			}
+			if apiErr.Method != "GET" || apiErr.Endpoint != "/properties" {
+				t.Errorf("Expected GET /properties, got %s %s", apiErr.Method, apiErr.Endpoint)
+			}
+		})
+	}
+}
+
+func TestAPIError_NonJSONBody(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html><body>502 Bad Gateway" + strings.Repeat(".", 1000) + "</body></html>"))
+	})
+
+	_, err := client.ListProperties(context.Background(), nil)
+
+	var apiErr *hostex.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *hostex.APIError, got %T: %v", err, err)
+	}
+
+	if apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", apiErr.StatusCode)
+	}
+	if !strings.HasPrefix(apiErr.Body, "<html><body>502 Bad Gateway") {
+		t.Errorf("Expected body to contain gateway HTML, got %q", apiErr.Body)
+	}
+	if len(apiErr.Body) > 600 {
+		t.Errorf("Expected body to be truncated, got %d bytes", len(apiErr.Body))
+	}
+	if apiErr.Err == nil {
+		t.Error("Expected underlying parse error")
+	}
+}
+
+func TestAPIError_EmptyBody(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := client.ListProperties(context.Background(), nil)
+
+	var apiErr *hostex.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *hostex.APIError, got %T: %v", err, err)
+	}
+
+	if apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", apiErr.StatusCode)
+	}
+	if errors.Is(err, hostex.ErrNotFound) {
+		t.Error("Expected 502 not to match ErrNotFound")
+	}
+}
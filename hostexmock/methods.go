@@ -0,0 +1,427 @@
+package hostexmock
+
+import (
+	"context"
+	"io"
+	"iter"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/internal/paginate"
+)
+
+// ListProperties calls ListPropertiesFunc
+func (m *Client) ListProperties(ctx context.Context, params *hostex.ListPropertiesParams) (*hostex.PropertiesResponse, error) {
+	m.record("ListProperties", params)
+	if m.ListPropertiesFunc == nil {
+		return nil, notConfigured("ListProperties")
+	}
+	return m.ListPropertiesFunc(ctx, params)
+}
+
+// Properties calls PropertiesFunc, or pages through ListProperties if it is nil
+func (m *Client) Properties(ctx context.Context, params *hostex.ListPropertiesParams) iter.Seq2[hostex.Property, error] {
+	m.record("Properties", params)
+	if m.PropertiesFunc != nil {
+		return m.PropertiesFunc(ctx, params)
+	}
+
+	var p hostex.ListPropertiesParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]hostex.Property, int, error) {
+		page := p
+		page.Offset, page.Limit = offset, limit
+		resp, err := m.ListProperties(ctx, &page)
+		if err != nil || resp == nil {
+			return nil, 0, err
+		}
+		return resp.Properties, resp.Total, nil
+	})
+}
+
+// AllProperties calls AllPropertiesFunc, or collects Properties if it is nil
+func (m *Client) AllProperties(ctx context.Context, params *hostex.ListPropertiesParams) ([]hostex.Property, error) {
+	m.record("AllProperties", params)
+	if m.AllPropertiesFunc != nil {
+		return m.AllPropertiesFunc(ctx, params)
+	}
+	return hostex.Collect(m.Properties(ctx, params), 0)
+}
+
+// ListRoomTypes calls ListRoomTypesFunc
+func (m *Client) ListRoomTypes(ctx context.Context, params *hostex.ListRoomTypesParams) (*hostex.RoomTypesResponse, error) {
+	m.record("ListRoomTypes", params)
+	if m.ListRoomTypesFunc == nil {
+		return nil, notConfigured("ListRoomTypes")
+	}
+	return m.ListRoomTypesFunc(ctx, params)
+}
+
+// RoomTypes calls RoomTypesFunc, or pages through ListRoomTypes if it is nil
+func (m *Client) RoomTypes(ctx context.Context, params *hostex.ListRoomTypesParams) iter.Seq2[hostex.RoomType, error] {
+	m.record("RoomTypes", params)
+	if m.RoomTypesFunc != nil {
+		return m.RoomTypesFunc(ctx, params)
+	}
+
+	var p hostex.ListRoomTypesParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]hostex.RoomType, int, error) {
+		page := p
+		page.Offset, page.Limit = offset, limit
+		resp, err := m.ListRoomTypes(ctx, &page)
+		if err != nil || resp == nil {
+			return nil, 0, err
+		}
+		return resp.RoomTypes, resp.Total, nil
+	})
+}
+
+// AllRoomTypes calls AllRoomTypesFunc, or collects RoomTypes if it is nil
+func (m *Client) AllRoomTypes(ctx context.Context, params *hostex.ListRoomTypesParams) ([]hostex.RoomType, error) {
+	m.record("AllRoomTypes", params)
+	if m.AllRoomTypesFunc != nil {
+		return m.AllRoomTypesFunc(ctx, params)
+	}
+	return hostex.Collect(m.RoomTypes(ctx, params), 0)
+}
+
+// ListAvailabilities calls ListAvailabilitiesFunc
+func (m *Client) ListAvailabilities(ctx context.Context, params hostex.ListAvailabilitiesParams) (*hostex.AvailabilitiesResponse, error) {
+	m.record("ListAvailabilities", params)
+	if m.ListAvailabilitiesFunc == nil {
+		return nil, notConfigured("ListAvailabilities")
+	}
+	return m.ListAvailabilitiesFunc(ctx, params)
+}
+
+// UpdateAvailabilities calls UpdateAvailabilitiesFunc
+func (m *Client) UpdateAvailabilities(ctx context.Context, data hostex.UpdateAvailabilitiesData) error {
+	m.record("UpdateAvailabilities", data)
+	if m.UpdateAvailabilitiesFunc == nil {
+		return notConfigured("UpdateAvailabilities")
+	}
+	return m.UpdateAvailabilitiesFunc(ctx, data)
+}
+
+// ListReservations calls ListReservationsFunc
+func (m *Client) ListReservations(ctx context.Context, params *hostex.ListReservationsParams) (*hostex.ReservationsResponse, error) {
+	m.record("ListReservations", params)
+	if m.ListReservationsFunc == nil {
+		return nil, notConfigured("ListReservations")
+	}
+	return m.ListReservationsFunc(ctx, params)
+}
+
+// Reservations calls ReservationsFunc, or pages through ListReservations if it is nil
+func (m *Client) Reservations(ctx context.Context, params *hostex.ListReservationsParams) iter.Seq2[hostex.Reservation, error] {
+	m.record("Reservations", params)
+	if m.ReservationsFunc != nil {
+		return m.ReservationsFunc(ctx, params)
+	}
+
+	var p hostex.ListReservationsParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]hostex.Reservation, int, error) {
+		page := p
+		page.Offset, page.Limit = offset, limit
+		resp, err := m.ListReservations(ctx, &page)
+		if err != nil || resp == nil {
+			return nil, 0, err
+		}
+		return resp.Reservations, resp.Total, nil
+	})
+}
+
+// AllReservations calls AllReservationsFunc, or collects Reservations if it is nil
+func (m *Client) AllReservations(ctx context.Context, params *hostex.ListReservationsParams) ([]hostex.Reservation, error) {
+	m.record("AllReservations", params)
+	if m.AllReservationsFunc != nil {
+		return m.AllReservationsFunc(ctx, params)
+	}
+	return hostex.Collect(m.Reservations(ctx, params), 0)
+}
+
+// CreateReservation calls CreateReservationFunc
+func (m *Client) CreateReservation(ctx context.Context, data hostex.CreateReservationData) (*hostex.CreateReservationResponse, error) {
+	m.record("CreateReservation", data)
+	if m.CreateReservationFunc == nil {
+		return nil, notConfigured("CreateReservation")
+	}
+	return m.CreateReservationFunc(ctx, data)
+}
+
+// CancelReservation calls CancelReservationFunc
+func (m *Client) CancelReservation(ctx context.Context, reservationCode string) error {
+	m.record("CancelReservation", reservationCode)
+	if m.CancelReservationFunc == nil {
+		return notConfigured("CancelReservation")
+	}
+	return m.CancelReservationFunc(ctx, reservationCode)
+}
+
+// UpdateLockCode calls UpdateLockCodeFunc
+func (m *Client) UpdateLockCode(ctx context.Context, stayCode, lockCode string) error {
+	m.record("UpdateLockCode", stayCode, lockCode)
+	if m.UpdateLockCodeFunc == nil {
+		return notConfigured("UpdateLockCode")
+	}
+	return m.UpdateLockCodeFunc(ctx, stayCode, lockCode)
+}
+
+// GetCustomFields calls GetCustomFieldsFunc
+func (m *Client) GetCustomFields(ctx context.Context, stayCode string) (*hostex.CustomFieldsResponse, error) {
+	m.record("GetCustomFields", stayCode)
+	if m.GetCustomFieldsFunc == nil {
+		return nil, notConfigured("GetCustomFields")
+	}
+	return m.GetCustomFieldsFunc(ctx, stayCode)
+}
+
+// UpdateCustomFields calls UpdateCustomFieldsFunc
+func (m *Client) UpdateCustomFields(ctx context.Context, stayCode string, customFields map[string]interface{}) error {
+	m.record("UpdateCustomFields", stayCode, customFields)
+	if m.UpdateCustomFieldsFunc == nil {
+		return notConfigured("UpdateCustomFields")
+	}
+	return m.UpdateCustomFieldsFunc(ctx, stayCode, customFields)
+}
+
+// ListCustomChannels calls ListCustomChannelsFunc
+func (m *Client) ListCustomChannels(ctx context.Context) (*hostex.CustomChannelsResponse, error) {
+	m.record("ListCustomChannels")
+	if m.ListCustomChannelsFunc == nil {
+		return nil, notConfigured("ListCustomChannels")
+	}
+	return m.ListCustomChannelsFunc(ctx)
+}
+
+// ListIncomeMethods calls ListIncomeMethodsFunc
+func (m *Client) ListIncomeMethods(ctx context.Context) (*hostex.IncomeMethodsResponse, error) {
+	m.record("ListIncomeMethods")
+	if m.ListIncomeMethodsFunc == nil {
+		return nil, notConfigured("ListIncomeMethods")
+	}
+	return m.ListIncomeMethodsFunc(ctx)
+}
+
+// ListConversations calls ListConversationsFunc
+func (m *Client) ListConversations(ctx context.Context, params *hostex.ListConversationsParams) (*hostex.ConversationsResponse, error) {
+	m.record("ListConversations", params)
+	if m.ListConversationsFunc == nil {
+		return nil, notConfigured("ListConversations")
+	}
+	return m.ListConversationsFunc(ctx, params)
+}
+
+// Conversations calls ConversationsFunc, or pages through ListConversations if it is nil
+func (m *Client) Conversations(ctx context.Context, params *hostex.ListConversationsParams) iter.Seq2[hostex.Conversation, error] {
+	m.record("Conversations", params)
+	if m.ConversationsFunc != nil {
+		return m.ConversationsFunc(ctx, params)
+	}
+
+	var p hostex.ListConversationsParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]hostex.Conversation, int, error) {
+		page := p
+		page.Offset, page.Limit = offset, limit
+		resp, err := m.ListConversations(ctx, &page)
+		if err != nil || resp == nil {
+			return nil, 0, err
+		}
+		return resp.Conversations, resp.Total, nil
+	})
+}
+
+// AllConversations calls AllConversationsFunc, or collects Conversations if it is nil
+func (m *Client) AllConversations(ctx context.Context, params *hostex.ListConversationsParams) ([]hostex.Conversation, error) {
+	m.record("AllConversations", params)
+	if m.AllConversationsFunc != nil {
+		return m.AllConversationsFunc(ctx, params)
+	}
+	return hostex.Collect(m.Conversations(ctx, params), 0)
+}
+
+// GetConversation calls GetConversationFunc
+func (m *Client) GetConversation(ctx context.Context, conversationID string) (*hostex.ConversationDetails, error) {
+	m.record("GetConversation", conversationID)
+	if m.GetConversationFunc == nil {
+		return nil, notConfigured("GetConversation")
+	}
+	return m.GetConversationFunc(ctx, conversationID)
+}
+
+// SendMessage calls SendMessageFunc
+func (m *Client) SendMessage(ctx context.Context, conversationID string, data hostex.SendMessageData) error {
+	m.record("SendMessage", conversationID, data)
+	if m.SendMessageFunc == nil {
+		return notConfigured("SendMessage")
+	}
+	return m.SendMessageFunc(ctx, conversationID, data)
+}
+
+// SendImage calls SendImageFunc, or encodes the image and calls SendMessage if it is nil
+func (m *Client) SendImage(ctx context.Context, conversationID string, r io.Reader) error {
+	m.record("SendImage", conversationID, r)
+	if m.SendImageFunc != nil {
+		return m.SendImageFunc(ctx, conversationID, r)
+	}
+
+	encoded, err := hostex.EncodeImage(r, nil)
+	if err != nil {
+		return err
+	}
+	return m.SendMessage(ctx, conversationID, hostex.SendMessageData{JpegBase64: encoded})
+}
+
+// SendTextAndImage calls SendTextAndImageFunc, or encodes the image and
+// calls SendMessage for the text and then the image if it is nil
+func (m *Client) SendTextAndImage(ctx context.Context, conversationID, text string, r io.Reader) error {
+	m.record("SendTextAndImage", conversationID, text, r)
+	if m.SendTextAndImageFunc != nil {
+		return m.SendTextAndImageFunc(ctx, conversationID, text, r)
+	}
+
+	encoded, err := hostex.EncodeImage(r, nil)
+	if err != nil {
+		return err
+	}
+	if err := m.SendMessage(ctx, conversationID, hostex.SendMessageData{Message: text}); err != nil {
+		return err
+	}
+	return m.SendMessage(ctx, conversationID, hostex.SendMessageData{JpegBase64: encoded})
+}
+
+// GetListingCalendar calls GetListingCalendarFunc
+func (m *Client) GetListingCalendar(ctx context.Context, data hostex.GetListingCalendarData) (*hostex.ListingCalendarResponse, error) {
+	m.record("GetListingCalendar", data)
+	if m.GetListingCalendarFunc == nil {
+		return nil, notConfigured("GetListingCalendar")
+	}
+	return m.GetListingCalendarFunc(ctx, data)
+}
+
+// UpdateListingPrices calls UpdateListingPricesFunc
+func (m *Client) UpdateListingPrices(ctx context.Context, data hostex.UpdateListingPricesData) error {
+	m.record("UpdateListingPrices", data)
+	if m.UpdateListingPricesFunc == nil {
+		return notConfigured("UpdateListingPrices")
+	}
+	return m.UpdateListingPricesFunc(ctx, data)
+}
+
+// UpdateListingInventories calls UpdateListingInventoriesFunc
+func (m *Client) UpdateListingInventories(ctx context.Context, data hostex.UpdateListingInventoriesData) error {
+	m.record("UpdateListingInventories", data)
+	if m.UpdateListingInventoriesFunc == nil {
+		return notConfigured("UpdateListingInventories")
+	}
+	return m.UpdateListingInventoriesFunc(ctx, data)
+}
+
+// UpdateListingRestrictions calls UpdateListingRestrictionsFunc
+func (m *Client) UpdateListingRestrictions(ctx context.Context, data hostex.UpdateListingRestrictionsData) error {
+	m.record("UpdateListingRestrictions", data)
+	if m.UpdateListingRestrictionsFunc == nil {
+		return notConfigured("UpdateListingRestrictions")
+	}
+	return m.UpdateListingRestrictionsFunc(ctx, data)
+}
+
+// ListReviews calls ListReviewsFunc
+func (m *Client) ListReviews(ctx context.Context, params *hostex.ListReviewsParams) (*hostex.ReviewsResponse, error) {
+	m.record("ListReviews", params)
+	if m.ListReviewsFunc == nil {
+		return nil, notConfigured("ListReviews")
+	}
+	return m.ListReviewsFunc(ctx, params)
+}
+
+// Reviews calls ReviewsFunc, or pages through ListReviews if it is nil
+func (m *Client) Reviews(ctx context.Context, params *hostex.ListReviewsParams) iter.Seq2[hostex.Review, error] {
+	m.record("Reviews", params)
+	if m.ReviewsFunc != nil {
+		return m.ReviewsFunc(ctx, params)
+	}
+
+	var p hostex.ListReviewsParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]hostex.Review, int, error) {
+		page := p
+		page.Offset, page.Limit = offset, limit
+		resp, err := m.ListReviews(ctx, &page)
+		if err != nil || resp == nil {
+			return nil, 0, err
+		}
+		return resp.Reviews, resp.Total, nil
+	})
+}
+
+// AllReviews calls AllReviewsFunc, or collects Reviews if it is nil
+func (m *Client) AllReviews(ctx context.Context, params *hostex.ListReviewsParams) ([]hostex.Review, error) {
+	m.record("AllReviews", params)
+	if m.AllReviewsFunc != nil {
+		return m.AllReviewsFunc(ctx, params)
+	}
+	return hostex.Collect(m.Reviews(ctx, params), 0)
+}
+
+// CreateReview calls CreateReviewFunc
+func (m *Client) CreateReview(ctx context.Context, reservationCode string, data hostex.CreateReviewData) error {
+	m.record("CreateReview", reservationCode, data)
+	if m.CreateReviewFunc == nil {
+		return notConfigured("CreateReview")
+	}
+	return m.CreateReviewFunc(ctx, reservationCode, data)
+}
+
+// ListWebhooks calls ListWebhooksFunc
+func (m *Client) ListWebhooks(ctx context.Context) (*hostex.WebhooksResponse, error) {
+	m.record("ListWebhooks")
+	if m.ListWebhooksFunc == nil {
+		return nil, notConfigured("ListWebhooks")
+	}
+	return m.ListWebhooksFunc(ctx)
+}
+
+// CreateWebhook calls CreateWebhookFunc
+func (m *Client) CreateWebhook(ctx context.Context, webhookURL string) (*hostex.CreateWebhookResponse, error) {
+	m.record("CreateWebhook", webhookURL)
+	if m.CreateWebhookFunc == nil {
+		return nil, notConfigured("CreateWebhook")
+	}
+	return m.CreateWebhookFunc(ctx, webhookURL)
+}
+
+// DeleteWebhook calls DeleteWebhookFunc
+func (m *Client) DeleteWebhook(ctx context.Context, webhookID int) error {
+	m.record("DeleteWebhook", webhookID)
+	if m.DeleteWebhookFunc == nil {
+		return notConfigured("DeleteWebhook")
+	}
+	return m.DeleteWebhookFunc(ctx, webhookID)
+}
+
+// EnsureWebhooks calls EnsureWebhooksFunc
+func (m *Client) EnsureWebhooks(ctx context.Context, desiredURLs []string, opts *hostex.EnsureWebhooksOptions) (*hostex.EnsureWebhooksReport, error) {
+	m.record("EnsureWebhooks", desiredURLs, opts)
+	if m.EnsureWebhooksFunc == nil {
+		return nil, notConfigured("EnsureWebhooks")
+	}
+	return m.EnsureWebhooksFunc(ctx, desiredURLs, opts)
+}
@@ -0,0 +1,138 @@
+// Package hostexmock provides a programmable mock of the Hostex API for unit
+// testing code that depends on the hostex service interfaces.
+//
+// Each method of Client calls the matching Func field, e.g. ListReservations
+// calls ListReservationsFunc, and records the call. Methods whose Func is nil
+// return ErrNotConfigured, except the iterator and All* methods, which page
+// through the corresponding List method by default, and the image methods,
+// which encode the image and call SendMessage.
+//
+//	mock := &hostexmock.Client{
+//		ListReservationsFunc: func(ctx context.Context, params *hostex.ListReservationsParams) (*hostex.ReservationsResponse, error) {
+//			return &hostex.ReservationsResponse{Reservations: fixtures, Total: len(fixtures)}, nil
+//		},
+//	}
+//	runNightlyReport(ctx, mock)
+//	if mock.CallCount("ListReservations") != 1 { ... }
+package hostexmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+
+	"github.com/keithah/hostex-go"
+)
+
+// ErrNotConfigured is returned by methods whose Func field is nil
+var ErrNotConfigured = errors.New("hostexmock: method not configured")
+
+// Call is a recorded method call. Args holds the arguments after the context.
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// Client is a mock implementation of hostex.API and hostex.ImageSender. It
+// is safe for concurrent use as long as the Func fields are not changed
+// while it is in use.
+type Client struct {
+	ListPropertiesFunc       func(ctx context.Context, params *hostex.ListPropertiesParams) (*hostex.PropertiesResponse, error)
+	PropertiesFunc           func(ctx context.Context, params *hostex.ListPropertiesParams) iter.Seq2[hostex.Property, error]
+	AllPropertiesFunc        func(ctx context.Context, params *hostex.ListPropertiesParams) ([]hostex.Property, error)
+	ListRoomTypesFunc        func(ctx context.Context, params *hostex.ListRoomTypesParams) (*hostex.RoomTypesResponse, error)
+	RoomTypesFunc            func(ctx context.Context, params *hostex.ListRoomTypesParams) iter.Seq2[hostex.RoomType, error]
+	AllRoomTypesFunc         func(ctx context.Context, params *hostex.ListRoomTypesParams) ([]hostex.RoomType, error)
+	ListAvailabilitiesFunc   func(ctx context.Context, params hostex.ListAvailabilitiesParams) (*hostex.AvailabilitiesResponse, error)
+	UpdateAvailabilitiesFunc func(ctx context.Context, data hostex.UpdateAvailabilitiesData) error
+
+	ListReservationsFunc   func(ctx context.Context, params *hostex.ListReservationsParams) (*hostex.ReservationsResponse, error)
+	ReservationsFunc       func(ctx context.Context, params *hostex.ListReservationsParams) iter.Seq2[hostex.Reservation, error]
+	AllReservationsFunc    func(ctx context.Context, params *hostex.ListReservationsParams) ([]hostex.Reservation, error)
+	CreateReservationFunc  func(ctx context.Context, data hostex.CreateReservationData) (*hostex.CreateReservationResponse, error)
+	CancelReservationFunc  func(ctx context.Context, reservationCode string) error
+	UpdateLockCodeFunc     func(ctx context.Context, stayCode, lockCode string) error
+	GetCustomFieldsFunc    func(ctx context.Context, stayCode string) (*hostex.CustomFieldsResponse, error)
+	UpdateCustomFieldsFunc func(ctx context.Context, stayCode string, customFields map[string]interface{}) error
+	ListCustomChannelsFunc func(ctx context.Context) (*hostex.CustomChannelsResponse, error)
+	ListIncomeMethodsFunc  func(ctx context.Context) (*hostex.IncomeMethodsResponse, error)
+
+	ListConversationsFunc func(ctx context.Context, params *hostex.ListConversationsParams) (*hostex.ConversationsResponse, error)
+	ConversationsFunc     func(ctx context.Context, params *hostex.ListConversationsParams) iter.Seq2[hostex.Conversation, error]
+	AllConversationsFunc  func(ctx context.Context, params *hostex.ListConversationsParams) ([]hostex.Conversation, error)
+	GetConversationFunc   func(ctx context.Context, conversationID string) (*hostex.ConversationDetails, error)
+	SendMessageFunc       func(ctx context.Context, conversationID string, data hostex.SendMessageData) error
+	SendImageFunc         func(ctx context.Context, conversationID string, r io.Reader) error
+	SendTextAndImageFunc  func(ctx context.Context, conversationID, text string, r io.Reader) error
+
+	GetListingCalendarFunc        func(ctx context.Context, data hostex.GetListingCalendarData) (*hostex.ListingCalendarResponse, error)
+	UpdateListingPricesFunc       func(ctx context.Context, data hostex.UpdateListingPricesData) error
+	UpdateListingInventoriesFunc  func(ctx context.Context, data hostex.UpdateListingInventoriesData) error
+	UpdateListingRestrictionsFunc func(ctx context.Context, data hostex.UpdateListingRestrictionsData) error
+
+	ListReviewsFunc  func(ctx context.Context, params *hostex.ListReviewsParams) (*hostex.ReviewsResponse, error)
+	ReviewsFunc      func(ctx context.Context, params *hostex.ListReviewsParams) iter.Seq2[hostex.Review, error]
+	AllReviewsFunc   func(ctx context.Context, params *hostex.ListReviewsParams) ([]hostex.Review, error)
+	CreateReviewFunc func(ctx context.Context, reservationCode string, data hostex.CreateReviewData) error
+
+	ListWebhooksFunc   func(ctx context.Context) (*hostex.WebhooksResponse, error)
+	CreateWebhookFunc  func(ctx context.Context, webhookURL string) (*hostex.CreateWebhookResponse, error)
+	DeleteWebhookFunc  func(ctx context.Context, webhookID int) error
+	EnsureWebhooksFunc func(ctx context.Context, desiredURLs []string, opts *hostex.EnsureWebhooksOptions) (*hostex.EnsureWebhooksReport, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var (
+	_ hostex.API         = (*Client)(nil)
+	_ hostex.ImageSender = (*Client)(nil)
+)
+
+// record appends a call to the call log
+func (m *Client) record(method string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns all recorded calls, in order
+func (m *Client) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the recorded calls of a method, e.g. "SendMessage"
+func (m *Client) CallsTo(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var matched []Call
+	for _, c := range m.calls {
+		if c.Method == method {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// CallCount returns the number of recorded calls of a method
+func (m *Client) CallCount(method string) int {
+	return len(m.CallsTo(method))
+}
+
+// Reset forgets the recorded calls
+func (m *Client) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+// notConfigured returns the error for a method without a Func
+func notConfigured(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotConfigured, method)
+}
@@ -0,0 +1,124 @@
+package hostexmock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/hostexmock"
+)
+
+// greetArrivals messages every guest checking in on day, as an example of
+// code that depends on narrow service interfaces
+func greetArrivals(ctx context.Context, reservations hostex.ReservationService, conversations hostex.ConversationService, day hostex.Date) error {
+	arrivals, err := reservations.AllReservations(ctx, &hostex.ListReservationsParams{
+		Status:           hostex.ReservationStatusAccepted,
+		StartCheckInDate: day,
+		EndCheckInDate:   day,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range arrivals {
+		if err := conversations.SendMessage(ctx, r.ConversationID, hostex.SendMessageData{Message: "Welcome, " + r.GuestName + "!"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestClient_ProgrammedResponses(t *testing.T) {
+	day := hostex.MustParseDate("2024-07-01")
+	fixtures := []hostex.Reservation{
+		{ReservationCode: "A", GuestName: "Ada", ConversationID: "conv-a"},
+		{ReservationCode: "B", GuestName: "Grace", ConversationID: "conv-b"},
+		{ReservationCode: "C", GuestName: "Edsger", ConversationID: "conv-c"},
+	}
+
+	mock := &hostexmock.Client{
+		ListReservationsFunc: func(ctx context.Context, params *hostex.ListReservationsParams) (*hostex.ReservationsResponse, error) {
+			// Serve two per page to exercise the default iterator
+			end := min(params.Offset+2, len(fixtures))
+			return &hostex.ReservationsResponse{Reservations: fixtures[params.Offset:end], Total: len(fixtures)}, nil
+		},
+		SendMessageFunc: func(ctx context.Context, conversationID string, data hostex.SendMessageData) error {
+			return nil
+		},
+	}
+
+	if err := greetArrivals(context.Background(), mock, mock, day); err != nil {
+		t.Fatalf("greetArrivals failed: %v", err)
+	}
+
+	if n := mock.CallCount("ListReservations"); n != 2 {
+		t.Errorf("Expected 2 pages to be fetched, got %d", n)
+	}
+
+	sends := mock.CallsTo("SendMessage")
+	if len(sends) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(sends))
+	}
+	if id := sends[1].Args[0]; id != "conv-b" {
+		t.Errorf("Expected second message to conv-b, got %v", id)
+	}
+	if data := sends[1].Args[1].(hostex.SendMessageData); data.Message != "Welcome, Grace!" {
+		t.Errorf("Unexpected message %q", data.Message)
+	}
+
+	params := mock.CallsTo("AllReservations")[0].Args[0].(*hostex.ListReservationsParams)
+	if params.StartCheckInDate != day {
+		t.Errorf("Expected check-in filter %s, got %s", day, params.StartCheckInDate)
+	}
+
+	mock.Reset()
+	if len(mock.Calls()) != 0 {
+		t.Error("Expected Reset to clear recorded calls")
+	}
+}
+
+func TestClient_NotConfigured(t *testing.T) {
+	mock := &hostexmock.Client{}
+
+	if _, err := mock.ListWebhooks(context.Background()); !errors.Is(err, hostexmock.ErrNotConfigured) {
+		t.Errorf("Expected ErrNotConfigured, got %v", err)
+	}
+	if err := mock.DeleteWebhook(context.Background(), 1); !errors.Is(err, hostexmock.ErrNotConfigured) {
+		t.Errorf("Expected ErrNotConfigured, got %v", err)
+	}
+	if _, err := mock.AllReviews(context.Background(), nil); !errors.Is(err, hostexmock.ErrNotConfigured) {
+		t.Errorf("Expected ErrNotConfigured from the default iterator, got %v", err)
+	}
+	if n := mock.CallCount("DeleteWebhook"); n != 1 {
+		t.Errorf("Expected unconfigured calls to be recorded, got %d", n)
+	}
+}
+
+func TestClient_DefaultIteratorsRangeAgain(t *testing.T) {
+	reservations := []hostex.Reservation{{ReservationCode: "A"}, {ReservationCode: "B"}, {ReservationCode: "C"}}
+	conversations := []hostex.Conversation{{ID: "conv-a"}, {ID: "conv-b"}, {ID: "conv-c"}}
+
+	mock := &hostexmock.Client{
+		ListReservationsFunc: func(ctx context.Context, params *hostex.ListReservationsParams) (*hostex.ReservationsResponse, error) {
+			end := min(params.Offset+2, len(reservations))
+			return &hostex.ReservationsResponse{Reservations: reservations[params.Offset:end], Total: len(reservations)}, nil
+		},
+		ListConversationsFunc: func(ctx context.Context, params *hostex.ListConversationsParams) (*hostex.ConversationsResponse, error) {
+			end := min(params.Offset+2, len(conversations))
+			return &hostex.ConversationsResponse{Conversations: conversations[params.Offset:end], Total: len(conversations)}, nil
+		},
+	}
+
+	ctx := context.Background()
+	reservationSeq := mock.Reservations(ctx, nil)
+	conversationSeq := mock.Conversations(ctx, nil)
+	for round := range 2 {
+		if got, err := hostex.Collect(reservationSeq, 0); err != nil || len(got) != 3 {
+			t.Errorf("Range %d: expected 3 reservations, got %d, %v", round+1, len(got), err)
+		}
+		if got, err := hostex.Collect(conversationSeq, 0); err != nil || len(got) != 3 {
+			t.Errorf("Range %d: expected 3 conversations, got %d, %v", round+1, len(got), err)
+		}
+	}
+}
@@ -0,0 +1,766 @@
+package hostextest
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keithah/hostex-go"
+)
+
+const (
+	// defaultLimit is the page size when a list request does not set limit
+	defaultLimit = 20
+
+	// maxLimit is the largest page size the server accepts
+	maxLimit = 100
+)
+
+// routes registers the Hostex API endpoints
+func (s *Server) routes(mux *http.ServeMux) {
+	s.handle(mux, "GET /properties", s.listProperties)
+	s.handle(mux, "GET /room_types", s.listRoomTypes)
+
+	s.handle(mux, "GET /reservations", s.listReservations)
+	s.handle(mux, "POST /reservations", s.createReservation)
+	s.handle(mux, "DELETE /reservations/{code}", s.cancelReservation)
+	s.handle(mux, "PATCH /reservations/{code}/check_in_details", s.updateLockCode)
+	s.handle(mux, "GET /reservations/{code}/custom_fields", s.getCustomFields)
+	s.handle(mux, "PATCH /reservations/{code}/custom_fields", s.updateCustomFields)
+
+	s.handle(mux, "GET /availabilities", s.listAvailabilities)
+	s.handle(mux, "POST /availabilities", s.updateAvailabilities)
+
+	s.handle(mux, "POST /listings/calendar", s.getListingCalendar)
+	s.handle(mux, "POST /listings/prices", s.updateListingPrices)
+	s.handle(mux, "POST /listings/inventories", s.updateListingInventories)
+	s.handle(mux, "POST /listings/restrictions", s.updateListingRestrictions)
+
+	s.handle(mux, "GET /conversations", s.listConversations)
+	s.handle(mux, "GET /conversations/{id}", s.getConversation)
+	s.handle(mux, "POST /conversations/{id}", s.sendMessage)
+
+	s.handle(mux, "GET /reviews", s.listReviews)
+	s.handle(mux, "POST /reviews/{code}", s.createReview)
+
+	s.handle(mux, "GET /webhooks", s.listWebhooks)
+	s.handle(mux, "POST /webhooks", s.createWebhook)
+	s.handle(mux, "DELETE /webhooks/{id}", s.deleteWebhook)
+
+	s.handle(mux, "GET /custom_channels", s.listCustomChannels)
+	s.handle(mux, "GET /income_methods", s.listIncomeMethods)
+
+	s.handle(mux, "/", func(r *http.Request, body []byte) (any, error) {
+		return nil, errorf(http.StatusNotFound, "unknown endpoint %s %s", r.Method, r.URL.Path)
+	})
+}
+
+// errorf returns an *apiError with a formatted message
+func errorf(code int, format string, args ...any) error {
+	return &apiError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// decode decodes a JSON request body into v
+func decode(body []byte, v any) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// intParam parses an optional integer query parameter
+func intParam(q url.Values, name string, def int) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errorf(http.StatusBadRequest, "invalid %s %q", name, v)
+	}
+	return n, nil
+}
+
+// dateParam parses an optional date query parameter
+func dateParam(q url.Values, name string) (hostex.Date, error) {
+	v := q.Get(name)
+	if v == "" {
+		return hostex.Date{}, nil
+	}
+	d, err := hostex.ParseDate(v)
+	if err != nil {
+		return hostex.Date{}, errorf(http.StatusBadRequest, "invalid %s %q", name, v)
+	}
+	return d, nil
+}
+
+// inRange reports whether d is within the optional bounds from and to
+func inRange(d, from, to hostex.Date) bool {
+	return (from.IsZero() || !d.Before(from)) && (to.IsZero() || !d.After(to))
+}
+
+// paginate returns the page of items selected by the offset and limit query
+// parameters, and the total number of items
+func paginate[T any](items []T, q url.Values) ([]T, int, error) {
+	offset, err := intParam(q, "offset", 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	limit, err := intParam(q, "limit", defaultLimit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if limit == 0 || limit > maxLimit {
+		return nil, 0, errorf(http.StatusBadRequest, "limit must be between 1 and %d", maxLimit)
+	}
+
+	page := make([]T, 0, limit)
+	if offset < len(items) {
+		page = append(page, items[offset:min(offset+limit, len(items))]...)
+	}
+	return page, len(items), nil
+}
+
+func (s *Server) listProperties(r *http.Request, body []byte) (any, error) {
+	q := r.URL.Query()
+	id, err := intParam(q, "id", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []hostex.Property
+	for _, p := range s.properties {
+		if id == 0 || p.ID == id {
+			matched = append(matched, p)
+		}
+	}
+
+	page, total, err := paginate(matched, q)
+	if err != nil {
+		return nil, err
+	}
+	return hostex.PropertiesResponse{Properties: page, Total: total}, nil
+}
+
+func (s *Server) listRoomTypes(r *http.Request, body []byte) (any, error) {
+	page, total, err := paginate(s.roomTypes, r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	return hostex.RoomTypesResponse{RoomTypes: page, Total: total}, nil
+}
+
+func (s *Server) listReservations(r *http.Request, body []byte) (any, error) {
+	q := r.URL.Query()
+	propertyID, err := intParam(q, "property_id", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var bounds [4]hostex.Date
+	for i, name := range []string{"start_check_in_date", "end_check_in_date", "start_check_out_date", "end_check_out_date"} {
+		if bounds[i], err = dateParam(q, name); err != nil {
+			return nil, err
+		}
+	}
+
+	code := q.Get("reservation_code")
+	status := hostex.ReservationStatus(q.Get("status"))
+
+	var matched []hostex.Reservation
+	for _, res := range s.reservations {
+		switch {
+		case code != "" && res.ReservationCode != code,
+			propertyID != 0 && res.PropertyID != propertyID,
+			status != "" && res.Status != status,
+			!inRange(res.CheckInDate, bounds[0], bounds[1]),
+			!inRange(res.CheckOutDate, bounds[2], bounds[3]):
+			continue
+		}
+		matched = append(matched, res)
+	}
+
+	switch orderBy := q.Get("order_by"); orderBy {
+	case "":
+	case "check_in_date":
+		slices.SortStableFunc(matched, func(a, b hostex.Reservation) int { return a.CheckInDate.Compare(b.CheckInDate) })
+	case "check_out_date":
+		slices.SortStableFunc(matched, func(a, b hostex.Reservation) int { return a.CheckOutDate.Compare(b.CheckOutDate) })
+	case "booked_at":
+		slices.SortStableFunc(matched, func(a, b hostex.Reservation) int { return compareTimes(a.BookedAt, b.BookedAt) })
+	default:
+		return nil, errorf(http.StatusBadRequest, "invalid order_by %q", orderBy)
+	}
+
+	page, total, err := paginate(matched, q)
+	if err != nil {
+		return nil, err
+	}
+	return hostex.ReservationsResponse{Reservations: page, Total: total}, nil
+}
+
+func (s *Server) createReservation(r *http.Request, body []byte) (any, error) {
+	var data hostex.CreateReservationData
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+
+	propertyID, _ := strconv.Atoi(data.PropertyID)
+	if s.property(propertyID) == nil {
+		return nil, errorf(http.StatusNotFound, "property %s not found", data.PropertyID)
+	}
+	if !slices.ContainsFunc(s.customChannels, func(c hostex.CustomChannel) bool { return c.ID == data.CustomChannelID }) {
+		return nil, errorf(http.StatusBadRequest, "unknown custom_channel_id %d", data.CustomChannelID)
+	}
+	if !slices.ContainsFunc(s.incomeMethods, func(m hostex.IncomeMethod) bool { return m.ID == data.IncomeMethodID }) {
+		return nil, errorf(http.StatusBadRequest, "unknown income_method_id %d", data.IncomeMethodID)
+	}
+	for d := range hostex.DateRange(data.CheckInDate, data.CheckOutDate.AddDays(-1)) {
+		if !s.available(propertyID, d) {
+			return nil, errorf(http.StatusBadRequest, "property %d is not available on %s", propertyID, d)
+		}
+	}
+
+	s.seq++
+	now := s.Now()
+	res := hostex.Reservation{
+		ReservationCode: fmt.Sprintf("HX%06d", s.seq),
+		StayCode:        fmt.Sprintf("ST%06d", s.seq),
+		PropertyID:      propertyID,
+		ChannelType:     hostex.ChannelHostexDirect,
+		CheckInDate:     data.CheckInDate,
+		CheckOutDate:    data.CheckOutDate,
+		NumberOfGuests:  data.NumberOfGuests,
+		Status:          hostex.ReservationStatusAccepted,
+		GuestName:       data.GuestName,
+		GuestPhone:      data.Mobile,
+		GuestEmail:      data.Email,
+		BookedAt:        &now,
+		CreatedAt:       &now,
+		Creator:         "hostextest",
+	}
+	s.reservations = append(s.reservations, res)
+
+	return hostex.CreateReservationResponse{Reservation: res}, nil
+}
+
+func (s *Server) cancelReservation(r *http.Request, body []byte) (any, error) {
+	code := r.PathValue("code")
+	i := slices.IndexFunc(s.reservations, func(res hostex.Reservation) bool { return res.ReservationCode == code })
+	if i < 0 {
+		return nil, errorf(http.StatusNotFound, "reservation %s not found", code)
+	}
+
+	res := &s.reservations[i]
+	if res.ChannelType != hostex.ChannelHostexDirect {
+		return nil, errorf(http.StatusBadRequest, "only direct bookings can be cancelled")
+	}
+	if res.Status == hostex.ReservationStatusCancelled {
+		return nil, errorf(http.StatusBadRequest, "reservation %s is already cancelled", code)
+	}
+
+	now := s.Now()
+	res.Status = hostex.ReservationStatusCancelled
+	res.CancelledAt = &now
+	return nil, nil
+}
+
+func (s *Server) updateLockCode(r *http.Request, body []byte) (any, error) {
+	code := r.PathValue("code")
+	if s.stay(code) < 0 {
+		return nil, errorf(http.StatusNotFound, "stay %s not found", code)
+	}
+
+	var data struct {
+		LockCode string `json:"lock_code"`
+	}
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+
+	s.lockCodes[code] = data.LockCode
+	return nil, nil
+}
+
+func (s *Server) getCustomFields(r *http.Request, body []byte) (any, error) {
+	code := r.PathValue("code")
+	if s.stay(code) < 0 {
+		return nil, errorf(http.StatusNotFound, "stay %s not found", code)
+	}
+
+	fields := s.customFields[code]
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	return hostex.CustomFieldsResponse{CustomFields: fields}, nil
+}
+
+func (s *Server) updateCustomFields(r *http.Request, body []byte) (any, error) {
+	code := r.PathValue("code")
+	if s.stay(code) < 0 {
+		return nil, errorf(http.StatusNotFound, "stay %s not found", code)
+	}
+
+	var data struct {
+		CustomFields map[string]any `json:"custom_fields"`
+	}
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+
+	fields := s.customFields[code]
+	if fields == nil {
+		fields = make(map[string]any)
+		s.customFields[code] = fields
+	}
+	for k, v := range data.CustomFields {
+		if v == nil {
+			delete(fields, k)
+			continue
+		}
+		fields[k] = v
+	}
+	return nil, nil
+}
+
+// availabilityListing mirrors an entry of hostex.AvailabilitiesResponse
+type availabilityListing struct {
+	ID             int                   `json:"id"`
+	Availabilities []hostex.Availability `json:"availabilities"`
+}
+
+func (s *Server) listAvailabilities(r *http.Request, body []byte) (any, error) {
+	q := r.URL.Query()
+	start, err := dateParam(q, "start_date")
+	if err != nil {
+		return nil, err
+	}
+	end, err := dateParam(q, "end_date")
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return nil, errorf(http.StatusBadRequest, "start_date and end_date are required")
+	}
+
+	var listings []availabilityListing
+	for _, field := range strings.Split(q.Get("property_ids"), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid property_ids %q", q.Get("property_ids"))
+		}
+		if s.property(id) == nil {
+			return nil, errorf(http.StatusNotFound, "property %d not found", id)
+		}
+
+		listing := availabilityListing{ID: id}
+		for d := range hostex.DateRange(start, end) {
+			listing.Availabilities = append(listing.Availabilities, hostex.Availability{Date: d, Available: s.available(id, d)})
+		}
+		listings = append(listings, listing)
+	}
+
+	return map[string]any{"listings": listings}, nil
+}
+
+func (s *Server) updateAvailabilities(r *http.Request, body []byte) (any, error) {
+	var data hostex.UpdateAvailabilitiesData
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+
+	dates := data.Dates
+	if len(dates) == 0 {
+		dates = slices.Collect(hostex.DateRange(data.StartDate, data.EndDate))
+	}
+
+	for _, id := range data.PropertyIDs {
+		if s.property(id) == nil {
+			return nil, errorf(http.StatusNotFound, "property %d not found", id)
+		}
+	}
+	for _, id := range data.PropertyIDs {
+		if s.availability[id] == nil {
+			s.availability[id] = make(map[hostex.Date]bool)
+		}
+		for _, d := range dates {
+			s.availability[id][d] = data.Available
+		}
+	}
+	return nil, nil
+}
+
+// calendarListing mirrors an entry of hostex.ListingCalendarResponse
+type calendarListing struct {
+	ChannelType hostex.ChannelType `json:"channel_type"`
+	ListingID   string             `json:"listing_id"`
+	Calendar    []calendarEntry    `json:"calendar"`
+}
+
+// calendarEntry mirrors a calendar day of hostex.ListingCalendarResponse
+type calendarEntry struct {
+	Date              hostex.Date `json:"date"`
+	Price             int         `json:"price,omitempty"`
+	Inventory         int         `json:"inventory,omitempty"`
+	Available         bool        `json:"available,omitempty"`
+	MinStay           int         `json:"min_stay,omitempty"`
+	MaxStay           int         `json:"max_stay,omitempty"`
+	ClosedToArrival   bool        `json:"closed_to_arrival,omitempty"`
+	ClosedToDeparture bool        `json:"closed_to_departure,omitempty"`
+}
+
+func (s *Server) getListingCalendar(r *http.Request, body []byte) (any, error) {
+	var data hostex.GetListingCalendarData
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+
+	var listings []calendarListing
+	for _, l := range data.Listings {
+		key := listingKey{l.ChannelType, l.ListingID}
+		if !s.hasListing(key) {
+			return nil, errorf(http.StatusNotFound, "listing %s/%s not found", l.ChannelType, l.ListingID)
+		}
+
+		listing := calendarListing{ChannelType: l.ChannelType, ListingID: l.ListingID}
+		for d := range hostex.DateRange(data.StartDate, data.EndDate) {
+			day := s.calendarDay(key, d)
+			listing.Calendar = append(listing.Calendar, calendarEntry{
+				Date:              d,
+				Price:             day.Price,
+				Inventory:         day.Inventory,
+				Available:         day.Available,
+				MinStay:           day.MinStay,
+				MaxStay:           day.MaxStay,
+				ClosedToArrival:   day.ClosedToArrival,
+				ClosedToDeparture: day.ClosedToDeparture,
+			})
+		}
+		listings = append(listings, listing)
+	}
+
+	return map[string]any{"listings": listings}, nil
+}
+
+// updateCalendar applies update to the listing on each date after checking
+// that the listing exists
+func (s *Server) updateCalendar(channelType hostex.ChannelType, listingID string, dates []hostex.Date, update func(i int, day *CalendarDay)) error {
+	key := listingKey{channelType, listingID}
+	if !s.hasListing(key) {
+		return errorf(http.StatusNotFound, "listing %s/%s not found", channelType, listingID)
+	}
+
+	if s.calendars[key] == nil {
+		s.calendars[key] = make(map[hostex.Date]CalendarDay)
+	}
+	for i, d := range dates {
+		day := s.calendarDay(key, d)
+		update(i, &day)
+		s.calendars[key][d] = day
+	}
+	return nil
+}
+
+func (s *Server) updateListingPrices(r *http.Request, body []byte) (any, error) {
+	var data hostex.UpdateListingPricesData
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+
+	dates := make([]hostex.Date, len(data.Prices))
+	prices := make([]int, len(data.Prices))
+	for i, p := range data.Prices {
+		price, err := p.Price.MajorUnits()
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "prices[%d].price: %v", i, err)
+		}
+		dates[i], prices[i] = p.Date, price
+	}
+	return nil, s.updateCalendar(data.ChannelType, data.ListingID, dates, func(i int, day *CalendarDay) {
+		day.Price = prices[i]
+	})
+}
+
+func (s *Server) updateListingInventories(r *http.Request, body []byte) (any, error) {
+	var data hostex.UpdateListingInventoriesData
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+
+	dates := make([]hostex.Date, len(data.Inventories))
+	for i, inv := range data.Inventories {
+		dates[i] = inv.Date
+	}
+	return nil, s.updateCalendar(data.ChannelType, data.ListingID, dates, func(i int, day *CalendarDay) {
+		day.Inventory = data.Inventories[i].Inventory
+		day.Available = day.Inventory > 0
+	})
+}
+
+func (s *Server) updateListingRestrictions(r *http.Request, body []byte) (any, error) {
+	var data hostex.UpdateListingRestrictionsData
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+
+	dates := make([]hostex.Date, len(data.Restrictions))
+	for i, rs := range data.Restrictions {
+		dates[i] = rs.Date
+	}
+	return nil, s.updateCalendar(data.ChannelType, data.ListingID, dates, func(i int, day *CalendarDay) {
+		rs := data.Restrictions[i]
+		day.MinStay = rs.MinStay
+		day.MaxStay = rs.MaxStay
+		day.ClosedToArrival = rs.ClosedToArrival
+		day.ClosedToDeparture = rs.ClosedToDeparture
+	})
+}
+
+func (s *Server) listConversations(r *http.Request, body []byte) (any, error) {
+	// Most recent activity first
+	sorted := slices.Clone(s.conversations)
+	slices.SortStableFunc(sorted, func(a, b hostex.Conversation) int {
+		return b.LastMessageAt.Compare(a.LastMessageAt)
+	})
+
+	page, total, err := paginate(sorted, r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	return hostex.ConversationsResponse{Conversations: page, Total: total}, nil
+}
+
+func (s *Server) getConversation(r *http.Request, body []byte) (any, error) {
+	id := r.PathValue("id")
+	conv := s.conversation(id)
+	if conv == nil {
+		return nil, errorf(http.StatusNotFound, "conversation %s not found", id)
+	}
+
+	messages := s.messages[id]
+	if messages == nil {
+		messages = []hostex.Message{}
+	}
+	return hostex.ConversationDetails{Guest: conv.Guest, ChannelType: conv.ChannelType, Messages: messages}, nil
+}
+
+func (s *Server) sendMessage(r *http.Request, body []byte) (any, error) {
+	id := r.PathValue("id")
+	conv := s.conversation(id)
+	if conv == nil {
+		return nil, errorf(http.StatusNotFound, "conversation %s not found", id)
+	}
+
+	var data hostex.SendMessageData
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+	if data.Message == "" && data.JpegBase64 == "" {
+		return nil, errorf(http.StatusBadRequest, "message or jpeg_base64 is required")
+	}
+
+	if data.JpegBase64 != "" {
+		image, err := base64.StdEncoding.DecodeString(data.JpegBase64)
+		if err != nil || !isJPEG(image) {
+			return nil, errorf(http.StatusBadRequest, "jpeg_base64 is not a base64 encoded JPEG image")
+		}
+	}
+
+	s.addMessage(conv, hostex.SenderHost, data.Message, data.JpegBase64 != "")
+	conv.UnreadCount = 0
+	return nil, nil
+}
+
+// isJPEG reports whether data starts with the JPEG start-of-image marker
+func isJPEG(data []byte) bool {
+	return len(data) > 2 && data[0] == 0xFF && data[1] == 0xD8
+}
+
+func (s *Server) listReviews(r *http.Request, body []byte) (any, error) {
+	q := r.URL.Query()
+	propertyID, err := intParam(q, "property_id", 0)
+	if err != nil {
+		return nil, err
+	}
+	from, err := dateParam(q, "start_check_out_date")
+	if err != nil {
+		return nil, err
+	}
+	to, err := dateParam(q, "end_check_out_date")
+	if err != nil {
+		return nil, err
+	}
+
+	code := q.Get("reservation_code")
+	status := hostex.ReviewStatus(q.Get("review_status"))
+
+	var matched []hostex.Review
+	for _, rv := range s.reviews {
+		switch {
+		case code != "" && rv.ReservationCode != code,
+			propertyID != 0 && rv.PropertyID != propertyID,
+			status != "" && rv.ReviewStatus != status,
+			!inRange(rv.CheckOutDate, from, to):
+			continue
+		}
+		matched = append(matched, rv)
+	}
+
+	page, total, err := paginate(matched, q)
+	if err != nil {
+		return nil, err
+	}
+	return hostex.ReviewsResponse{Reviews: page, Total: total}, nil
+}
+
+func (s *Server) createReview(r *http.Request, body []byte) (any, error) {
+	code := r.PathValue("code")
+
+	var data hostex.CreateReviewData
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+
+	i := slices.IndexFunc(s.reviews, func(rv hostex.Review) bool { return rv.ReservationCode == code })
+	if i < 0 {
+		j := slices.IndexFunc(s.reservations, func(res hostex.Reservation) bool { return res.ReservationCode == code })
+		if j < 0 {
+			return nil, errorf(http.StatusNotFound, "reservation %s not found", code)
+		}
+		res := s.reservations[j]
+		s.reviews = append(s.reviews, hostex.Review{
+			ReservationCode: code,
+			PropertyID:      res.PropertyID,
+			ChannelType:     res.ChannelType,
+			CheckOutDate:    res.CheckOutDate,
+			ReviewStatus:    hostex.ReviewStatusPendingHostReview,
+		})
+		i = len(s.reviews) - 1
+	}
+
+	rv := &s.reviews[i]
+	now := s.Now()
+	if data.HostReviewScore != 0 {
+		if rv.HostReview != nil {
+			return nil, errorf(http.StatusBadRequest, "reservation %s has already been reviewed", code)
+		}
+		rv.HostReview = &hostex.ReviewData{Score: data.HostReviewScore, Content: data.HostReviewContent, CreatedAt: now}
+		rv.ReviewStatus = hostex.ReviewStatusReviewed
+	}
+	if data.HostReplyContent != "" {
+		if rv.GuestReview == nil {
+			return nil, errorf(http.StatusBadRequest, "reservation %s has no guest review to reply to", code)
+		}
+		rv.HostReply = &hostex.ReplyData{Content: data.HostReplyContent, CreatedAt: now}
+	}
+	return nil, nil
+}
+
+func (s *Server) listWebhooks(r *http.Request, body []byte) (any, error) {
+	webhooks := slices.Clone(s.webhooks)
+	if webhooks == nil {
+		webhooks = []hostex.Webhook{}
+	}
+	return hostex.WebhooksResponse{Webhooks: webhooks}, nil
+}
+
+func (s *Server) createWebhook(r *http.Request, body []byte) (any, error) {
+	var data struct {
+		URL string `json:"url"`
+	}
+	if err := decode(body, &data); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(data.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errorf(http.StatusBadRequest, "invalid webhook url %q", data.URL)
+	}
+	if slices.ContainsFunc(s.webhooks, func(w hostex.Webhook) bool { return w.URL == data.URL }) {
+		return nil, errorf(http.StatusBadRequest, "webhook %s already exists", data.URL)
+	}
+
+	s.seq++
+	webhook := hostex.Webhook{ID: s.seq, URL: data.URL, Manageable: true, CreatedAt: s.Now()}
+	s.webhooks = append(s.webhooks, webhook)
+
+	return hostex.CreateWebhookResponse{Webhook: webhook}, nil
+}
+
+func (s *Server) deleteWebhook(r *http.Request, body []byte) (any, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid webhook id %q", r.PathValue("id"))
+	}
+
+	i := slices.IndexFunc(s.webhooks, func(w hostex.Webhook) bool { return w.ID == id })
+	if i < 0 {
+		return nil, errorf(http.StatusNotFound, "webhook %d not found", id)
+	}
+	if !s.webhooks[i].Manageable {
+		return nil, errorf(http.StatusForbidden, "webhook %d is not manageable", id)
+	}
+
+	s.webhooks = slices.Delete(s.webhooks, i, i+1)
+	return nil, nil
+}
+
+func (s *Server) listCustomChannels(r *http.Request, body []byte) (any, error) {
+	channels := slices.Clone(s.customChannels)
+	if channels == nil {
+		channels = []hostex.CustomChannel{}
+	}
+	return hostex.CustomChannelsResponse{CustomChannels: channels}, nil
+}
+
+func (s *Server) listIncomeMethods(r *http.Request, body []byte) (any, error) {
+	methods := slices.Clone(s.incomeMethods)
+	if methods == nil {
+		methods = []hostex.IncomeMethod{}
+	}
+	return hostex.IncomeMethodsResponse{IncomeMethods: methods}, nil
+}
+
+// compareTimes orders optional timestamps, with missing ones first
+func compareTimes(a, b *time.Time) int {
+	if a == nil || b == nil {
+		return cmp.Compare(boolInt(a != nil), boolInt(b != nil))
+	}
+	return a.Compare(*b)
+}
+
+// boolInt returns 1 for true and 0 for false
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
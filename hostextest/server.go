@@ -0,0 +1,484 @@
+// Package hostextest provides an in-memory fake of the Hostex API for
+// testing code that uses the hostex client without network access.
+//
+// The fake implements every endpoint the client calls, backed by mutable
+// in-memory state that can be seeded with fixtures and inspected after the
+// code under test has run. Failures can be injected per endpoint and every
+// request is recorded for assertions.
+//
+//	srv := hostextest.NewServer()
+//	defer srv.Close()
+//	srv.Seed(hostextest.DefaultFixtures())
+//
+//	client := srv.Client()
+//	reservations, err := client.ListReservations(ctx, nil)
+package hostextest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+)
+
+// DefaultToken is the access token accepted by a new Server
+const DefaultToken = "hostextest-token"
+
+// Server is a fake Hostex API server. All methods are safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the fake API, for use as Config.BaseURL
+	URL string
+
+	// Token is the access token the server accepts. Change it before
+	// sending requests.
+	Token string
+
+	// Now returns the time used for the timestamps of records created
+	// through the API. Change it before sending requests.
+	Now func() time.Time
+
+	srv *httptest.Server
+
+	mu             sync.Mutex
+	seq            int
+	requests       []Request
+	failures       map[string][]*Failure
+	properties     []hostex.Property
+	roomTypes      []hostex.RoomType
+	reservations   []hostex.Reservation
+	lockCodes      map[string]string
+	customFields   map[string]map[string]any
+	availability   map[int]map[hostex.Date]bool
+	calendars      map[listingKey]map[hostex.Date]CalendarDay
+	conversations  []hostex.Conversation
+	messages       map[string][]hostex.Message
+	reviews        []hostex.Review
+	webhooks       []hostex.Webhook
+	customChannels []hostex.CustomChannel
+	incomeMethods  []hostex.IncomeMethod
+}
+
+// listingKey identifies a channel listing
+type listingKey struct {
+	channelType hostex.ChannelType
+	listingID   string
+}
+
+// CalendarDay is the state of a channel listing on one date
+type CalendarDay struct {
+	Price             int
+	Inventory         int
+	Available         bool
+	MinStay           int
+	MaxStay           int
+	ClosedToArrival   bool
+	ClosedToDeparture bool
+}
+
+// defaultCalendarDay is the state of a listing date that was never updated
+var defaultCalendarDay = CalendarDay{Inventory: 1, Available: true}
+
+// NewServer starts an empty fake server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		Token:        DefaultToken,
+		Now:          time.Now,
+		failures:     make(map[string][]*Failure),
+		lockCodes:    make(map[string]string),
+		customFields: make(map[string]map[string]any),
+		availability: make(map[int]map[hostex.Date]bool),
+		calendars:    make(map[listingKey]map[hostex.Date]CalendarDay),
+		messages:     make(map[string][]hostex.Message),
+	}
+
+	mux := http.NewServeMux()
+	s.routes(mux)
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Config returns a client configuration pointing at the server, with
+// retries disabled so injected failures surface directly
+func (s *Server) Config() hostex.Config {
+	return hostex.Config{
+		AccessToken: s.Token,
+		BaseURL:     s.URL,
+		HTTPClient:  s.srv.Client(),
+		RetryPolicy: &hostex.NoRetry,
+	}
+}
+
+// Client returns a client for the server created from Config
+func (s *Server) Client() *hostex.Client {
+	client, err := hostex.NewClient(s.Config())
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// Fixtures is the initial state of a Server. Messages are keyed by
+// conversation ID.
+type Fixtures struct {
+	Properties     []hostex.Property
+	RoomTypes      []hostex.RoomType
+	Reservations   []hostex.Reservation
+	Conversations  []hostex.Conversation
+	Messages       map[string][]hostex.Message
+	Reviews        []hostex.Review
+	Webhooks       []hostex.Webhook
+	CustomChannels []hostex.CustomChannel
+	IncomeMethods  []hostex.IncomeMethod
+}
+
+// Seed adds the fixtures to the server state
+func (s *Server) Seed(f Fixtures) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.properties = append(s.properties, f.Properties...)
+	s.roomTypes = append(s.roomTypes, f.RoomTypes...)
+	s.reservations = append(s.reservations, f.Reservations...)
+	s.conversations = append(s.conversations, f.Conversations...)
+	for id, msgs := range f.Messages {
+		s.messages[id] = append(s.messages[id], msgs...)
+	}
+	s.reviews = append(s.reviews, f.Reviews...)
+	s.webhooks = append(s.webhooks, f.Webhooks...)
+	s.customChannels = append(s.customChannels, f.CustomChannels...)
+	s.incomeMethods = append(s.incomeMethods, f.IncomeMethods...)
+
+	for _, w := range f.Webhooks {
+		s.seq = max(s.seq, w.ID)
+	}
+}
+
+// DefaultFixtures returns a small, consistent data set: one property listed
+// on Airbnb, a room type, a custom channel, an income method, an accepted
+// reservation with a conversation and a review awaiting the host
+func DefaultFixtures() Fixtures {
+	booked := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	checkIn := hostex.MustParseDate("2024-07-01")
+	checkOut := hostex.MustParseDate("2024-07-05")
+	airbnb := hostex.Channel{ChannelType: hostex.ChannelAirbnb, ListingID: "airbnb-1001"}
+
+	return Fixtures{
+		Properties: []hostex.Property{
+			{ID: 1001, Title: "Seaside Cottage", Address: "1 Harbour Road", Channels: []hostex.Channel{airbnb}},
+		},
+		RoomTypes: []hostex.RoomType{
+			{ID: 2001, Title: "Cottage", Properties: []hostex.Property{{ID: 1001, Title: "Seaside Cottage"}}, Channels: []hostex.Channel{airbnb}},
+		},
+		Reservations: []hostex.Reservation{
+			{
+				ReservationCode: "HMABC123",
+				StayCode:        "ST-ABC123",
+				PropertyID:      1001,
+				ChannelType:     hostex.ChannelAirbnb,
+				ListingID:       airbnb.ListingID,
+				CheckInDate:     checkIn,
+				CheckOutDate:    checkOut,
+				NumberOfGuests:  2,
+				NumberOfAdults:  2,
+				Status:          hostex.ReservationStatusAccepted,
+				GuestName:       "Ada Lovelace",
+				BookedAt:        &booked,
+				CreatedAt:       &booked,
+				ConversationID:  "conv-1",
+			},
+		},
+		Conversations: []hostex.Conversation{
+			{
+				ID:            "conv-1",
+				ChannelType:   hostex.ChannelAirbnb,
+				Guest:         hostex.Guest{Name: "Ada Lovelace"},
+				PropertyID:    1001,
+				PropertyTitle: "Seaside Cottage",
+				CheckInDate:   checkIn,
+				CheckOutDate:  checkOut,
+				LastMessageAt: booked,
+				UnreadCount:   1,
+			},
+		},
+		Messages: map[string][]hostex.Message{
+			"conv-1": {
+				{ID: "msg-1", SenderRole: hostex.SenderGuest, Content: "Hi! Is early check-in possible?", CreatedAt: booked},
+			},
+		},
+		Reviews: []hostex.Review{
+			{
+				ReservationCode: "HMABC123",
+				PropertyID:      1001,
+				ChannelType:     hostex.ChannelAirbnb,
+				CheckOutDate:    checkOut,
+				ReviewStatus:    hostex.ReviewStatusPendingHostReview,
+				GuestReview:     &hostex.ReviewData{Score: 5, Content: "Lovely stay", CreatedAt: booked},
+			},
+		},
+		CustomChannels: []hostex.CustomChannel{{ID: 1, Name: "Direct"}},
+		IncomeMethods:  []hostex.IncomeMethod{{ID: 1, Name: "Bank transfer"}},
+	}
+}
+
+// Request is a request received by the server
+type Request struct {
+	// Pattern is the route that handled the request, e.g. "POST /listings/prices"
+	Pattern string
+
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// DecodeBody decodes the JSON request body into v
+func (r Request) DecodeBody(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Requests returns all requests received so far, in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the requests handled by the route pattern, e.g.
+// "GET /reservations" or "DELETE /webhooks/{id}"
+func (s *Server) RequestsTo(pattern string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []Request
+	for _, r := range s.requests {
+		if r.Pattern == pattern {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// ResetRequests forgets the recorded requests
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// AssertCalled reports a test error unless the route pattern handled
+// exactly n requests
+func (s *Server) AssertCalled(t testing.TB, pattern string, n int) {
+	t.Helper()
+	if got := len(s.RequestsTo(pattern)); got != n {
+		t.Errorf("hostextest: expected %d %s requests, got %d", n, pattern, got)
+	}
+}
+
+// AssertNotCalled reports a test error if the route pattern handled any requests
+func (s *Server) AssertNotCalled(t testing.TB, pattern string) {
+	t.Helper()
+	s.AssertCalled(t, pattern, 0)
+}
+
+// Failure describes an error response injected with Fail
+type Failure struct {
+	// StatusCode is the HTTP status code (defaults to 500)
+	StatusCode int
+
+	// ErrorCode is the Hostex error code in the body (defaults to StatusCode)
+	ErrorCode int
+
+	// ErrorMsg is the Hostex error message (defaults to the status text)
+	ErrorMsg string
+
+	// Body, when set, is sent as-is instead of a Hostex error response,
+	// e.g. to simulate a gateway HTML page
+	Body string
+
+	// RetryAfter sets the Retry-After header when positive
+	RetryAfter time.Duration
+
+	// Delay is waited before responding, unless the request is cancelled
+	Delay time.Duration
+
+	// Times is the number of matching requests that fail. Zero means
+	// once and a negative value means every request.
+	Times int
+}
+
+// AnyRoute matches every route in Fail
+const AnyRoute = "*"
+
+// Fail makes requests to the route pattern fail, e.g.
+// Fail("POST /listings/prices", Failure{StatusCode: 503}). Failures for the
+// same pattern are used in the order they were added.
+func (s *Server) Fail(pattern string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f.StatusCode == 0 {
+		f.StatusCode = http.StatusInternalServerError
+	}
+	if f.ErrorCode == 0 {
+		f.ErrorCode = f.StatusCode
+	}
+	if f.ErrorMsg == "" {
+		f.ErrorMsg = http.StatusText(f.StatusCode)
+	}
+	if f.Times == 0 {
+		f.Times = 1
+	}
+	s.failures[pattern] = append(s.failures[pattern], &f)
+}
+
+// ClearFailures removes all pending injected failures
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = make(map[string][]*Failure)
+}
+
+// takeFailure consumes the next failure for pattern, if any
+func (s *Server) takeFailure(pattern string) *Failure {
+	for _, key := range []string{pattern, AnyRoute} {
+		queue := s.failures[key]
+		if len(queue) == 0 {
+			continue
+		}
+
+		f := *queue[0]
+		if queue[0].Times > 0 {
+			queue[0].Times--
+			if queue[0].Times == 0 {
+				s.failures[key] = queue[1:]
+			}
+		}
+		return &f
+	}
+	return nil
+}
+
+// apiError is an error response from a handler
+type apiError struct {
+	code int
+	msg  string
+}
+
+func (e *apiError) Error() string {
+	return e.msg
+}
+
+// handlerFunc handles a request with the server lock held and returns the
+// response data or an *apiError
+type handlerFunc func(r *http.Request, body []byte) (any, error)
+
+// handle registers h for pattern, adding recording, authentication and
+// failure injection
+func (s *Server) handle(mux *http.ServeMux, pattern string, h handlerFunc) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		s.mu.Lock()
+		s.seq++
+		requestID := "hostextest-" + strconv.Itoa(s.seq)
+		s.requests = append(s.requests, Request{
+			Pattern: pattern,
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Query:   r.URL.Query(),
+			Header:  r.Header.Clone(),
+			Body:    body,
+		})
+		failure := s.takeFailure(pattern)
+		token := s.Token
+		s.mu.Unlock()
+
+		if failure != nil {
+			writeFailure(w, r, requestID, failure)
+			return
+		}
+
+		if r.Header.Get("Hostex-Access-Token") != token {
+			writeResponse(w, requestID, nil, &apiError{http.StatusUnauthorized, "invalid access token"})
+			return
+		}
+
+		s.mu.Lock()
+		data, err := h(r, body)
+		s.mu.Unlock()
+
+		writeResponse(w, requestID, data, err)
+	})
+}
+
+// writeResponse writes a Hostex response envelope with data or err
+func writeResponse(w http.ResponseWriter, requestID string, data any, err error) {
+	resp := struct {
+		RequestID string `json:"request_id"`
+		ErrorCode int    `json:"error_code"`
+		ErrorMsg  string `json:"error_msg"`
+		Data      any    `json:"data,omitempty"`
+	}{RequestID: requestID, ErrorCode: 200, ErrorMsg: "Done.", Data: data}
+
+	status := http.StatusOK
+	if err != nil {
+		apiErr, ok := err.(*apiError)
+		if !ok {
+			apiErr = &apiError{http.StatusInternalServerError, err.Error()}
+		}
+		status = apiErr.code
+		resp.ErrorCode = apiErr.code
+		resp.ErrorMsg = apiErr.msg
+		resp.Data = nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeFailure writes an injected failure
+func writeFailure(w http.ResponseWriter, r *http.Request, requestID string, f *Failure) {
+	if f.Delay > 0 {
+		select {
+		case <-time.After(f.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((f.RetryAfter+time.Second-1)/time.Second)))
+	}
+
+	if f.Body != "" {
+		w.WriteHeader(f.StatusCode)
+		io.WriteString(w, f.Body)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.StatusCode)
+	json.NewEncoder(w).Encode(map[string]any{
+		"request_id": requestID,
+		"error_code": f.ErrorCode,
+		"error_msg":  f.ErrorMsg,
+	})
+}
@@ -0,0 +1,286 @@
+package hostextest_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/hostextest"
+)
+
+func newServer(t *testing.T) (*hostextest.Server, *hostex.Client) {
+	t.Helper()
+
+	srv := hostextest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Seed(hostextest.DefaultFixtures())
+	srv.Now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	return srv, srv.Client()
+}
+
+func TestServer_Reservations(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	created, err := client.CreateReservation(ctx, hostex.CreateReservationData{
+		PropertyID:      "1001",
+		CustomChannelID: 1,
+		IncomeMethodID:  1,
+		GuestName:       "Grace Hopper",
+		CheckInDate:     hostex.MustParseDate("2024-08-01"),
+		CheckOutDate:    hostex.MustParseDate("2024-08-03"),
+		RateAmount:      hostex.MustParseMoney("300.00", "USD"),
+	})
+	if err != nil {
+		t.Fatalf("CreateReservation failed: %v", err)
+	}
+	code := created.Reservation.ReservationCode
+
+	list, err := client.ListReservations(ctx, &hostex.ListReservationsParams{PropertyID: 1001, OrderBy: "check_in_date"})
+	if err != nil {
+		t.Fatalf("ListReservations failed: %v", err)
+	}
+	if list.Total != 2 || list.Reservations[1].ReservationCode != code {
+		t.Errorf("Expected seeded and created reservation, got %+v", list)
+	}
+
+	if srv.Available(1001, hostex.MustParseDate("2024-08-01")) {
+		t.Error("Expected booked night to be unavailable")
+	}
+
+	_, err = client.CreateReservation(ctx, hostex.CreateReservationData{
+		PropertyID:      "1001",
+		CustomChannelID: 1,
+		IncomeMethodID:  1,
+		GuestName:       "Double Booking",
+		CheckInDate:     hostex.MustParseDate("2024-08-02"),
+		CheckOutDate:    hostex.MustParseDate("2024-08-04"),
+		RateAmount:      hostex.MustParseMoney("300.00", "USD"),
+	})
+	if !errors.Is(err, hostex.ErrValidation) {
+		t.Errorf("Expected overlapping booking to be rejected, got %v", err)
+	}
+
+	stay := created.Reservation.StayCode
+	if err := client.UpdateLockCode(ctx, stay, "4321"); err != nil {
+		t.Fatalf("UpdateLockCode failed: %v", err)
+	}
+	if got := srv.LockCode(stay); got != "4321" {
+		t.Errorf("Expected lock code 4321, got %q", got)
+	}
+
+	if err := client.UpdateCustomFields(ctx, stay, map[string]interface{}{"parking": "B2"}); err != nil {
+		t.Fatalf("UpdateCustomFields failed: %v", err)
+	}
+	fields, err := client.GetCustomFields(ctx, stay)
+	if err != nil || fields.CustomFields["parking"] != "B2" {
+		t.Errorf("Expected custom field to round trip, got %+v, %v", fields, err)
+	}
+
+	if err := client.CancelReservation(ctx, code); err != nil {
+		t.Fatalf("CancelReservation failed: %v", err)
+	}
+	if res, _ := srv.Reservation(code); res.Status != hostex.ReservationStatusCancelled || res.CancelledAt == nil {
+		t.Errorf("Expected reservation to be cancelled, got %+v", res)
+	}
+	if !srv.Available(1001, hostex.MustParseDate("2024-08-01")) {
+		t.Error("Expected cancelled night to be available again")
+	}
+
+	if err := client.CancelReservation(ctx, "missing"); !errors.Is(err, hostex.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+}
+
+func TestServer_Calendar(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+	day := hostex.MustParseDate("2024-09-10")
+
+	err := client.UpdateListingPrices(ctx, hostex.UpdateListingPricesData{
+		ChannelType: hostex.ChannelAirbnb,
+		ListingID:   "airbnb-1001",
+		Prices:      []hostex.Price{{Date: day, Price: hostex.MustParseMoney("150", "USD")}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateListingPrices failed: %v", err)
+	}
+
+	err = client.UpdateListingRestrictions(ctx, hostex.UpdateListingRestrictionsData{
+		ChannelType:  hostex.ChannelAirbnb,
+		ListingID:    "airbnb-1001",
+		Restrictions: []hostex.Restriction{{Date: day, MinStay: 2, ClosedToArrival: true}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateListingRestrictions failed: %v", err)
+	}
+
+	err = client.UpdateListingInventories(ctx, hostex.UpdateListingInventoriesData{
+		ChannelType: hostex.ChannelAirbnb,
+		ListingID:   "unknown",
+		Inventories: []hostex.Inventory{{Date: day, Inventory: 0}},
+	})
+	if !errors.Is(err, hostex.ErrNotFound) {
+		t.Errorf("Expected unknown listing to be not found, got %v", err)
+	}
+
+	cal, err := client.GetListingCalendar(ctx, hostex.GetListingCalendarData{
+		StartDate: day,
+		EndDate:   day.AddDays(1),
+		Listings:  []hostex.Listing{{ChannelType: hostex.ChannelAirbnb, ListingID: "airbnb-1001"}},
+	})
+	if err != nil {
+		t.Fatalf("GetListingCalendar failed: %v", err)
+	}
+	days := cal.Listings[0].Calendar
+	if len(days) != 2 || days[0].Price != 150 || days[0].MinStay != 2 || !days[0].ClosedToArrival || days[1].Price != 0 {
+		t.Errorf("Unexpected calendar: %+v", days)
+	}
+
+	err = client.UpdateAvailabilities(ctx, hostex.UpdateAvailabilitiesData{
+		PropertyIDs: []int{1001},
+		StartDate:   day,
+		EndDate:     day.AddDays(2),
+		Available:   false,
+	})
+	if err != nil {
+		t.Fatalf("UpdateAvailabilities failed: %v", err)
+	}
+
+	avail, err := client.ListAvailabilities(ctx, hostex.ListAvailabilitiesParams{PropertyIDs: "1001", StartDate: day.AddDays(2), EndDate: day.AddDays(3)})
+	if err != nil {
+		t.Fatalf("ListAvailabilities failed: %v", err)
+	}
+	got := avail.Listings[0].Availabilities
+	if len(got) != 2 || got[0].Available || !got[1].Available {
+		t.Errorf("Unexpected availabilities: %+v", got)
+	}
+
+	if day := srv.CalendarDay(hostex.ChannelAirbnb, "airbnb-1001", day); day.Price != 150 {
+		t.Errorf("Expected stored price, got %+v", day)
+	}
+}
+
+func TestServer_ConversationsAndReviews(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	srv.ReceiveMessage("conv-1", "Also, is there parking?")
+
+	if err := client.SendMessage(ctx, "conv-1", hostex.SendMessageData{Message: "Yes, bay B2."}); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	jpeg := base64.StdEncoding.EncodeToString([]byte{0xFF, 0xD8, 0xFF, 0xE0})
+	if err := client.SendMessage(ctx, "conv-1", hostex.SendMessageData{JpegBase64: jpeg}); err != nil {
+		t.Fatalf("SendMessage with image failed: %v", err)
+	}
+
+	details, err := client.GetConversation(ctx, "conv-1")
+	if err != nil {
+		t.Fatalf("GetConversation failed: %v", err)
+	}
+	if len(details.Messages) != 4 || details.Messages[2].SenderRole != hostex.SenderHost || details.Messages[3].ImageURL == "" {
+		t.Errorf("Unexpected messages: %+v", details.Messages)
+	}
+
+	if err := client.CreateReview(ctx, "HMABC123", hostex.CreateReviewData{HostReviewScore: 5, HostReviewContent: "Great guest", HostReplyContent: "Thanks!"}); err != nil {
+		t.Fatalf("CreateReview failed: %v", err)
+	}
+	reviews, err := client.ListReviews(ctx, &hostex.ListReviewsParams{ReviewStatus: hostex.ReviewStatusReviewed})
+	if err != nil {
+		t.Fatalf("ListReviews failed: %v", err)
+	}
+	if reviews.Total != 1 || reviews.Reviews[0].HostReview.Score != 5 || reviews.Reviews[0].HostReply == nil {
+		t.Errorf("Unexpected reviews: %+v", reviews)
+	}
+}
+
+func TestServer_Webhooks(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	created, err := client.CreateWebhook(ctx, "https://example.com/hook")
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	if _, err := client.CreateWebhook(ctx, "https://example.com/hook"); !errors.Is(err, hostex.ErrValidation) {
+		t.Errorf("Expected duplicate webhook to be rejected, got %v", err)
+	}
+
+	if err := client.DeleteWebhook(ctx, created.Webhook.ID); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+	if len(srv.Webhooks()) != 0 {
+		t.Errorf("Expected no webhooks, got %+v", srv.Webhooks())
+	}
+
+	srv.AssertCalled(t, "POST /webhooks", 2)
+	srv.AssertCalled(t, "DELETE /webhooks/{id}", 1)
+
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := srv.RequestsTo("POST /webhooks")[0].DecodeBody(&body); err != nil || body.URL != "https://example.com/hook" {
+		t.Errorf("Expected recorded body, got %+v, %v", body, err)
+	}
+}
+
+func TestServer_Pagination(t *testing.T) {
+	srv := hostextest.NewServer()
+	defer srv.Close()
+
+	var properties []hostex.Property
+	for i := 1; i <= 45; i++ {
+		properties = append(properties, hostex.Property{ID: i, Title: "Property"})
+	}
+	srv.Seed(hostextest.Fixtures{Properties: properties})
+
+	client := srv.Client()
+	all, err := client.AllProperties(context.Background(), &hostex.ListPropertiesParams{Limit: 20})
+	if err != nil {
+		t.Fatalf("AllProperties failed: %v", err)
+	}
+	if len(all) != 45 {
+		t.Errorf("Expected 45 properties, got %d", len(all))
+	}
+	srv.AssertCalled(t, "GET /properties", 3)
+}
+
+func TestServer_Failures(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	srv.Fail("GET /properties", hostextest.Failure{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second})
+	_, err := client.ListProperties(ctx, nil)
+
+	var apiErr *hostex.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, hostex.ErrRateLimited) || apiErr.RetryAfter != 2*time.Second {
+		t.Errorf("Expected rate limit error with Retry-After, got %v", err)
+	}
+	if _, err := client.ListProperties(ctx, nil); err != nil {
+		t.Errorf("Expected failure to be used once, got %v", err)
+	}
+
+	srv.Fail(hostextest.AnyRoute, hostextest.Failure{StatusCode: http.StatusBadGateway, Body: "<html>bad gateway</html>", Times: -1})
+	for range 2 {
+		if _, err := client.ListWebhooks(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+			t.Errorf("Expected 502, got %v", err)
+		}
+	}
+	srv.ClearFailures()
+
+	config := srv.Config()
+	config.AccessToken = "wrong"
+	badClient, err := hostex.NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := badClient.ListProperties(ctx, nil); !errors.Is(err, hostex.ErrUnauthorized) {
+		t.Errorf("Expected unauthorized, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package hostextest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/keithah/hostex-go"
+)
+
+// property returns the property with id, or nil
+func (s *Server) property(id int) *hostex.Property {
+	i := slices.IndexFunc(s.properties, func(p hostex.Property) bool { return p.ID == id })
+	if i < 0 {
+		return nil
+	}
+	return &s.properties[i]
+}
+
+// stay returns the index of a reservation with the stay code, or -1
+func (s *Server) stay(code string) int {
+	return slices.IndexFunc(s.reservations, func(res hostex.Reservation) bool { return res.StayCode == code })
+}
+
+// conversation returns the conversation with id, or nil
+func (s *Server) conversation(id string) *hostex.Conversation {
+	i := slices.IndexFunc(s.conversations, func(c hostex.Conversation) bool { return c.ID == id })
+	if i < 0 {
+		return nil
+	}
+	return &s.conversations[i]
+}
+
+// blocksCalendar reports whether a reservation occupies its dates
+func blocksCalendar(res hostex.Reservation) bool {
+	switch res.Status {
+	case hostex.ReservationStatusCancelled, hostex.ReservationStatusDenied, hostex.ReservationStatusTimeout:
+		return false
+	}
+	return true
+}
+
+// available reports whether the property can be booked for the night of d.
+// Explicit availability updates take precedence over reservations.
+func (s *Server) available(propertyID int, d hostex.Date) bool {
+	if available, ok := s.availability[propertyID][d]; ok {
+		return available
+	}
+	for _, res := range s.reservations {
+		if res.PropertyID == propertyID && blocksCalendar(res) && !d.Before(res.CheckInDate) && d.Before(res.CheckOutDate) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasListing reports whether a property or room type has the channel listing
+func (s *Server) hasListing(key listingKey) bool {
+	match := func(c hostex.Channel) bool {
+		return c.ChannelType == key.channelType && c.ListingID == key.listingID
+	}
+	for _, p := range s.properties {
+		if slices.ContainsFunc(p.Channels, match) {
+			return true
+		}
+	}
+	for _, rt := range s.roomTypes {
+		if slices.ContainsFunc(rt.Channels, match) {
+			return true
+		}
+	}
+	return false
+}
+
+// calendarDay returns the state of a listing on d
+func (s *Server) calendarDay(key listingKey, d hostex.Date) CalendarDay {
+	if day, ok := s.calendars[key][d]; ok {
+		return day
+	}
+	return defaultCalendarDay
+}
+
+// addMessage appends a message to a conversation and returns it
+func (s *Server) addMessage(conv *hostex.Conversation, role hostex.SenderRole, content string, image bool) hostex.Message {
+	s.seq++
+	msg := hostex.Message{
+		ID:         fmt.Sprintf("msg-%06d", s.seq),
+		SenderRole: role,
+		Content:    content,
+		CreatedAt:  s.Now(),
+	}
+	if image {
+		msg.ImageURL = "https://hostextest.invalid/images/" + msg.ID + ".jpg"
+	}
+
+	s.messages[conv.ID] = append(s.messages[conv.ID], msg)
+	conv.LastMessageAt = msg.CreatedAt
+	return msg
+}
+
+// Reservations returns all reservations, including cancelled ones
+func (s *Server) Reservations() []hostex.Reservation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.reservations)
+}
+
+// Reservation returns the reservation with the reservation code
+func (s *Server) Reservation(code string) (hostex.Reservation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.reservations, func(res hostex.Reservation) bool { return res.ReservationCode == code })
+	if i < 0 {
+		return hostex.Reservation{}, false
+	}
+	return s.reservations[i], true
+}
+
+// UpdateReservation changes a reservation in place, e.g. to simulate a guest
+// cancelling on the channel. It reports whether the reservation exists.
+func (s *Server) UpdateReservation(code string, update func(*hostex.Reservation)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.reservations, func(res hostex.Reservation) bool { return res.ReservationCode == code })
+	if i < 0 {
+		return false
+	}
+	update(&s.reservations[i])
+	return true
+}
+
+// LockCode returns the lock code set for a stay
+func (s *Server) LockCode(stayCode string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lockCodes[stayCode]
+}
+
+// CustomFields returns the custom fields set for a stay
+func (s *Server) CustomFields(stayCode string) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.customFields[stayCode])
+}
+
+// Available reports whether the property can be booked for the night of d
+func (s *Server) Available(propertyID int, d hostex.Date) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.available(propertyID, d)
+}
+
+// CalendarDay returns the prices, inventory and restrictions of a channel
+// listing on d
+func (s *Server) CalendarDay(channelType hostex.ChannelType, listingID string, d hostex.Date) CalendarDay {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calendarDay(listingKey{channelType, listingID}, d)
+}
+
+// Messages returns the messages of a conversation, oldest first
+func (s *Server) Messages(conversationID string) []hostex.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages[conversationID])
+}
+
+// ReceiveMessage simulates a guest sending a message to a conversation and
+// returns it. It reports false if the conversation does not exist.
+func (s *Server) ReceiveMessage(conversationID, content string) (hostex.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv := s.conversation(conversationID)
+	if conv == nil {
+		return hostex.Message{}, false
+	}
+	conv.UnreadCount++
+	return s.addMessage(conv, hostex.SenderGuest, content, false), true
+}
+
+// Review returns the review for a reservation code
+func (s *Server) Review(reservationCode string) (hostex.Review, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.reviews, func(rv hostex.Review) bool { return rv.ReservationCode == reservationCode })
+	if i < 0 {
+		return hostex.Review{}, false
+	}
+	return s.reviews[i], true
+}
+
+// Webhooks returns the registered webhooks
+func (s *Server) Webhooks() []hostex.Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.webhooks)
+}
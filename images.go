@@ -0,0 +1,358 @@
+package hostex
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+
+	// Register the decoders accepted by EncodeImage
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Image defaults
+const (
+	// DefaultMaxImageDimension is the default longest side of a sent image, in pixels
+	DefaultMaxImageDimension = 2048
+
+	// DefaultMaxImageBytes is the default size limit of a sent JPEG, before base64 encoding
+	DefaultMaxImageBytes = 5 << 20
+
+	// DefaultJPEGQuality is the default quality of re-encoded images
+	DefaultJPEGQuality = 85
+
+	// DefaultMaxImageInputBytes is the default size limit of an image read
+	// by EncodeImage
+	DefaultMaxImageInputBytes = 32 << 20
+
+	// DefaultMaxImagePixels is the default limit on the width times height
+	// of an image read by EncodeImage
+	DefaultMaxImagePixels = 40_000_000
+)
+
+// minJPEGQuality is the lowest quality tried before an image is shrunk further
+const minJPEGQuality = 50
+
+// ErrUnsupportedImage is returned for images that are not PNG, GIF or JPEG
+var ErrUnsupportedImage = errors.New("hostex: unsupported image format")
+
+// ErrImageTooLarge is returned for images over ImageOptions.MaxInputBytes or
+// ImageOptions.MaxPixels
+var ErrImageTooLarge = errors.New("hostex: image too large")
+
+// ImageOptions controls how images are converted for SendImage
+type ImageOptions struct {
+	// MaxDimension is the longest side in pixels; larger images are
+	// downsized (optional, defaults to DefaultMaxImageDimension)
+	MaxDimension int
+
+	// MaxBytes is the size limit of the JPEG. Larger images are re-encoded
+	// at a lower quality, then downsized until they fit (optional, defaults
+	// to DefaultMaxImageBytes)
+	MaxBytes int
+
+	// Quality is the JPEG quality used when an image is re-encoded, from 1
+	// to 100 (optional, defaults to DefaultJPEGQuality)
+	Quality int
+
+	// MaxInputBytes is the size limit of the image read, checked before it
+	// is decoded (optional, defaults to DefaultMaxImageInputBytes)
+	MaxInputBytes int
+
+	// MaxPixels is the limit on the width times height of the image read,
+	// checked before it is decoded (optional, defaults to
+	// DefaultMaxImagePixels)
+	MaxPixels int
+}
+
+// withDefaults returns the options with unset fields defaulted
+func (o *ImageOptions) withDefaults() ImageOptions {
+	var opts ImageOptions
+	if o != nil {
+		opts = *o
+	}
+	if opts.MaxDimension <= 0 {
+		opts.MaxDimension = DefaultMaxImageDimension
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxImageBytes
+	}
+	if opts.Quality <= 0 || opts.Quality > 100 {
+		opts.Quality = DefaultJPEGQuality
+	}
+	if opts.MaxInputBytes <= 0 {
+		opts.MaxInputBytes = DefaultMaxImageInputBytes
+	}
+	if opts.MaxPixels <= 0 {
+		opts.MaxPixels = DefaultMaxImagePixels
+	}
+	return opts
+}
+
+// EncodeImage reads a PNG, GIF or JPEG image and returns it as base64
+// encoded JPEG for SendMessageData.JpegBase64. Upright JPEGs within the
+// limits are sent unchanged; other images are converted, with transparency
+// flattened onto white, only the first frame of an animated GIF and JPEGs
+// turned upright according to their EXIF orientation. Images over
+// MaxInputBytes or MaxPixels return ErrImageTooLarge. opts may be nil.
+func EncodeImage(r io.Reader, opts *ImageOptions) (string, error) {
+	o := opts.withDefaults()
+
+	data, err := io.ReadAll(io.LimitReader(r, int64(o.MaxInputBytes)+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > o.MaxInputBytes {
+		return "", fmt.Errorf("%w: over %d bytes", ErrImageTooLarge, o.MaxInputBytes)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return "", ErrUnsupportedImage
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if pixels := int64(cfg.Width) * int64(cfg.Height); pixels > int64(o.MaxPixels) {
+		return "", fmt.Errorf("%w: %dx%d is over %d pixels", ErrImageTooLarge, cfg.Width, cfg.Height, o.MaxPixels)
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	if format == "jpeg" && orientation == 1 && max(cfg.Width, cfg.Height) <= o.MaxDimension && len(data) <= o.MaxBytes {
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	jpg, err := encodeJPEG(orient(flatten(img), orientation), o)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(jpg), nil
+}
+
+// encodeJPEG encodes an image within the dimension and size limits, first
+// lowering the quality and then the size until it fits
+func encodeJPEG(img *image.RGBA, o ImageOptions) ([]byte, error) {
+	longest := o.MaxDimension
+	for {
+		img = downsize(img, longest)
+
+		var buf bytes.Buffer
+		for quality := o.Quality; ; quality -= 10 {
+			buf.Reset()
+			if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: max(quality, minJPEGQuality)}); err != nil {
+				return nil, fmt.Errorf("failed to encode image: %w", err)
+			}
+			if buf.Len() <= o.MaxBytes {
+				return buf.Bytes(), nil
+			}
+			if quality <= minJPEGQuality {
+				break
+			}
+		}
+
+		b := img.Bounds()
+		longest = max(b.Dx(), b.Dy()) * 3 / 4
+		if longest < 16 {
+			return nil, fmt.Errorf("failed to encode image: cannot fit in %d bytes", o.MaxBytes)
+		}
+	}
+}
+
+// flatten draws an image onto a white RGBA canvas, as JPEG has no transparency
+func flatten(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, from 1 to 8, or 1
+// if it has none
+func jpegOrientation(data []byte) int {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			// Fill byte before a marker
+			i++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			// The EXIF segment comes before the image data
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of EXIF
+// (TIFF) data, or returns 1 if it has none
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int64(order.Uint32(tiff[4:]))
+	if ifd+2 > int64(len(tiff)) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for k := range entries {
+		e := int(ifd) + 2 + 12*k
+		if e+12 > len(tiff) {
+			break
+		}
+		// Orientation is tag 0x0112, a SHORT (type 3)
+		if order.Uint16(tiff[e:]) != 0x0112 {
+			continue
+		}
+		if order.Uint16(tiff[e+2:]) != 3 {
+			return 1
+		}
+		if v := int(order.Uint16(tiff[e+8:])); v >= 1 && v <= 8 {
+			return v
+		}
+		return 1
+	}
+	return 1
+}
+
+// orient turns an image upright according to its EXIF orientation
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := sw, sh
+	if orientation >= 5 {
+		// Orientations 5 to 8 swap the sides
+		dw, dh = sh, sw
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		for x := range dw {
+			// Each case names how the stored image is turned
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = sw-1-x, y
+			case 3: // rotated 180°
+				sx, sy = sw-1-x, sh-1-y
+			case 4: // flipped
+				sx, sy = x, sh-1-y
+			case 5: // mirrored and rotated 90° counter-clockwise
+				sx, sy = y, x
+			case 6: // rotated 90° counter-clockwise
+				sx, sy = y, sh-1-x
+			case 7: // mirrored and rotated 90° clockwise
+				sx, sy = sw-1-y, sh-1-x
+			case 8: // rotated 90° clockwise
+				sx, sy = sw-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(sx, sy):][:4])
+		}
+	}
+	return dst
+}
+
+// downsize scales an image so its longest side is at most longest pixels,
+// averaging the source pixels covered by each destination pixel
+func downsize(src *image.RGBA, longest int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= longest && sh <= longest {
+		return src
+	}
+
+	dw, dh := longest, max(sh*longest/sw, 1)
+	if sh > sw {
+		dw, dh = max(sw*longest/sh, 1), longest
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		for x := range dw {
+			x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r, g, b, a = r+uint32(p[0]), g+uint32(p[1]), b+uint32(p[2]), a+uint32(p[3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
+
+// SendImage sends a PNG, GIF or JPEG image to a conversation, converting it
+// to JPEG within the limits of Config.Images
+func (c *Client) SendImage(ctx context.Context, conversationID string, r io.Reader) error {
+	encoded, err := EncodeImage(r, c.images)
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(ctx, conversationID, SendMessageData{JpegBase64: encoded})
+}
+
+// SendTextAndImage sends a text message followed by an image. The image is
+// converted first, so nothing is sent if it cannot be.
+func (c *Client) SendTextAndImage(ctx context.Context, conversationID, text string, r io.Reader) error {
+	encoded, err := EncodeImage(r, c.images)
+	if err != nil {
+		return err
+	}
+	if err := c.SendMessage(ctx, conversationID, SendMessageData{Message: text}); err != nil {
+		return err
+	}
+	if err := c.SendMessage(ctx, conversationID, SendMessageData{JpegBase64: encoded}); err != nil {
+		return fmt.Errorf("failed to send image after text: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package hostex_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/hostextest"
+)
+
+// newImage returns a w×h image filled with fill
+func newImage(w, h int, fill color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, fill)
+		}
+	}
+	return img
+}
+
+// decodeJPEG decodes base64 encoded JPEG data
+func decodeJPEG(t *testing.T, encoded string) (image.Image, int) {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("invalid base64: %v", err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("invalid JPEG: %v", err)
+	}
+	return img, len(data)
+}
+
+// withOrientation inserts an EXIF segment with an orientation tag after the
+// start of a JPEG
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big endian header, IFD at offset 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, byte(orientation >> 8), byte(orientation), 0, 0, // orientation SHORT
+		0, 0, 0, 0, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	size := len(payload) + 2
+	app1 := append([]byte{0xFF, 0xE1, byte(size >> 8), byte(size)}, payload...)
+	return slices.Concat(jpg[:2], app1, jpg[2:])
+}
+
+func TestEncodeImage(t *testing.T) {
+	var pngData bytes.Buffer
+	png.Encode(&pngData, newImage(3000, 1500, color.NRGBA{A: 0}))
+
+	var gifData bytes.Buffer
+	gif.Encode(&gifData, newImage(40, 20, color.NRGBA{R: 255, A: 255}), nil)
+
+	var jpegData bytes.Buffer
+	jpeg.Encode(&jpegData, newImage(100, 100, color.Black), nil)
+
+	t.Run("transparent PNG is flattened and downsized", func(t *testing.T) {
+		encoded, err := hostex.EncodeImage(bytes.NewReader(pngData.Bytes()), nil)
+		if err != nil {
+			t.Fatalf("EncodeImage failed: %v", err)
+		}
+		img, _ := decodeJPEG(t, encoded)
+		if b := img.Bounds(); b.Dx() != 2048 || b.Dy() != 1024 {
+			t.Errorf("Expected 2048x1024, got %dx%d", b.Dx(), b.Dy())
+		}
+		if r, g, b, _ := img.At(10, 10).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+			t.Errorf("Expected a white background, got %v", img.At(10, 10))
+		}
+	})
+
+	t.Run("GIF is converted", func(t *testing.T) {
+		encoded, err := hostex.EncodeImage(&gifData, &hostex.ImageOptions{MaxDimension: 10})
+		if err != nil {
+			t.Fatalf("EncodeImage failed: %v", err)
+		}
+		img, _ := decodeJPEG(t, encoded)
+		if b := img.Bounds(); b.Dx() != 10 || b.Dy() != 5 {
+			t.Errorf("Expected 10x5, got %dx%d", b.Dx(), b.Dy())
+		}
+	})
+
+	t.Run("small JPEG is sent unchanged", func(t *testing.T) {
+		encoded, err := hostex.EncodeImage(bytes.NewReader(jpegData.Bytes()), nil)
+		if err != nil {
+			t.Fatalf("EncodeImage failed: %v", err)
+		}
+		if encoded != base64.StdEncoding.EncodeToString(jpegData.Bytes()) {
+			t.Error("Expected the JPEG to be passed through")
+		}
+	})
+
+	t.Run("large JPEG is shrunk to the byte limit", func(t *testing.T) {
+		noise := image.NewNRGBA(image.Rect(0, 0, 800, 800))
+		rng := rand.New(rand.NewPCG(1, 2))
+		for i := range noise.Pix {
+			noise.Pix[i] = uint8(rng.IntN(256))
+		}
+		var big bytes.Buffer
+		jpeg.Encode(&big, noise, &jpeg.Options{Quality: 100})
+
+		const limit = 50 << 10
+		encoded, err := hostex.EncodeImage(&big, &hostex.ImageOptions{MaxBytes: limit})
+		if err != nil {
+			t.Fatalf("EncodeImage failed: %v", err)
+		}
+		if _, size := decodeJPEG(t, encoded); size > limit {
+			t.Errorf("Expected at most %d bytes, got %d", limit, size)
+		}
+	})
+
+	t.Run("EXIF orientation is applied", func(t *testing.T) {
+		// Stored sideways: red on the left, blue on the right; upright the
+		// red side is at the top
+		sideways := newImage(40, 20, color.NRGBA{B: 255, A: 255})
+		for y := range 20 {
+			for x := range 20 {
+				sideways.Set(x, y, color.NRGBA{R: 255, A: 255})
+			}
+		}
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, sideways, nil)
+
+		encoded, err := hostex.EncodeImage(bytes.NewReader(withOrientation(buf.Bytes(), 6)), nil)
+		if err != nil {
+			t.Fatalf("EncodeImage failed: %v", err)
+		}
+		img, _ := decodeJPEG(t, encoded)
+		if b := img.Bounds(); b.Dx() != 20 || b.Dy() != 40 {
+			t.Fatalf("Expected 20x40, got %dx%d", b.Dx(), b.Dy())
+		}
+		if r, _, b, _ := img.At(10, 5).RGBA(); r>>8 < 200 || b>>8 > 50 {
+			t.Errorf("Expected red at the top, got %v", img.At(10, 5))
+		}
+		if r, _, b, _ := img.At(10, 35).RGBA(); r>>8 > 50 || b>>8 < 200 {
+			t.Errorf("Expected blue at the bottom, got %v", img.At(10, 35))
+		}
+	})
+
+	t.Run("input over the byte limit", func(t *testing.T) {
+		_, err := hostex.EncodeImage(bytes.NewReader(pngData.Bytes()), &hostex.ImageOptions{MaxInputBytes: 100})
+		if !errors.Is(err, hostex.ErrImageTooLarge) {
+			t.Errorf("Expected ErrImageTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("input over the pixel limit", func(t *testing.T) {
+		_, err := hostex.EncodeImage(bytes.NewReader(pngData.Bytes()), &hostex.ImageOptions{MaxPixels: 1000 * 1000})
+		if !errors.Is(err, hostex.ErrImageTooLarge) {
+			t.Errorf("Expected ErrImageTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := hostex.EncodeImage(strings.NewReader("%PDF-1.7"), nil)
+		if !errors.Is(err, hostex.ErrUnsupportedImage) {
+			t.Errorf("Expected ErrUnsupportedImage, got %v", err)
+		}
+	})
+}
+
+func TestSendTextAndImage(t *testing.T) {
+	srv := hostextest.NewServer()
+	defer srv.Close()
+	srv.Seed(hostextest.DefaultFixtures())
+	client := srv.Client()
+	ctx := context.Background()
+
+	var photo bytes.Buffer
+	png.Encode(&photo, newImage(64, 64, color.White))
+
+	if err := client.SendTextAndImage(ctx, "conv-1", "Here is the key box:", &photo); err != nil {
+		t.Fatalf("SendTextAndImage failed: %v", err)
+	}
+	msgs := srv.Messages("conv-1")
+	text, img := msgs[len(msgs)-2], msgs[len(msgs)-1]
+	if text.Content != "Here is the key box:" || img.ImageURL == "" {
+		t.Errorf("Expected text then image, got %+v, %+v", text, img)
+	}
+
+	// Nothing is sent if the image cannot be converted
+	srv.ResetRequests()
+	if err := client.SendTextAndImage(ctx, "conv-1", "Oops", strings.NewReader("not an image")); !errors.Is(err, hostex.ErrUnsupportedImage) {
+		t.Errorf("Expected ErrUnsupportedImage, got %v", err)
+	}
+	if err := client.SendImage(ctx, "conv-1", strings.NewReader("not an image")); !errors.Is(err, hostex.ErrUnsupportedImage) {
+		t.Errorf("Expected ErrUnsupportedImage, got %v", err)
+	}
+	srv.AssertNotCalled(t, "POST /conversations/{id}")
+}
@@ -182,8 +182,8 @@ func TestIntegration_ListReservations(t *testing.T) {
 
 	t.Run("filter by date range", func(t *testing.T) {
 		resp, err := client.ListReservations(ctx, &hostex.ListReservationsParams{
-			StartCheckInDate: "2025-09-01",
-			EndCheckInDate:   "2025-12-31",
+			StartCheckInDate: hostex.MustParseDate("2025-09-01"),
+			EndCheckInDate:   hostex.MustParseDate("2025-12-31"),
 			Limit:            5,
 		})
 		if err != nil {
@@ -488,8 +488,8 @@ func TestIntegration_Availabilities(t *testing.T) {
 	t.Run("list availabilities", func(t *testing.T) {
 		resp, err := client.ListAvailabilities(ctx, hostex.ListAvailabilitiesParams{
 			PropertyIDs: fmt.Sprintf("%d", propertyID),
-			StartDate:   "2025-11-01",
-			EndDate:     "2025-11-30",
+			StartDate:   hostex.MustParseDate("2025-11-01"),
+			EndDate:     hostex.MustParseDate("2025-11-30"),
 		})
 		if err != nil {
 			t.Fatalf("ListAvailabilities failed: %v", err)
@@ -509,7 +509,7 @@ func TestIntegration_Availabilities(t *testing.T) {
 		// To manually test:
 		// err := client.UpdateAvailabilities(ctx, hostex.UpdateAvailabilitiesData{
 		// 	PropertyIDs: []int{propertyID},
-		// 	Dates:       []string{"2025-12-25"},
+		// 	Dates:       []hostex.Date{hostex.MustParseDate("2025-12-25")},
 		// 	Available:   false,
 		// })
 	})
@@ -548,8 +548,8 @@ func TestIntegration_Listings(t *testing.T) {
 
 	t.Run("get listing calendar", func(t *testing.T) {
 		resp, err := client.GetListingCalendar(ctx, hostex.GetListingCalendarData{
-			StartDate: "2025-11-01",
-			EndDate:   "2025-11-30",
+			StartDate: hostex.MustParseDate("2025-11-01"),
+			EndDate:   hostex.MustParseDate("2025-11-30"),
 			Listings:  testListings,
 		})
 		if err != nil {
@@ -0,0 +1,50 @@
+// Package paginate iterates over offset-paginated list endpoints. It backs
+// the iterators of both the client and the hostexmock package.
+package paginate
+
+import (
+	"context"
+	"iter"
+)
+
+// DefaultPageSize is the page size used when the caller does not set one
+const DefaultPageSize = 100
+
+// Seq returns an iterator that fetches pages of pageSize items starting at
+// offset until the reported total is reached or a page comes back empty. A
+// pageSize of zero or less means DefaultPageSize. Every range starts again
+// from offset, and ctx is checked before each page is fetched.
+func Seq[T any](ctx context.Context, offset, pageSize int, fetch func(ctx context.Context, offset, limit int) ([]T, int, error)) iter.Seq2[T, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	return func(yield func(T, error) bool) {
+		offset := offset
+		for {
+			if err := ctx.Err(); err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			items, total, err := fetch(ctx, offset, pageSize)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			offset += len(items)
+			if len(items) == 0 || offset >= total {
+				return
+			}
+		}
+	}
+}
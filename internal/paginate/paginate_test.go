@@ -0,0 +1,71 @@
+package paginate_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/keithah/hostex-go/internal/paginate"
+)
+
+// numbers serves 0..total-1 and records the requested limits
+func numbers(total int, limits *[]int) func(ctx context.Context, offset, limit int) ([]int, int, error) {
+	return func(ctx context.Context, offset, limit int) ([]int, int, error) {
+		*limits = append(*limits, limit)
+		var page []int
+		for i := offset; i < min(offset+limit, total); i++ {
+			page = append(page, i)
+		}
+		return page, total, nil
+	}
+}
+
+func TestSeq(t *testing.T) {
+	var limits []int
+	seq := paginate.Seq(context.Background(), 1, 2, numbers(6, &limits))
+
+	// Every range starts again from the offset
+	for range 2 {
+		var got []int
+		for n, err := range seq {
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got = append(got, n)
+		}
+		if !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+			t.Errorf("Expected 1 to 5, got %v", got)
+		}
+	}
+	if !slices.Equal(limits, []int{2, 2, 2, 2, 2, 2}) {
+		t.Errorf("Expected pages of 2, got %v", limits)
+	}
+
+	limits = nil
+	for range paginate.Seq(context.Background(), 0, 0, numbers(1, &limits)) {
+	}
+	if !slices.Equal(limits, []int{paginate.DefaultPageSize}) {
+		t.Errorf("Expected the default page size, got %v", limits)
+	}
+}
+
+func TestSeq_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var limits []int
+
+	var got []int
+	var last error
+	for n, err := range paginate.Seq(ctx, 0, 2, numbers(6, &limits)) {
+		if err != nil {
+			last = err
+			break
+		}
+		got = append(got, n)
+		cancel()
+	}
+	if !errors.Is(last, context.Canceled) || len(got) != 2 || len(limits) != 1 {
+		t.Errorf("Expected the next page not to be fetched, got %v, %v after %d pages", got, last, len(limits))
+	}
+}
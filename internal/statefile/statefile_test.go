@@ -0,0 +1,41 @@
+package statefile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keithah/hostex-go/internal/statefile"
+)
+
+type state struct {
+	Count int `json:"count"`
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	// A missing file leaves the value as is
+	s := state{Count: 1}
+	if err := statefile.Load(path, &s); err != nil || s.Count != 1 {
+		t.Fatalf("Load of a missing file = %+v, %v", s, err)
+	}
+
+	if err := statefile.Save(path, state{Count: 2}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := statefile.Load(path, &s); err != nil || s.Count != 2 {
+		t.Errorf("Load = %+v, %v, want count 2", s, err)
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file, got %d entries", len(entries))
+	}
+
+	os.WriteFile(path, []byte("{"), 0o644)
+	if err := statefile.Load(path, &s); err == nil {
+		t.Error("Expected a corrupt file to fail")
+	}
+}
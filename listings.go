@@ -8,24 +8,33 @@ import (
 // ListingCalendarResponse represents the response from getting listing calendar
 type ListingCalendarResponse struct {
 	Listings []struct {
-		ChannelType string `json:"channel_type"`
-		ListingID   string `json:"listing_id"`
+		ChannelType ChannelType `json:"channel_type"`
+		ListingID   string      `json:"listing_id"`
 		Calendar    []struct {
-			Date              string `json:"date"`
-			Price             int    `json:"price,omitempty"`
-			Inventory         int    `json:"inventory,omitempty"`
-			Available         bool   `json:"available,omitempty"`
-			MinStay           int    `json:"min_stay,omitempty"`
-			MaxStay           int    `json:"max_stay,omitempty"`
-			ClosedToArrival   bool   `json:"closed_to_arrival,omitempty"`
-			ClosedToDeparture bool   `json:"closed_to_departure,omitempty"`
+			Date              Date `json:"date"`
+			Price             int  `json:"price,omitempty"`
+			Inventory         int  `json:"inventory,omitempty"`
+			Available         bool `json:"available,omitempty"`
+			MinStay           int  `json:"min_stay,omitempty"`
+			MaxStay           int  `json:"max_stay,omitempty"`
+			ClosedToArrival   bool `json:"closed_to_arrival,omitempty"`
+			ClosedToDeparture bool `json:"closed_to_departure,omitempty"`
 		} `json:"calendar"`
 	} `json:"listings"`
 }
 
 // GetListingCalendar retrieves calendar information for multiple listings
 func (c *Client) GetListingCalendar(ctx context.Context, data GetListingCalendarData) (*ListingCalendarResponse, error) {
-	resp, err := c.doRequest(ctx, "POST", "/listings/calendar", nil, data)
+	if err := c.validate(data); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "GetListingCalendar",
+		Method:    "POST",
+		Endpoint:  "/listings/calendar",
+		Body:      data,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -40,18 +49,45 @@ func (c *Client) GetListingCalendar(ctx context.Context, data GetListingCalendar
 
 // UpdateListingPrices updates listing prices for channel listings
 func (c *Client) UpdateListingPrices(ctx context.Context, data UpdateListingPricesData) error {
-	_, err := c.doRequest(ctx, "POST", "/listings/prices", nil, data)
+	if err := c.validate(data); err != nil {
+		return err
+	}
+
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "UpdateListingPrices",
+		Method:    "POST",
+		Endpoint:  "/listings/prices",
+		Body:      data,
+	})
 	return err
 }
 
 // UpdateListingInventories updates inventory levels for channel listings
 func (c *Client) UpdateListingInventories(ctx context.Context, data UpdateListingInventoriesData) error {
-	_, err := c.doRequest(ctx, "POST", "/listings/inventories", nil, data)
+	if err := c.validate(data); err != nil {
+		return err
+	}
+
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "UpdateListingInventories",
+		Method:    "POST",
+		Endpoint:  "/listings/inventories",
+		Body:      data,
+	})
 	return err
 }
 
 // UpdateListingRestrictions updates listing restrictions for channel listings
 func (c *Client) UpdateListingRestrictions(ctx context.Context, data UpdateListingRestrictionsData) error {
-	_, err := c.doRequest(ctx, "POST", "/listings/restrictions", nil, data)
+	if err := c.validate(data); err != nil {
+		return err
+	}
+
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "UpdateListingRestrictions",
+		Method:    "POST",
+		Endpoint:  "/listings/restrictions",
+		Body:      data,
+	})
 	return err
 }
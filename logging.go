@@ -0,0 +1,163 @@
+package hostex
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// redacted replaces sensitive values in log records
+const redacted = "[REDACTED]"
+
+// DefaultRedactedFields lists the query parameters and JSON fields whose
+// values are redacted from log records by default
+var DefaultRedactedFields = []string{
+	"guest_phone",
+	"guest_email",
+	"email",
+	"mobile",
+}
+
+// LoggingOptions controls what LoggingMiddleware records
+type LoggingOptions struct {
+	// LogBodies adds a debug level record with the request and response
+	// bodies of every call
+	LogBodies bool
+
+	// RedactFields lists query parameters and JSON fields whose values are
+	// redacted (optional, defaults to DefaultRedactedFields)
+	RedactFields []string
+}
+
+// LoggingMiddleware emits a structured record for every attempt of every
+// API call. It is installed automatically when Config.Logger is set.
+func LoggingMiddleware(logger *slog.Logger, opts LoggingOptions) Middleware {
+	fields := opts.RedactFields
+	if fields == nil {
+		fields = DefaultRedactedFields
+	}
+
+	redact := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		redact[strings.ToLower(field)] = true
+	}
+
+	return func(next RoundTrip) RoundTrip {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			duration := time.Since(start)
+
+			attrs := []slog.Attr{
+				slog.String("operation", req.Operation),
+				slog.String("method", req.Method),
+				slog.String("endpoint", req.Endpoint),
+				slog.Int("attempt", req.Attempt),
+				slog.Duration("duration", duration),
+			}
+			if len(req.Params) > 0 {
+				attrs = append(attrs, slog.String("params", redactParams(req.Params, redact)))
+			}
+			if resp != nil {
+				attrs = append(attrs, slog.Int("status", resp.StatusCode))
+				if resp.API != nil {
+					attrs = append(attrs,
+						slog.String("request_id", resp.API.RequestID),
+						slog.Int("error_code", resp.API.ErrorCode),
+					)
+				}
+			}
+
+			level := slog.LevelInfo
+			if err != nil {
+				level = slog.LevelWarn
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+
+			logger.LogAttrs(ctx, level, "hostex request", attrs...)
+
+			if opts.LogBodies && logger.Enabled(ctx, slog.LevelDebug) {
+				bodyAttrs := []slog.Attr{
+					slog.String("operation", req.Operation),
+					slog.Int("attempt", req.Attempt),
+				}
+				if len(req.Header) > 0 {
+					bodyAttrs = append(bodyAttrs, slog.Any("headers", redactHeaders(req.Header)))
+				}
+				if req.Body != nil {
+					bodyAttrs = append(bodyAttrs, slog.String("request_body", redactJSON(req.Body, redact)))
+				}
+				if resp != nil && resp.API != nil && resp.API.Data != nil {
+					bodyAttrs = append(bodyAttrs, slog.String("response_body", redactJSON(resp.API.Data, redact)))
+				}
+				logger.LogAttrs(ctx, slog.LevelDebug, "hostex request body", bodyAttrs...)
+			}
+
+			return resp, err
+		}
+	}
+}
+
+// redactParams encodes query parameters with sensitive values redacted
+func redactParams(params url.Values, redact map[string]bool) string {
+	clean := make(url.Values, len(params))
+	for key, values := range params {
+		if redact[strings.ToLower(key)] {
+			clean[key] = []string{redacted}
+			continue
+		}
+		clean[key] = values
+	}
+	return clean.Encode()
+}
+
+// redactHeaders copies headers with the access token redacted
+func redactHeaders(header http.Header) http.Header {
+	clean := header.Clone()
+	if clean.Get("Hostex-Access-Token") != "" {
+		clean.Set("Hostex-Access-Token", redacted)
+	}
+	return clean
+}
+
+// redactJSON encodes v as JSON with sensitive fields redacted at any depth
+func redactJSON(v interface{}, redact map[string]bool) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "<unencodable: " + err.Error() + ">"
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return string(raw)
+	}
+
+	clean, err := json.Marshal(redactValue(generic, redact))
+	if err != nil {
+		return string(raw)
+	}
+	return string(clean)
+}
+
+// redactValue walks decoded JSON replacing the values of sensitive fields
+func redactValue(v interface{}, redact map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if redact[strings.ToLower(key)] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(value, redact)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value, redact)
+		}
+	}
+	return v
+}
@@ -0,0 +1,130 @@
+package hostex_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+)
+
+func decodeLogRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to decode log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogging_RecordPerRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	client := newTestClientWithConfig(t, hostex.Config{
+		RetryPolicy: &hostex.NoRetry,
+		Logger:      logger,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"request_id":"req-42","error_code":404,"error_msg":"not found"}`))
+	})
+
+	_, err := client.ListReservations(context.Background(), &hostex.ListReservationsParams{
+		ReservationCode: "ABC",
+		Limit:           5,
+	})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	records := decodeLogRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 log record, got %d", len(records))
+	}
+
+	record := records[0]
+	expected := map[string]interface{}{
+		"level":      "WARN",
+		"operation":  "ListReservations",
+		"method":     "GET",
+		"endpoint":   "/reservations",
+		"params":     "limit=5&reservation_code=ABC",
+		"status":     float64(200),
+		"request_id": "req-42",
+		"error_code": float64(404),
+		"attempt":    float64(1),
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, record[key])
+		}
+	}
+	if _, ok := record["duration"]; !ok {
+		t.Error("Expected duration attribute")
+	}
+}
+
+func TestLogging_RedactsBodies(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := newTestClientWithConfig(t, hostex.Config{
+		RetryPolicy: &hostex.NoRetry,
+		Logger:      logger,
+		LogBodies:   true,
+		Middlewares: []hostex.Middleware{
+			func(next hostex.RoundTrip) hostex.RoundTrip {
+				return func(ctx context.Context, req *hostex.Request) (*hostex.Response, error) {
+					req.Header = http.Header{"Hostex-Access-Token": {"secret-token"}}
+					return next(ctx, req)
+				}
+			},
+		},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"request_id":"req-1","error_code":200,"error_msg":"Done.","data":{"reservations":[{"reservation_code":"ABC","guest_phone":"+15551234","guest_email":"guest@example.com"}],"total":1}}`))
+	})
+
+	if _, err := client.ListReservations(context.Background(), nil); err != nil {
+		t.Fatalf("ListReservations failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, secret := range []string{"+15551234", "guest@example.com", "secret-token"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("Expected %q to be redacted from logs:\n%s", secret, output)
+		}
+	}
+	if !strings.Contains(output, "ABC") {
+		t.Errorf("Expected response body to be logged:\n%s", output)
+	}
+}
+
+func TestLogging_NoBodiesByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := newTestClientWithConfig(t, hostex.Config{
+		RetryPolicy: &hostex.NoRetry,
+		Logger:      logger,
+	}, okHandler)
+
+	err := client.SendMessage(context.Background(), "conv-1", hostex.SendMessageData{Message: "door code 1234"})
+	if err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "door code 1234") {
+		t.Errorf("Expected request body not to be logged:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,100 @@
+package hostex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Request describes a single Hostex API call as seen by middleware
+type Request struct {
+	// Operation is the name of the Client method making the call, e.g. "ListReservations"
+	Operation string
+
+	// Method is the HTTP method
+	Method string
+
+	// Endpoint is the API endpoint relative to the base URL, e.g. "/reservations"
+	Endpoint string
+
+	// Params are the query parameters
+	Params url.Values
+
+	// Body is the request payload, encoded as JSON when the request is sent
+	Body interface{}
+
+	// Header holds additional headers sent with the request
+	Header http.Header
+
+	// Idempotent reports whether the call is safe to repeat
+	Idempotent bool
+
+	// Attempt is the 1-based attempt number. It is updated by the retry
+	// middleware, so after a call returns it holds the number of attempts made.
+	Attempt int
+}
+
+// Response is the result of a Hostex API call as seen by middleware
+type Response struct {
+	// StatusCode is the HTTP status code
+	StatusCode int
+
+	// Header holds the HTTP response headers
+	Header http.Header
+
+	// API is the decoded API response, nil if the body could not be parsed
+	API *APIResponse
+}
+
+// RoundTrip performs a Hostex API call. On API errors it returns both the
+// response and an error.
+type RoundTrip func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware wraps a RoundTrip to add behavior around API calls
+type Middleware func(next RoundTrip) RoundTrip
+
+// chain wraps rt with the middlewares, the first middleware being the outermost
+func chain(rt RoundTrip, middlewares ...Middleware) RoundTrip {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if middlewares[i] != nil {
+			rt = middlewares[i](rt)
+		}
+	}
+	return rt
+}
+
+// RetryMiddleware retries failed calls according to policy. It is installed
+// automatically from Config.RetryPolicy; set that to NoRetry when adding
+// this middleware to Config.Middlewares yourself.
+func RetryMiddleware(policy RetryPolicy) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			for attempt := 1; ; attempt++ {
+				req.Attempt = attempt
+
+				resp, err := next(ctx, req)
+				if err == nil || !policy.shouldRetry(attempt, req.Idempotent, err) {
+					return resp, err
+				}
+
+				if sleepErr := sleep(ctx, policy.delay(attempt, err)); sleepErr != nil {
+					return resp, err
+				}
+			}
+		}
+	}
+}
+
+// RateLimitMiddleware waits on limiter before every call it wraps. It is
+// installed automatically from the rate limit settings in Config.
+func RateLimitMiddleware(limiter Limiter) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("rate limiter: %w", err)
+			}
+			return next(ctx, req)
+		}
+	}
+}
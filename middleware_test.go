@@ -0,0 +1,151 @@
+package hostex_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+)
+
+func TestMiddleware_Order(t *testing.T) {
+	var order []string
+
+	record := func(name string) hostex.Middleware {
+		return func(next hostex.RoundTrip) hostex.RoundTrip {
+			return func(ctx context.Context, req *hostex.Request) (*hostex.Response, error) {
+				order = append(order, name+" before")
+				resp, err := next(ctx, req)
+				order = append(order, name+" after")
+				return resp, err
+			}
+		}
+	}
+
+	client := newTestClientWithConfig(t, hostex.Config{
+		RetryPolicy: &hostex.NoRetry,
+		Middlewares: []hostex.Middleware{record("outer"), record("inner")},
+	}, okHandler)
+
+	if _, err := client.ListWebhooks(context.Background()); err != nil {
+		t.Fatalf("ListWebhooks failed: %v", err)
+	}
+
+	expected := []string{"outer before", "inner before", "inner after", "outer after"}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, order)
+			break
+		}
+	}
+}
+
+func TestMiddleware_SeesRequestAndResponse(t *testing.T) {
+	var seen *hostex.Request
+	var requestID string
+
+	inspect := func(next hostex.RoundTrip) hostex.RoundTrip {
+		return func(ctx context.Context, req *hostex.Request) (*hostex.Response, error) {
+			resp, err := next(ctx, req)
+			seen = req
+			if resp != nil && resp.API != nil {
+				requestID = resp.API.RequestID
+			}
+			return resp, err
+		}
+	}
+
+	client := newTestClientWithConfig(t, hostex.Config{
+		RetryPolicy: &hostex.NoRetry,
+		Middlewares: []hostex.Middleware{inspect},
+	}, okHandler)
+
+	_, err := client.ListReservations(context.Background(), &hostex.ListReservationsParams{ReservationCode: "ABC"})
+	if err != nil {
+		t.Fatalf("ListReservations failed: %v", err)
+	}
+
+	if seen.Operation != "ListReservations" {
+		t.Errorf("Expected operation 'ListReservations', got '%s'", seen.Operation)
+	}
+	if seen.Method != "GET" || seen.Endpoint != "/reservations" {
+		t.Errorf("Expected GET /reservations, got %s %s", seen.Method, seen.Endpoint)
+	}
+	if seen.Params.Get("reservation_code") != "ABC" {
+		t.Errorf("Expected reservation_code param 'ABC', got '%s'", seen.Params.Get("reservation_code"))
+	}
+	if !seen.Idempotent {
+		t.Error("Expected ListReservations to be idempotent")
+	}
+	if requestID != "req-1" {
+		t.Errorf("Expected request ID 'req-1', got '%s'", requestID)
+	}
+}
+
+func TestMiddleware_MutatesRequest(t *testing.T) {
+	var gotHeader, gotQuery string
+
+	client := newTestClientWithConfig(t, hostex.Config{
+		RetryPolicy: &hostex.NoRetry,
+		Middlewares: []hostex.Middleware{
+			func(next hostex.RoundTrip) hostex.RoundTrip {
+				return func(ctx context.Context, req *hostex.Request) (*hostex.Response, error) {
+					req.Header = http.Header{"X-Audit-Id": {"audit-1"}}
+					req.Params.Set("limit", "7")
+					return next(ctx, req)
+				}
+			},
+		},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Audit-Id")
+		gotQuery = r.URL.Query().Get("limit")
+		okHandler(w, r)
+	})
+
+	if _, err := client.ListProperties(context.Background(), &hostex.ListPropertiesParams{Limit: 1}); err != nil {
+		t.Fatalf("ListProperties failed: %v", err)
+	}
+
+	if gotHeader != "audit-1" {
+		t.Errorf("Expected injected header 'audit-1', got '%s'", gotHeader)
+	}
+	if gotQuery != "7" {
+		t.Errorf("Expected mutated limit '7', got '%s'", gotQuery)
+	}
+}
+
+func TestMiddleware_SeesAttemptCount(t *testing.T) {
+	var calls atomic.Int32
+	var attempts int
+
+	client := newTestClientWithConfig(t, hostex.Config{
+		RetryPolicy: &fastRetry,
+		Middlewares: []hostex.Middleware{
+			func(next hostex.RoundTrip) hostex.RoundTrip {
+				return func(ctx context.Context, req *hostex.Request) (*hostex.Response, error) {
+					resp, err := next(ctx, req)
+					attempts = req.Attempt
+					return resp, err
+				}
+			},
+		},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		okHandler(w, r)
+	})
+
+	if _, err := client.ListWebhooks(context.Background()); err != nil {
+		t.Fatalf("ListWebhooks failed: %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
@@ -0,0 +1,231 @@
+package hostex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies
+var ErrCurrencyMismatch = errors.New("hostex: currency mismatch")
+
+// currencyExponents lists ISO 4217 currencies whose minor unit is not 1/100
+// of the major unit
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent returns the number of decimal places of the minor unit of
+// an ISO 4217 currency: 2 for USD (cents), 0 for JPY and 3 for KWD
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Money is an amount of money in the minor unit of an ISO 4217 currency, so
+// Money{Amount: 84000, Currency: "USD"} is $840.00 and
+// Money{Amount: 8400, Currency: "JPY"} is ¥8400. In JSON it is encoded as a
+// number in major units, e.g. 840 or 840.5, which is how the Hostex API
+// takes amounts; the currency travels in a separate field where the API has
+// one.
+type Money struct {
+	// Amount is the amount in minor units of Currency
+	Amount int64
+
+	// Currency is the ISO 4217 currency code, e.g. "USD"
+	Currency string
+}
+
+// NewMoney returns an amount in minor units of currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney parses a decimal amount in major units, such as "840.00" or
+// "-12.5", into Money. It fails if the amount has more decimal places than
+// the currency's minor unit allows.
+func ParseMoney(amount, currency string) (Money, error) {
+	exp := CurrencyExponent(currency)
+
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("invalid amount %q: %s allows at most %d decimal places", amount, strings.ToUpper(currency), exp)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	value, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || strings.ContainsAny(whole+frac, "+-") {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if negative {
+		value = -value
+	}
+
+	return NewMoney(value, currency), nil
+}
+
+// MustParseMoney is like ParseMoney but panics on invalid input. It is
+// intended for constants and tests.
+func MustParseMoney(amount, currency string) Money {
+	m, err := ParseMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Exponent returns the number of decimal places of m's minor unit
+func (m Money) Exponent() int {
+	return CurrencyExponent(m.Currency)
+}
+
+// Decimal returns the amount in major units as a decimal string, e.g. "840.00"
+func (m Money) Decimal() string {
+	exp := m.Exponent()
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String returns the amount with its currency, e.g. "840.00 USD"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// sameCurrency checks that m and other can be combined. An amount without a
+// currency takes on the currency of the other amount.
+func (m Money) sameCurrency(other Money) (string, error) {
+	switch {
+	case m.Currency == other.Currency || other.Currency == "":
+		return m.Currency, nil
+	case m.Currency == "":
+		return other.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+}
+
+// Add returns m + other. It fails if the amounts are in different currencies.
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.sameCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+// Sub returns m - other. It fails if the amounts are in different currencies.
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.sameCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}, nil
+}
+
+// Mul returns m multiplied by n, e.g. a nightly rate times the number of nights
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Compare returns -1, 0 or +1 depending on whether m is less than, equal to
+// or greater than other. It fails if the amounts are in different currencies.
+func (m Money) Compare(other Money) (int, error) {
+	if _, err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// MajorUnits returns the amount in whole major units, e.g. 840 for
+// "840.00 USD". It fails if the amount has a fractional part.
+func (m Money) MajorUnits() (int, error) {
+	unit := int64(1)
+	for range m.Exponent() {
+		unit *= 10
+	}
+	if m.Amount%unit != 0 {
+		return 0, fmt.Errorf("%s is not a whole amount", m)
+	}
+	return int(m.Amount / unit), nil
+}
+
+// MarshalJSON encodes the amount as a number in major units: an integer for
+// whole amounts, e.g. 840, and a decimal otherwise, e.g. 840.50
+func (m Money) MarshalJSON() ([]byte, error) {
+	if units, err := m.MajorUnits(); err == nil {
+		return strconv.AppendInt(nil, int64(units), 10), nil
+	}
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON decodes a number in major units of m's currency. The
+// currency is left unchanged.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	// json.Number also accepts quoted numbers, which the API does not send
+	var number json.Number
+	if data[0] == '"' || json.Unmarshal(data, &number) != nil {
+		return fmt.Errorf("invalid amount %s: expected a number in major units", data)
+	}
+	parsed, err := ParseMoney(number.String(), m.Currency)
+	if err != nil {
+		return err
+	}
+	m.Amount = parsed.Amount
+	return nil
+}
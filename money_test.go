@@ -0,0 +1,178 @@
+package hostex_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		minor    int64
+		decimal  string
+	}{
+		{"840.00", "USD", 84000, "840.00"},
+		{"840", "usd", 84000, "840.00"},
+		{"0.5", "EUR", 50, "0.50"},
+		{"-12.34", "USD", -1234, "-12.34"},
+		{"8400", "JPY", 8400, "8400"},
+		{"1.234", "KWD", 1234, "1.234"},
+		{"0.05", "USD", 5, "0.05"},
+	}
+
+	for _, tt := range tests {
+		m, err := hostex.ParseMoney(tt.amount, tt.currency)
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %q) failed: %v", tt.amount, tt.currency, err)
+			continue
+		}
+		if m.Amount != tt.minor {
+			t.Errorf("ParseMoney(%q, %q): expected %d minor units, got %d", tt.amount, tt.currency, tt.minor, m.Amount)
+		}
+		if m.Decimal() != tt.decimal {
+			t.Errorf("ParseMoney(%q, %q): expected decimal %q, got %q", tt.amount, tt.currency, tt.decimal, m.Decimal())
+		}
+	}
+
+	for _, invalid := range [][2]string{{"1.5", "JPY"}, {"1.234", "USD"}, {"abc", "USD"}, {"--5", "USD"}, {"", "USD"}} {
+		if _, err := hostex.ParseMoney(invalid[0], invalid[1]); err == nil {
+			t.Errorf("Expected ParseMoney(%q, %q) to fail", invalid[0], invalid[1])
+		}
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	nightly := hostex.MustParseMoney("120.00", "USD")
+	total := nightly.Mul(7)
+	if total.String() != "840.00 USD" {
+		t.Errorf("Expected 840.00 USD, got %s", total)
+	}
+
+	commission := hostex.NewMoney(8400, "USD")
+	received, err := total.Sub(commission)
+	if err != nil {
+		t.Fatalf("Sub failed: %v", err)
+	}
+	if received.Amount != 75600 {
+		t.Errorf("Expected 75600, got %d", received.Amount)
+	}
+
+	if _, err := total.Add(hostex.NewMoney(100, "JPY")); !errors.Is(err, hostex.ErrCurrencyMismatch) {
+		t.Errorf("Expected currency mismatch, got %v", err)
+	}
+
+	if cmp, err := commission.Compare(total); err != nil || cmp != -1 {
+		t.Errorf("Expected commission < total, got %d, %v", cmp, err)
+	}
+}
+
+func TestMoney_MajorUnits(t *testing.T) {
+	if amount, err := hostex.MustParseMoney("840.00", "USD").MajorUnits(); err != nil || amount != 840 {
+		t.Errorf("Expected 840, got %d, %v", amount, err)
+	}
+	if amount, err := hostex.MustParseMoney("8400", "JPY").MajorUnits(); err != nil || amount != 8400 {
+		t.Errorf("Expected 8400, got %d, %v", amount, err)
+	}
+	if _, err := hostex.MustParseMoney("840.50", "USD").MajorUnits(); err == nil {
+		t.Error("Expected a fractional amount to fail")
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	tests := []struct {
+		money hostex.Money
+		json  string
+	}{
+		{hostex.MustParseMoney("840.00", "USD"), "840"},
+		{hostex.MustParseMoney("840.5", "USD"), "840.50"},
+		{hostex.MustParseMoney("8400", "JPY"), "8400"},
+		{hostex.MustParseMoney("1.234", "KWD"), "1.234"},
+	}
+
+	for _, tt := range tests {
+		body, err := json.Marshal(tt.money)
+		if err != nil || string(body) != tt.json {
+			t.Errorf("Marshal(%s): expected %s, got %s, %v", tt.money, tt.json, body, err)
+		}
+
+		decoded := hostex.Money{Currency: tt.money.Currency}
+		if err := json.Unmarshal(body, &decoded); err != nil || decoded != tt.money {
+			t.Errorf("Unmarshal(%s): expected %s, got %s, %v", body, tt.money, decoded, err)
+		}
+	}
+
+	var m hostex.Money
+	if err := json.Unmarshal([]byte(`"840"`), &m); err == nil {
+		t.Error("Expected a quoted amount to fail")
+	}
+}
+
+func TestCreateReservationData_JSON(t *testing.T) {
+	data := hostex.CreateReservationData{
+		PropertyID:       "12345",
+		CheckInDate:      hostex.MustParseDate("2024-07-01"),
+		CheckOutDate:     hostex.MustParseDate("2024-07-07"),
+		RateAmount:       hostex.MustParseMoney("840.00", "USD"),
+		CommissionAmount: hostex.MustParseMoney("84.00", "USD"),
+		ReceivedAmount:   hostex.MustParseMoney("756.00", "USD"),
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, fragment := range []string{`"rate_amount":840,`, `"commission_amount":84,`, `"received_amount":756,`, `"currency":"USD"`} {
+		if !strings.Contains(string(body), fragment) {
+			t.Errorf("Expected %s in %s", fragment, body)
+		}
+	}
+
+	var decoded hostex.CreateReservationData
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.RateAmount != data.RateAmount || decoded.CommissionAmount != data.CommissionAmount || decoded.ReceivedAmount != data.ReceivedAmount {
+		t.Errorf("Expected round trip to preserve amounts, got %+v", decoded)
+	}
+
+	// The currency field sets the exponent of the amounts
+	if err := json.Unmarshal([]byte(`{"currency":"KWD","rate_amount":1.234}`), &decoded); err != nil || decoded.RateAmount != hostex.NewMoney(1234, "KWD") {
+		t.Errorf("Expected 1.234 KWD, got %s, %v", decoded.RateAmount, err)
+	}
+
+	data.CommissionAmount = hostex.NewMoney(100, "EUR")
+	if _, err := json.Marshal(data); !errors.Is(err, hostex.ErrCurrencyMismatch) {
+		t.Errorf("Expected currency mismatch, got %v", err)
+	}
+}
+
+func TestCreateReservationData_Setters(t *testing.T) {
+	var data hostex.CreateReservationData
+	if err := data.SetRate(hostex.MustParseMoney("840.00", "USD")); err != nil {
+		t.Fatalf("SetRate failed: %v", err)
+	}
+	if err := data.SetCommission(hostex.MustParseMoney("84.00", "USD")); err != nil {
+		t.Fatalf("SetCommission failed: %v", err)
+	}
+
+	if err := data.SetReceived(hostex.MustParseMoney("756", "EUR")); !errors.Is(err, hostex.ErrCurrencyMismatch) {
+		t.Errorf("Expected currency mismatch, got %v", err)
+	}
+	if !data.ReceivedAmount.IsZero() {
+		t.Errorf("Expected a rejected amount to be left unset, got %s", data.ReceivedAmount)
+	}
+
+	// Replacing the only amounts in a currency may change it
+	data.CommissionAmount = hostex.Money{}
+	if err := data.SetRate(hostex.MustParseMoney("700", "EUR")); err != nil {
+		t.Errorf("Expected the rate to change currency, got %v", err)
+	}
+	if currency, _ := data.Currency(); currency != "EUR" {
+		t.Errorf("Expected EUR, got %q", currency)
+	}
+}
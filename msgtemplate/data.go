@@ -0,0 +1,123 @@
+package msgtemplate
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/keithah/hostex-go"
+)
+
+// Data is what a template is rendered with
+type Data struct {
+	Reservation hostex.Reservation
+	Property    hostex.Property
+
+	// CustomFields are the stay's custom fields, by name
+	CustomFields map[string]any
+
+	// LockCode is the door code for the stay
+	LockCode string
+}
+
+// LockCodeSource looks up the lock code of a stay. The Hostex API can set
+// lock codes but not read them back, so they come from the lock system or
+// from wherever UpdateLockCode is called.
+type LockCodeSource interface {
+	LockCode(ctx context.Context, stayCode string) (string, error)
+}
+
+// LockCodes is a LockCodeSource backed by a map from stay code to lock code
+type LockCodes map[string]string
+
+// LockCode implements LockCodeSource
+func (m LockCodes) LockCode(ctx context.Context, stayCode string) (string, error) {
+	return m[stayCode], nil
+}
+
+// Service is the part of the API a Loader uses
+type Service interface {
+	hostex.PropertyService
+	hostex.ReservationService
+}
+
+// LoaderOptions configures a Loader
+type LoaderOptions struct {
+	// LockCodes looks up lock codes (optional, leaves LockCode empty)
+	LockCodes LockCodeSource
+}
+
+// Loader builds template data for reservations. Properties are fetched once
+// and cached. It is safe for concurrent use.
+type Loader struct {
+	svc  Service
+	opts LoaderOptions
+
+	mu         sync.Mutex
+	properties map[int]hostex.Property
+}
+
+// NewLoader creates a Loader
+func NewLoader(svc Service, opts LoaderOptions) *Loader {
+	return &Loader{
+		svc:        svc,
+		opts:       opts,
+		properties: make(map[int]hostex.Property),
+	}
+}
+
+// Load returns the template data for a reservation: its property, its custom
+// fields from GetCustomFields and its lock code
+func (l *Loader) Load(ctx context.Context, r hostex.Reservation) (*Data, error) {
+	property, err := l.property(ctx, r.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &Data{Reservation: r, Property: property, CustomFields: make(map[string]any)}
+	if fields, ok := r.CustomFields.(map[string]any); ok {
+		maps.Copy(data.CustomFields, fields)
+	}
+	if r.StayCode == "" {
+		return data, nil
+	}
+
+	resp, err := l.svc.GetCustomFields(ctx, r.StayCode)
+	if err != nil {
+		return nil, fmt.Errorf("msgtemplate: failed to get custom fields for %s: %w", r.StayCode, err)
+	}
+	maps.Copy(data.CustomFields, resp.CustomFields)
+
+	if l.opts.LockCodes != nil {
+		data.LockCode, err = l.opts.LockCodes.LockCode(ctx, r.StayCode)
+		if err != nil {
+			return nil, fmt.Errorf("msgtemplate: failed to get lock code for %s: %w", r.StayCode, err)
+		}
+	}
+	return data, nil
+}
+
+// property returns a property from the cache, fetching it on a miss
+func (l *Loader) property(ctx context.Context, id int) (hostex.Property, error) {
+	l.mu.Lock()
+	p, ok := l.properties[id]
+	l.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	resp, err := l.svc.ListProperties(ctx, &hostex.ListPropertiesParams{ID: id})
+	if err != nil {
+		return hostex.Property{}, fmt.Errorf("msgtemplate: failed to get property %d: %w", id, err)
+	}
+	for _, p := range resp.Properties {
+		if p.ID == id {
+			l.mu.Lock()
+			l.properties[id] = p
+			l.mu.Unlock()
+			return p, nil
+		}
+	}
+	return hostex.Property{}, fmt.Errorf("msgtemplate: property %d: %w", id, hostex.ErrNotFound)
+}
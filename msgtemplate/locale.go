@@ -0,0 +1,161 @@
+package msgtemplate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/keithah/hostex-go"
+)
+
+// Locale holds the words and layouts used to format dates and counts in one
+// language
+type Locale struct {
+	// Tag is the language tag, e.g. "en-US"
+	Tag string
+
+	// Months are the month names, starting with January
+	Months [12]string
+
+	// Weekdays are the weekday names, starting with Sunday
+	Weekdays [7]string
+
+	// LongDate lays out a date with the placeholders {weekday}, {day},
+	// {month} and {year}
+	LongDate string
+
+	// ShortDate is a time layout for numeric dates, e.g. "01/02/2006"
+	ShortDate string
+
+	// Singular reports whether a count takes the singular form (optional,
+	// defaults to n == 1)
+	Singular func(n int) bool
+}
+
+// Built-in locales
+var (
+	English = Locale{
+		Tag:       "en-US",
+		Months:    [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		Weekdays:  [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		LongDate:  "{weekday}, {month} {day}, {year}",
+		ShortDate: "01/02/2006",
+	}
+
+	BritishEnglish = Locale{
+		Tag:       "en-GB",
+		Months:    English.Months,
+		Weekdays:  English.Weekdays,
+		LongDate:  "{weekday} {day} {month} {year}",
+		ShortDate: "02/01/2006",
+	}
+
+	French = Locale{
+		Tag:       "fr-FR",
+		Months:    [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		Weekdays:  [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		LongDate:  "{weekday} {day} {month} {year}",
+		ShortDate: "02/01/2006",
+		Singular:  func(n int) bool { return n == 0 || n == 1 },
+	}
+
+	German = Locale{
+		Tag:       "de-DE",
+		Months:    [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		Weekdays:  [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		LongDate:  "{weekday}, {day}. {month} {year}",
+		ShortDate: "02.01.2006",
+	}
+
+	Spanish = Locale{
+		Tag:       "es-ES",
+		Months:    [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		Weekdays:  [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		LongDate:  "{weekday}, {day} de {month} de {year}",
+		ShortDate: "02/01/2006",
+	}
+)
+
+// locales are the built-in locales, in lookup order
+var locales = []Locale{English, BritishEnglish, French, German, Spanish}
+
+// LookupLocale returns the built-in locale for a language tag such as
+// "en-GB" or "fr". A bare language matches the first locale for it.
+func LookupLocale(tag string) (Locale, bool) {
+	for _, l := range locales {
+		if strings.EqualFold(l.Tag, tag) {
+			return l, true
+		}
+	}
+	for _, l := range locales {
+		lang, _, _ := strings.Cut(l.Tag, "-")
+		if strings.EqualFold(lang, tag) {
+			return l, true
+		}
+	}
+	return Locale{}, false
+}
+
+// FormatDate formats a date with LongDate, or returns "" for a zero or invalid Date
+func (l Locale) FormatDate(d hostex.Date) string {
+	if !d.IsValid() {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{weekday}", l.Weekday(d),
+		"{day}", strconv.Itoa(d.Day),
+		"{month}", l.Months[d.Month-1],
+		"{year}", strconv.Itoa(d.Year),
+	).Replace(l.LongDate)
+}
+
+// FormatShortDate formats a date with ShortDate, or returns "" for a zero or invalid Date
+func (l Locale) FormatShortDate(d hostex.Date) string {
+	if !d.IsValid() {
+		return ""
+	}
+	return d.In(time.UTC).Format(l.ShortDate)
+}
+
+// Weekday returns the name of the day of the week of d, or "" for a zero or invalid Date
+func (l Locale) Weekday(d hostex.Date) string {
+	if !d.IsValid() {
+		return ""
+	}
+	return l.Weekdays[d.In(time.UTC).Weekday()]
+}
+
+// Plural formats a count followed by the singular or plural form of a noun,
+// e.g. "1 guest" or "3 guests"
+func (l Locale) Plural(n int, singular, plural string) string {
+	one := n == 1
+	if l.Singular != nil {
+		one = l.Singular(n)
+	}
+	if one {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// funcs returns the template helpers for a locale
+func (l Locale) funcs() template.FuncMap {
+	return template.FuncMap{
+		"date":      l.FormatDate,
+		"shortDate": l.FormatShortDate,
+		"weekday":   l.Weekday,
+		"plural":    l.Plural,
+		"nights": func(r hostex.Reservation) int {
+			return hostex.NightsBetween(r.CheckInDate, r.CheckOutDate)
+		},
+		"firstName": func(name string) string {
+			if fields := strings.Fields(name); len(fields) > 0 {
+				return fields[0]
+			}
+			return ""
+		},
+		"present": present,
+	}
+}
@@ -0,0 +1,318 @@
+// Package msgtemplate renders guest messages from text/template templates.
+//
+// Templates are executed with Data, which a Loader builds from a
+// reservation, its property, its custom fields and its lock code:
+//
+//	tmpl, err := msgtemplate.Parse("check-in", `Hi {{firstName .Reservation.GuestName}},
+//	welcome to {{.Property.Title}} on {{date .Reservation.CheckInDate}} for
+//	{{plural (nights .Reservation) "night" "nights"}}. The door code is {{.LockCode}}.`, nil)
+//
+//	data, err := msgtemplate.NewLoader(client, msgtemplate.LoaderOptions{LockCodes: locks}).Load(ctx, reservation)
+//	err = tmpl.Send(ctx, client, data)
+//
+// Besides the text/template builtins, templates can use these helpers,
+// formatted for the template's Locale:
+//
+//	date d              long date, e.g. "Monday, July 1, 2024"
+//	shortDate d         numeric date, e.g. "07/01/2024"
+//	weekday d           day of the week, e.g. "Monday"
+//	nights r            number of nights of a reservation
+//	plural n one many   count with a noun, e.g. "1 guest" or "3 guests"
+//	firstName s         first word of a name
+//	present v           whether v is set
+//
+// Before rendering, a template is checked for missing variables: fields that
+// do not exist, custom fields that are not set and values that are empty.
+// Custom fields can be used as {{.CustomFields.wifi}} or, for keys that are
+// not identifiers, {{index .CustomFields "door-code"}}. Variables used only
+// inside {{if}}, {{with}} or {{range}} are optional, so
+// {{with index .CustomFields "parking"}} guards a custom field that not every
+// stay has. Values the check cannot see, such as a custom field looked up
+// through a template variable, still fail Render if they are missing.
+package msgtemplate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/keithah/hostex-go"
+)
+
+// ErrMissingVariables is matched by MissingVariablesError
+var ErrMissingVariables = errors.New("msgtemplate: missing variables")
+
+// noValue is what text/template prints for a missing map value
+const noValue = "<no value>"
+
+// MissingVariablesError is returned when data lacks variables a template
+// requires. It matches ErrMissingVariables with errors.Is.
+type MissingVariablesError struct {
+	// Template is the name of the template
+	Template string
+
+	// Variables lists the missing variables, e.g. ".LockCode"
+	Variables []string
+}
+
+// Error implements the error interface
+func (e *MissingVariablesError) Error() string {
+	return fmt.Sprintf("msgtemplate: %s is missing %s", e.Template, strings.Join(e.Variables, ", "))
+}
+
+// Is reports whether target is ErrMissingVariables
+func (e *MissingVariablesError) Is(target error) bool {
+	return target == ErrMissingVariables
+}
+
+// Options configures a Template
+type Options struct {
+	// Locale formats dates and counts (optional, defaults to English)
+	Locale *Locale
+}
+
+// variable is a field chain used by a template, with a template that
+// reports whether it is set
+type variable struct {
+	name     string
+	required bool
+	check    *template.Template
+}
+
+// Template is a parsed message template. It is safe for concurrent use.
+type Template struct {
+	name      string
+	tmpl      *template.Template
+	variables []variable
+}
+
+// Parse parses a message template. opts may be nil.
+func Parse(name, text string, opts *Options) (*Template, error) {
+	locale := English
+	if opts != nil && opts.Locale != nil {
+		locale = *opts.Locale
+	}
+	funcs := locale.funcs()
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("msgtemplate: failed to parse %s: %w", name, err)
+	}
+
+	t := &Template{name: name, tmpl: tmpl}
+	seen := make(map[string]int)
+	collect(tmpl.Root, false, false, func(name string, required bool) {
+		if i, ok := seen[name]; ok {
+			t.variables[i].required = t.variables[i].required || required
+			return
+		}
+		check, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse("{{present (" + name + ")}}")
+		if err != nil {
+			return
+		}
+		seen[name] = len(t.variables)
+		t.variables = append(t.variables, variable{name: name, required: required, check: check})
+	})
+	return t, nil
+}
+
+// MustParse is like Parse but panics on error. It is intended for templates
+// compiled into the program.
+func MustParse(name, text string, opts *Options) *Template {
+	t, err := Parse(name, text, opts)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Name returns the name of the template
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Variables returns the variables the template uses, e.g. ".LockCode" or
+// `index .CustomFields "door-code"`, including optional ones
+func (t *Template) Variables() []string {
+	names := make([]string, len(t.variables))
+	for i, v := range t.variables {
+		names[i] = v.name
+	}
+	return names
+}
+
+// Validate checks that data has every variable the template requires. It
+// returns a *MissingVariablesError listing those that are missing.
+func (t *Template) Validate(data *Data) error {
+	var missing []string
+	for _, v := range t.variables {
+		if !v.required {
+			continue
+		}
+		var out strings.Builder
+		if err := v.check.Execute(&out, data); err != nil || out.String() != "true" {
+			missing = append(missing, v.name)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingVariablesError{Template: t.name, Variables: missing}
+	}
+	return nil
+}
+
+// Render validates data and executes the template with it. A message that
+// prints a missing map value as "<no value>" is rejected with
+// ErrMissingVariables.
+func (t *Template) Render(data *Data) (string, error) {
+	if err := t.Validate(data); err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	if err := t.tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("msgtemplate: failed to render %s: %w", t.name, err)
+	}
+	if strings.Contains(out.String(), noValue) {
+		return "", fmt.Errorf("%w: %s printed %q", ErrMissingVariables, t.name, noValue)
+	}
+	return out.String(), nil
+}
+
+// Send renders the template and sends it to the reservation's conversation.
+// Nothing is sent if a variable is missing.
+func (t *Template) Send(ctx context.Context, svc hostex.ConversationService, data *Data) error {
+	if data.Reservation.ConversationID == "" {
+		return fmt.Errorf("msgtemplate: reservation %s has no conversation", data.Reservation.ReservationCode)
+	}
+
+	message, err := t.Render(data)
+	if err != nil {
+		return err
+	}
+	if err := svc.SendMessage(ctx, data.Reservation.ConversationID, hostex.SendMessageData{Message: message}); err != nil {
+		return fmt.Errorf("msgtemplate: failed to send %s: %w", t.name, err)
+	}
+	return nil
+}
+
+// collect walks a template and reports the field chains relative to the
+// root. Chains inside control structures are optional; inside {{with}} and
+// {{range}} dot is rebound, so only $-rooted chains are reported there.
+func collect(node parse.Node, optional, rebound bool, report func(name string, required bool)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collect(child, optional, rebound, report)
+		}
+	case *parse.ActionNode:
+		collectPipe(n.Pipe, optional, rebound, report)
+	case *parse.TemplateNode:
+		collectPipe(n.Pipe, optional, rebound, report)
+	case *parse.IfNode:
+		collectPipe(n.Pipe, true, rebound, report)
+		collect(n.List, true, rebound, report)
+		collect(n.ElseList, true, rebound, report)
+	case *parse.WithNode:
+		collectPipe(n.Pipe, true, rebound, report)
+		collect(n.List, true, true, report)
+		collect(n.ElseList, true, rebound, report)
+	case *parse.RangeNode:
+		collectPipe(n.Pipe, true, rebound, report)
+		collect(n.List, true, true, report)
+		collect(n.ElseList, true, rebound, report)
+	}
+}
+
+// collectPipe reports the field chains used as arguments in a pipeline
+func collectPipe(pipe *parse.PipeNode, optional, rebound bool, report func(name string, required bool)) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		if name := indexName(cmd, rebound); name != "" {
+			report(name, !optional)
+		}
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				if !rebound {
+					report(a.String(), !optional)
+				}
+			case *parse.VariableNode:
+				if len(a.Ident) > 1 && a.Ident[0] == "$" {
+					report("."+strings.Join(a.Ident[1:], "."), !optional)
+				}
+			case *parse.PipeNode:
+				collectPipe(a, optional, rebound, report)
+			}
+		}
+	}
+}
+
+// indexName returns the expression of an index call on a field chain with
+// constant keys, e.g. `index .CustomFields "door-code"`, or "" for any other
+// command
+func indexName(cmd *parse.CommandNode, rebound bool) string {
+	if len(cmd.Args) < 3 {
+		return ""
+	}
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || ident.Ident != "index" {
+		return ""
+	}
+
+	var chain string
+	switch a := cmd.Args[1].(type) {
+	case *parse.FieldNode:
+		if rebound {
+			return ""
+		}
+		chain = a.String()
+	case *parse.VariableNode:
+		if len(a.Ident) < 2 || a.Ident[0] != "$" {
+			return ""
+		}
+		chain = "." + strings.Join(a.Ident[1:], ".")
+	default:
+		return ""
+	}
+
+	parts := []string{"index", chain}
+	for _, key := range cmd.Args[2:] {
+		switch key.(type) {
+		case *parse.StringNode, *parse.NumberNode:
+			parts = append(parts, key.String())
+		default:
+			return ""
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// present reports whether v is set: not nil, not empty and not a zero date
+func present(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case hostex.Date:
+		return !v.IsZero()
+	case time.Time:
+		return !v.IsZero()
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return !rv.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map:
+		return rv.Len() > 0
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package msgtemplate_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/hostextest"
+	"github.com/keithah/hostex-go/msgtemplate"
+)
+
+const checkIn = `Hi {{firstName .Reservation.GuestName}}, welcome to {{.Property.Title}}!
+You arrive on {{date .Reservation.CheckInDate}} for {{plural (nights .Reservation) "night" "nights"}} with {{plural .Reservation.NumberOfGuests "guest" "guests"}}.
+The door code is {{.LockCode}}.{{with index .CustomFields "parking"}} Parking: {{.}}.{{end}}`
+
+func newLoader(t *testing.T) (*hostextest.Server, *msgtemplate.Loader) {
+	t.Helper()
+	srv := hostextest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Seed(hostextest.DefaultFixtures())
+
+	loader := msgtemplate.NewLoader(srv.Client(), msgtemplate.LoaderOptions{
+		LockCodes: msgtemplate.LockCodes{"ST-ABC123": "4321"},
+	})
+	return srv, loader
+}
+
+func load(t *testing.T, srv *hostextest.Server, loader *msgtemplate.Loader) *msgtemplate.Data {
+	t.Helper()
+	r, _ := srv.Reservation("HMABC123")
+	data, err := loader.Load(context.Background(), r)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return data
+}
+
+func TestTemplate_Send(t *testing.T) {
+	srv, loader := newLoader(t)
+	ctx := context.Background()
+	client := srv.Client()
+	if err := client.UpdateCustomFields(ctx, "ST-ABC123", map[string]any{"parking": "space 2"}); err != nil {
+		t.Fatalf("UpdateCustomFields failed: %v", err)
+	}
+
+	tmpl := msgtemplate.MustParse("check-in", checkIn, nil)
+	if err := tmpl.Send(ctx, client, load(t, srv, loader)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	msgs := srv.Messages("conv-1")
+	want := "Hi Ada, welcome to Seaside Cottage!\n" +
+		"You arrive on Monday, July 1, 2024 for 4 nights with 2 guests.\n" +
+		"The door code is 4321. Parking: space 2."
+	if got := msgs[len(msgs)-1].Content; got != want {
+		t.Errorf("Unexpected message:\n got %q\nwant %q", got, want)
+	}
+
+	// Properties are cached
+	load(t, srv, loader)
+	srv.AssertCalled(t, "GET /properties", 1)
+}
+
+func TestTemplate_MissingVariables(t *testing.T) {
+	srv, loader := newLoader(t)
+	data := load(t, srv, loader)
+	data.LockCode = ""
+
+	tmpl := msgtemplate.MustParse("check-in", checkIn+` Wifi: {{.CustomFields.wifi}}.{{if .Reservation.GuestPhone}} {{.Reservation.GuestPhone}}{{end}}`, nil)
+	err := tmpl.Send(context.Background(), srv.Client(), data)
+	if !errors.Is(err, msgtemplate.ErrMissingVariables) {
+		t.Fatalf("Expected missing variables, got %v", err)
+	}
+	var missing *msgtemplate.MissingVariablesError
+	if !errors.As(err, &missing) || !slices.Equal(missing.Variables, []string{".LockCode", ".CustomFields.wifi"}) {
+		t.Errorf("Unexpected missing variables: %v", err)
+	}
+	srv.AssertNotCalled(t, "POST /conversations/{id}")
+
+	// Fields that do not exist are reported too
+	typo := msgtemplate.MustParse("typo", "Welcome to {{.Property.Name}}", nil)
+	if err := typo.Validate(data); !errors.Is(err, msgtemplate.ErrMissingVariables) {
+		t.Errorf("Expected unknown field to be reported, got %v", err)
+	}
+
+	want := []string{".Reservation.GuestName", ".Property.Title", ".Reservation.CheckInDate", ".Reservation", ".Reservation.NumberOfGuests", ".LockCode", `index .CustomFields "parking"`, ".CustomFields", ".CustomFields.wifi", ".Reservation.GuestPhone"}
+	if got := tmpl.Variables(); !slices.Equal(got, want) {
+		t.Errorf("Variables() = %v, want %v", got, want)
+	}
+}
+
+func TestTemplate_MissingIndexedCustomFields(t *testing.T) {
+	srv, loader := newLoader(t)
+	data := load(t, srv, loader)
+
+	// Custom fields looked up with index are required like any other variable
+	tmpl := msgtemplate.MustParse("check-in", `The door code is {{index .CustomFields "door-code"}}.`, nil)
+	var missing *msgtemplate.MissingVariablesError
+	if err := tmpl.Validate(data); !errors.As(err, &missing) || !slices.Equal(missing.Variables, []string{`index .CustomFields "door-code"`, ".CustomFields"}) {
+		t.Errorf("Expected the indexed custom field to be missing, got %v", err)
+	}
+
+	data.CustomFields = map[string]any{"door-code": "4321"}
+	if got, err := tmpl.Render(data); err != nil || got != "The door code is 4321." {
+		t.Errorf("Render() = %q, %v", got, err)
+	}
+
+	// Lookups the check cannot follow still fail instead of printing "<no value>"
+	indirect := msgtemplate.MustParse("indirect", `{{$fields := .CustomFields}}The gate code is {{index $fields "gate-code"}}.`, nil)
+	if got, err := indirect.Render(data); !errors.Is(err, msgtemplate.ErrMissingVariables) {
+		t.Errorf("Expected missing variables, got %q, %v", got, err)
+	}
+}
+
+func TestLocales(t *testing.T) {
+	data := &msgtemplate.Data{Reservation: hostex.Reservation{CheckInDate: hostex.MustParseDate("2024-07-01")}}
+
+	tests := []struct {
+		tag  string
+		text string
+		want string
+	}{
+		{"en", `{{date .Reservation.CheckInDate}} {{shortDate .Reservation.CheckInDate}}`, "Monday, July 1, 2024 07/01/2024"},
+		{"en-GB", `{{date .Reservation.CheckInDate}} {{shortDate .Reservation.CheckInDate}}`, "Monday 1 July 2024 01/07/2024"},
+		{"fr", `{{date .Reservation.CheckInDate}}, {{plural 0 "nuit" "nuits"}}`, "lundi 1 juillet 2024, 0 nuit"},
+		{"de", `{{date .Reservation.CheckInDate}} {{shortDate .Reservation.CheckInDate}}`, "Montag, 1. Juli 2024 01.07.2024"},
+		{"es-ES", `{{weekday .Reservation.CheckInDate}}, {{plural 0 "noche" "noches"}}`, "lunes, 0 noches"},
+	}
+	for _, tt := range tests {
+		locale, ok := msgtemplate.LookupLocale(tt.tag)
+		if !ok {
+			t.Fatalf("LookupLocale(%q) failed", tt.tag)
+		}
+		got, err := msgtemplate.MustParse(tt.tag, tt.text, &msgtemplate.Options{Locale: &locale}).Render(data)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.tag, got, err, tt.want)
+		}
+	}
+
+	if _, ok := msgtemplate.LookupLocale("xx"); ok {
+		t.Error("Expected unknown locale to be rejected")
+	}
+
+	en, _ := msgtemplate.LookupLocale("en")
+	for _, d := range []hostex.Date{{}, {Year: 2024, Month: 13, Day: 1}, {Year: 2024, Month: 2, Day: 30}} {
+		if got := en.FormatDate(d) + en.FormatShortDate(d) + en.Weekday(d); got != "" {
+			t.Errorf("Expected invalid date %+v to format as empty, got %q", d, got)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Package otelhostex provides OpenTelemetry tracing and metrics for the
+// Hostex API client.
+//
+// Add the middleware to the client configuration:
+//
+//	client, err := hostex.NewClient(hostex.Config{
+//		AccessToken: token,
+//		Middlewares: []hostex.Middleware{otelhostex.Middleware()},
+//	})
+//
+// Every API call then produces a client span named after the client method,
+// e.g. "hostex.ListReservations", covering all retry attempts.
+package otelhostex
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name used for tracers and meters
+const ScopeName = "github.com/keithah/hostex-go/otelhostex"
+
+// Attribute keys recorded on spans and metrics
+const (
+	OperationKey  = attribute.Key("hostex.operation")
+	EndpointKey   = attribute.Key("hostex.endpoint")
+	RequestIDKey  = attribute.Key("hostex.request_id")
+	ErrorCodeKey  = attribute.Key("hostex.error_code")
+	RetryCountKey = attribute.Key("hostex.retry_count")
+	MethodKey     = attribute.Key("http.request.method")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+	ErrorTypeKey  = attribute.Key("error.type")
+)
+
+// config holds the middleware options
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// Option configures the middleware
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider (defaults to the global provider)
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider (defaults to the global provider)
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// Middleware returns a hostex.Middleware that creates a span per API call and
+// records request durations and errors. Add it to Config.Middlewares so it
+// wraps the built-in retries and reports the retry count of each call.
+func Middleware(opts ...Option) hostex.Middleware {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tracer := cfg.tracerProvider.Tracer(ScopeName)
+	meter := cfg.meterProvider.Meter(ScopeName)
+
+	duration, err := meter.Float64Histogram("hostex.client.request.duration",
+		metric.WithDescription("Duration of Hostex API calls, including retries"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	errorCount, err := meter.Int64Counter("hostex.client.errors",
+		metric.WithDescription("Number of failed Hostex API calls"),
+		metric.WithUnit("{error}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(next hostex.RoundTrip) hostex.RoundTrip {
+		return func(ctx context.Context, req *hostex.Request) (*hostex.Response, error) {
+			ctx, span := tracer.Start(ctx, "hostex."+req.Operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					OperationKey.String(req.Operation),
+					EndpointKey.String(req.Endpoint),
+					MethodKey.String(req.Method),
+				),
+			)
+			defer span.End()
+
+			start := time.Now()
+			resp, err := next(ctx, req)
+			elapsed := time.Since(start)
+
+			span.SetAttributes(RetryCountKey.Int(max(req.Attempt-1, 0)))
+
+			metricAttrs := []attribute.KeyValue{
+				OperationKey.String(req.Operation),
+				MethodKey.String(req.Method),
+			}
+
+			if resp != nil {
+				span.SetAttributes(StatusCodeKey.Int(resp.StatusCode))
+				metricAttrs = append(metricAttrs, StatusCodeKey.Int(resp.StatusCode))
+				if resp.API != nil {
+					span.SetAttributes(RequestIDKey.String(resp.API.RequestID))
+				}
+			}
+
+			if err != nil {
+				errType := errorType(err)
+
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				span.SetAttributes(ErrorTypeKey.String(errType))
+
+				var apiErr *hostex.APIError
+				if errors.As(err, &apiErr) && apiErr.ErrorCode != 0 {
+					span.SetAttributes(ErrorCodeKey.Int(apiErr.ErrorCode))
+				}
+
+				metricAttrs = append(metricAttrs, ErrorTypeKey.String(errType))
+				if errorCount != nil {
+					errorCount.Add(ctx, 1, metric.WithAttributes(metricAttrs...))
+				}
+			}
+
+			if duration != nil {
+				duration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(metricAttrs...))
+			}
+
+			return resp, err
+		}
+	}
+}
+
+// errorType returns a low-cardinality classification of err
+func errorType(err error) string {
+	var apiErr *hostex.APIError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &apiErr) && apiErr.ErrorCode != 0:
+		return strconv.Itoa(apiErr.ErrorCode)
+	case errors.As(err, &apiErr):
+		return "invalid_response"
+	default:
+		return "transport"
+	}
+}
@@ -0,0 +1,176 @@
+package otelhostex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/otelhostex"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type testEnv struct {
+	client   *hostex.Client
+	spans    *tracetest.SpanRecorder
+	reader   *sdkmetric.ManualReader
+	requests *atomic.Int32
+}
+
+func newTestEnv(t *testing.T, handler func(call int32, w http.ResponseWriter)) *testEnv {
+	t.Helper()
+
+	env := &testEnv{
+		spans:    tracetest.NewSpanRecorder(),
+		reader:   sdkmetric.NewManualReader(),
+		requests: &atomic.Int32{},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(env.requests.Add(1), w)
+	}))
+	t.Cleanup(server.Close)
+
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(env.spans))
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(env.reader))
+
+	client, err := hostex.NewClient(hostex.Config{
+		AccessToken: "test-token",
+		BaseURL:     server.URL,
+		RetryPolicy: &hostex.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		Middlewares: []hostex.Middleware{
+			otelhostex.Middleware(
+				otelhostex.WithTracerProvider(tracerProvider),
+				otelhostex.WithMeterProvider(meterProvider),
+			),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	env.client = client
+
+	return env
+}
+
+func spanAttrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func collectMetrics(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Metrics {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Failed to collect metrics: %v", err)
+	}
+
+	metrics := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+	return metrics
+}
+
+func TestMiddleware_SuccessfulCallWithRetry(t *testing.T) {
+	env := newTestEnv(t, func(call int32, w http.ResponseWriter) {
+		if call == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"request_id":"req-7","error_code":200,"error_msg":"Done.","data":{"reservations":[],"total":0}}`))
+	})
+
+	if _, err := env.client.ListReservations(context.Background(), nil); err != nil {
+		t.Fatalf("ListReservations failed: %v", err)
+	}
+
+	spans := env.spans.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "hostex.ListReservations" {
+		t.Errorf("Expected span name 'hostex.ListReservations', got '%s'", span.Name())
+	}
+
+	attrs := spanAttrs(span)
+	if attrs[otelhostex.EndpointKey].AsString() != "/reservations" {
+		t.Errorf("Expected endpoint '/reservations', got '%s'", attrs[otelhostex.EndpointKey].AsString())
+	}
+	if attrs[otelhostex.RequestIDKey].AsString() != "req-7" {
+		t.Errorf("Expected request ID 'req-7', got '%s'", attrs[otelhostex.RequestIDKey].AsString())
+	}
+	if attrs[otelhostex.RetryCountKey].AsInt64() != 1 {
+		t.Errorf("Expected retry count 1, got %d", attrs[otelhostex.RetryCountKey].AsInt64())
+	}
+	if span.Status().Code == codes.Error {
+		t.Errorf("Expected span without error status, got %v", span.Status())
+	}
+
+	metrics := collectMetrics(t, env.reader)
+	histogram, ok := metrics["hostex.client.request.duration"].Data.(metricdata.Histogram[float64])
+	if !ok || len(histogram.DataPoints) != 1 || histogram.DataPoints[0].Count != 1 {
+		t.Errorf("Expected one duration measurement, got %+v", metrics["hostex.client.request.duration"])
+	}
+	if _, ok := metrics["hostex.client.errors"]; ok {
+		t.Error("Expected no error measurements")
+	}
+}
+
+func TestMiddleware_FailedCall(t *testing.T) {
+	env := newTestEnv(t, func(call int32, w http.ResponseWriter) {
+		w.Write([]byte(`{"request_id":"req-9","error_code":404,"error_msg":"reservation not found"}`))
+	})
+
+	if err := env.client.CancelReservation(context.Background(), "missing"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	spans := env.spans.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "hostex.CancelReservation" {
+		t.Errorf("Expected span name 'hostex.CancelReservation', got '%s'", span.Name())
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("Expected error status, got %v", span.Status())
+	}
+
+	attrs := spanAttrs(span)
+	if attrs[otelhostex.ErrorCodeKey].AsInt64() != 404 {
+		t.Errorf("Expected error code 404, got %d", attrs[otelhostex.ErrorCodeKey].AsInt64())
+	}
+	if attrs[otelhostex.RetryCountKey].AsInt64() != 0 {
+		t.Errorf("Expected retry count 0, got %d", attrs[otelhostex.RetryCountKey].AsInt64())
+	}
+
+	metrics := collectMetrics(t, env.reader)
+	counter, ok := metrics["hostex.client.errors"].Data.(metricdata.Sum[int64])
+	if !ok || len(counter.DataPoints) != 1 || counter.DataPoints[0].Value != 1 {
+		t.Fatalf("Expected one error measurement, got %+v", metrics["hostex.client.errors"])
+	}
+
+	errType, _ := counter.DataPoints[0].Attributes.Value(otelhostex.ErrorTypeKey)
+	if errType.AsString() != "404" {
+		t.Errorf("Expected error type '404', got '%s'", errType.AsString())
+	}
+}
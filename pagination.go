@@ -0,0 +1,154 @@
+package hostex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/keithah/hostex-go/internal/paginate"
+)
+
+const (
+	// DefaultPageSize is the page size used by iterators when the params do not set Limit
+	DefaultPageSize = paginate.DefaultPageSize
+
+	// DefaultMaxItems is the default cap on the number of items returned by the All* collectors
+	DefaultMaxItems = 10000
+)
+
+// ErrMaxItemsExceeded is returned by the All* collectors when more items are
+// available than the configured cap
+var ErrMaxItemsExceeded = errors.New("hostex: maximum number of items exceeded")
+
+// Collect gathers the items of seq into a slice. If seq yields more than
+// maxItems items, the first maxItems are returned with ErrMaxItemsExceeded.
+// A maxItems of zero or less means no cap.
+func Collect[T any](seq iter.Seq2[T, error], maxItems int) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return items, err
+		}
+		if maxItems > 0 && len(items) >= maxItems {
+			return items, fmt.Errorf("%w: more than %d items", ErrMaxItemsExceeded, maxItems)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Reservations returns an iterator over all reservations matching params,
+// fetching pages of params.Limit reservations as needed
+func (c *Client) Reservations(ctx context.Context, params *ListReservationsParams) iter.Seq2[Reservation, error] {
+	var p ListReservationsParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]Reservation, int, error) {
+		p.Offset, p.Limit = offset, limit
+		resp, err := c.ListReservations(ctx, &p)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Reservations, resp.Total, nil
+	})
+}
+
+// AllReservations returns all reservations matching params, up to the client's max items cap
+func (c *Client) AllReservations(ctx context.Context, params *ListReservationsParams) ([]Reservation, error) {
+	return Collect(c.Reservations(ctx, params), c.maxItems)
+}
+
+// Properties returns an iterator over all properties matching params,
+// fetching pages of params.Limit properties as needed
+func (c *Client) Properties(ctx context.Context, params *ListPropertiesParams) iter.Seq2[Property, error] {
+	var p ListPropertiesParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]Property, int, error) {
+		p.Offset, p.Limit = offset, limit
+		resp, err := c.ListProperties(ctx, &p)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Properties, resp.Total, nil
+	})
+}
+
+// AllProperties returns all properties matching params, up to the client's max items cap
+func (c *Client) AllProperties(ctx context.Context, params *ListPropertiesParams) ([]Property, error) {
+	return Collect(c.Properties(ctx, params), c.maxItems)
+}
+
+// RoomTypes returns an iterator over all room types, fetching pages of
+// params.Limit room types as needed
+func (c *Client) RoomTypes(ctx context.Context, params *ListRoomTypesParams) iter.Seq2[RoomType, error] {
+	var p ListRoomTypesParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]RoomType, int, error) {
+		p.Offset, p.Limit = offset, limit
+		resp, err := c.ListRoomTypes(ctx, &p)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.RoomTypes, resp.Total, nil
+	})
+}
+
+// AllRoomTypes returns all room types, up to the client's max items cap
+func (c *Client) AllRoomTypes(ctx context.Context, params *ListRoomTypesParams) ([]RoomType, error) {
+	return Collect(c.RoomTypes(ctx, params), c.maxItems)
+}
+
+// Reviews returns an iterator over all reviews matching params, fetching
+// pages of params.Limit reviews as needed
+func (c *Client) Reviews(ctx context.Context, params *ListReviewsParams) iter.Seq2[Review, error] {
+	var p ListReviewsParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]Review, int, error) {
+		p.Offset, p.Limit = offset, limit
+		resp, err := c.ListReviews(ctx, &p)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Reviews, resp.Total, nil
+	})
+}
+
+// AllReviews returns all reviews matching params, up to the client's max items cap
+func (c *Client) AllReviews(ctx context.Context, params *ListReviewsParams) ([]Review, error) {
+	return Collect(c.Reviews(ctx, params), c.maxItems)
+}
+
+// Conversations returns an iterator over all conversations, fetching pages
+// of params.Limit conversations as needed
+func (c *Client) Conversations(ctx context.Context, params *ListConversationsParams) iter.Seq2[Conversation, error] {
+	var p ListConversationsParams
+	if params != nil {
+		p = *params
+	}
+
+	return paginate.Seq(ctx, p.Offset, p.Limit, func(ctx context.Context, offset, limit int) ([]Conversation, int, error) {
+		p.Offset, p.Limit = offset, limit
+		resp, err := c.ListConversations(ctx, &p)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Conversations, resp.Total, nil
+	})
+}
+
+// AllConversations returns all conversations, up to the client's max items cap
+func (c *Client) AllConversations(ctx context.Context, params *ListConversationsParams) ([]Conversation, error) {
+	return Collect(c.Conversations(ctx, params), c.maxItems)
+}
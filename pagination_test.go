@@ -0,0 +1,139 @@
+package hostex_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+)
+
+// pagedPropertiesHandler serves total properties honoring offset and limit
+func pagedPropertiesHandler(total int, pages *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		*pages = append(*pages, r.URL.Query().Get("offset")+"/"+r.URL.Query().Get("limit"))
+
+		properties := []hostex.Property{}
+		for i := offset; i < offset+limit && i < total; i++ {
+			properties = append(properties, hostex.Property{ID: i + 1, Title: "Property " + strconv.Itoa(i+1)})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"request_id": "req-1",
+			"error_code": 200,
+			"error_msg":  "Done.",
+			"data": hostex.PropertiesResponse{
+				Properties: properties,
+				Total:      total,
+			},
+		})
+	}
+}
+
+func TestIterator_PagesUntilTotal(t *testing.T) {
+	var pages []string
+	client := newTestClient(t, pagedPropertiesHandler(7, &pages))
+
+	var ids []int
+	for property, err := range client.Properties(context.Background(), &hostex.ListPropertiesParams{Limit: 3}) {
+		if err != nil {
+			t.Fatalf("Iteration failed: %v", err)
+		}
+		ids = append(ids, property.ID)
+	}
+
+	if len(ids) != 7 {
+		t.Fatalf("Expected 7 properties, got %d", len(ids))
+	}
+	for i, id := range ids {
+		if id != i+1 {
+			t.Errorf("Expected property %d at position %d, got %d", i+1, i, id)
+		}
+	}
+
+	expected := []string{"/3", "3/3", "6/3"}
+	if len(pages) != len(expected) {
+		t.Fatalf("Expected pages %v, got %v", expected, pages)
+	}
+}
+
+func TestIterator_RangesAgain(t *testing.T) {
+	var pages []string
+	client := newTestClient(t, pagedPropertiesHandler(5, &pages))
+	seq := client.Properties(context.Background(), &hostex.ListPropertiesParams{Offset: 1, Limit: 2})
+
+	for round := range 2 {
+		var ids []int
+		for property, err := range seq {
+			if err != nil {
+				t.Fatalf("Iteration failed: %v", err)
+			}
+			ids = append(ids, property.ID)
+		}
+		if len(ids) != 4 || ids[0] != 2 {
+			t.Errorf("Range %d: expected properties 2-5, got %v", round+1, ids)
+		}
+	}
+}
+
+func TestIterator_StopsEarly(t *testing.T) {
+	var pages []string
+	client := newTestClient(t, pagedPropertiesHandler(100, &pages))
+
+	count := 0
+	for _, err := range client.Properties(context.Background(), &hostex.ListPropertiesParams{Limit: 10}) {
+		if err != nil {
+			t.Fatalf("Iteration failed: %v", err)
+		}
+		count++
+		if count == 5 {
+			break
+		}
+	}
+
+	if len(pages) != 1 {
+		t.Errorf("Expected 1 page request, got %d", len(pages))
+	}
+}
+
+func TestIterator_YieldsError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"request_id":"req-1","error_code":401,"error_msg":"invalid token"}`))
+	})
+
+	for _, err := range client.Reservations(context.Background(), nil) {
+		if !errors.Is(err, hostex.ErrUnauthorized) {
+			t.Errorf("Expected unauthorized error, got %v", err)
+		}
+	}
+}
+
+func TestAllProperties_MaxItems(t *testing.T) {
+	var pages []string
+	client := newTestClientWithConfig(t, hostex.Config{
+		RetryPolicy: &hostex.NoRetry,
+		MaxItems:    5,
+	}, pagedPropertiesHandler(12, &pages))
+
+	properties, err := client.AllProperties(context.Background(), &hostex.ListPropertiesParams{Limit: 4})
+	if !errors.Is(err, hostex.ErrMaxItemsExceeded) {
+		t.Fatalf("Expected ErrMaxItemsExceeded, got %v", err)
+	}
+	if len(properties) != 5 {
+		t.Errorf("Expected 5 properties, got %d", len(properties))
+	}
+
+	pages = nil
+	properties, err = client.AllProperties(context.Background(), &hostex.ListPropertiesParams{Limit: 4, Offset: 8})
+	if err != nil {
+		t.Fatalf("AllProperties failed: %v", err)
+	}
+	if len(properties) != 4 || properties[0].ID != 9 {
+		t.Errorf("Expected properties 9-12, got %+v", properties)
+	}
+}
@@ -36,7 +36,12 @@ func (c *Client) ListProperties(ctx context.Context, params *ListPropertiesParam
 		}
 	}
 
-	resp, err := c.doRequest(ctx, "GET", "/properties", urlParams, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "ListProperties",
+		Method:    "GET",
+		Endpoint:  "/properties",
+		Params:    urlParams,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +79,12 @@ func (c *Client) ListRoomTypes(ctx context.Context, params *ListRoomTypesParams)
 		}
 	}
 
-	resp, err := c.doRequest(ctx, "GET", "/room_types", urlParams, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "ListRoomTypes",
+		Method:    "GET",
+		Endpoint:  "/room_types",
+		Params:    urlParams,
+	})
 	if err != nil {
 		return nil, err
 	}
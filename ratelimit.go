@@ -0,0 +1,88 @@
+package hostex
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limiter limits the rate of API requests. It is satisfied by *RateLimiter
+// and by *rate.Limiter from golang.org/x/time/rate.
+type Limiter interface {
+	// Wait blocks until a request may be sent or the context is done
+	Wait(ctx context.Context) error
+}
+
+// RateLimiter is a token bucket limiter that is safe for concurrent use.
+// A single RateLimiter can be shared by several clients using the same
+// access token so that together they stay within the account's quota.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rps requests per second on
+// average, with bursts of up to burst requests. A burst below 1 is treated as 1.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   rps,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or the context is done. A token
+// reserved by a call whose context ends before it is granted is returned
+// to the bucket.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		l.tokens++
+		l.mu.Unlock()
+		return fmt.Errorf("wait of %v would exceed context deadline: %w", wait, context.DeadlineExceeded)
+	}
+	l.mu.Unlock()
+
+	if err := sleep(ctx, wait); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// refill adds the tokens accumulated since the last call, up to the burst size
+func (l *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	if elapsed > 0 {
+		l.tokens += elapsed * l.rate
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+		l.last = now
+	}
+}
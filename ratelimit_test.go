@@ -0,0 +1,91 @@
+package hostex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+)
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(`{"request_id":"req-1","error_code":200,"error_msg":"Done.","data":{}}`))
+}
+
+func TestRateLimiter_Burst(t *testing.T) {
+	limiter := hostex.NewRateLimiter(10, 3)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected burst to pass immediately, took %v", elapsed)
+	}
+
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected request beyond burst to wait ~100ms, took %v", elapsed)
+	}
+}
+
+func TestRateLimiter_RespectsContext(t *testing.T) {
+	limiter := hostex.NewRateLimiter(1, 1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := limiter.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestRateLimiter_SharedAcrossClients(t *testing.T) {
+	limiter := hostex.NewRateLimiter(20, 1)
+	config := hostex.Config{RetryPolicy: &hostex.NoRetry, RateLimiter: limiter}
+
+	first := newTestClientWithConfig(t, config, okHandler)
+	second := newTestClientWithConfig(t, config, okHandler)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := first.ListWebhooks(ctx); err != nil {
+			t.Fatalf("ListWebhooks failed: %v", err)
+		}
+		if _, err := second.ListWebhooks(ctx); err != nil {
+			t.Fatalf("ListWebhooks failed: %v", err)
+		}
+	}
+
+	// 4 requests at 20/s with a burst of 1 need at least 150ms
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("Expected shared limiter to throttle both clients, took %v", elapsed)
+	}
+}
+
+func TestConfig_RateLimit(t *testing.T) {
+	client := newTestClientWithConfig(t, hostex.Config{RetryPolicy: &hostex.NoRetry, RateLimit: 1}, okHandler)
+
+	if _, err := client.ListWebhooks(context.Background()); err != nil {
+		t.Fatalf("ListWebhooks failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.ListWebhooks(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected rate limited request to fail with deadline exceeded, got %v", err)
+	}
+}
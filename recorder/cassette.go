@@ -0,0 +1,195 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/keithah/hostex-go"
+)
+
+// cassetteVersion is the format version written to new cassettes
+const cassetteVersion = 1
+
+// Cassette is a recorded sequence of HTTP exchanges
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded HTTP request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body is a recorded message body. JSON bodies are stored as JSON so that
+// cassettes are easy to read and edit; anything else is stored as a string.
+type Body []byte
+
+// MarshalJSON implements json.Marshaler
+func (b Body) MarshalJSON() ([]byte, error) {
+	if len(b) == 0 {
+		return []byte(`""`), nil
+	}
+	if json.Valid(b) && !bytes.HasPrefix(bytes.TrimSpace(b), []byte(`"`)) {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, b); err == nil {
+			return compact.Bytes(), nil
+		}
+	}
+	return json.Marshal(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *Body) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*b = Body(s)
+		return nil
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Load reads a cassette file
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path, creating parent directories as needed
+func (c *Cassette) Save(path string) error {
+	if c.Version == 0 {
+		c.Version = cassetteVersion
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// scrubbed replaces secrets and personal data in cassettes
+const scrubbed = "[REDACTED]"
+
+// DefaultScrubFields lists the JSON fields and query parameters whose string
+// values are scrubbed from cassettes by default. Every string field of a
+// "guest" object is scrubbed as well.
+var DefaultScrubFields = append([]string{"guest_name", "lock_code"}, hostex.DefaultRedactedFields...)
+
+// secretHeaders are removed from recorded requests and responses
+var secretHeaders = []string{"Hostex-Access-Token", "Authorization", "Cookie", "Set-Cookie"}
+
+// scrubber removes secrets and personal data from recorded exchanges
+type scrubber struct {
+	fields map[string]bool
+}
+
+func newScrubber(fields []string) *scrubber {
+	s := &scrubber{fields: make(map[string]bool, len(fields))}
+	for _, f := range fields {
+		s.fields[strings.ToLower(f)] = true
+	}
+	return s
+}
+
+// header copies h with secret headers replaced
+func (s *scrubber) header(h http.Header) http.Header {
+	clean := h.Clone()
+	for _, name := range secretHeaders {
+		if clean.Get(name) != "" {
+			clean.Set(name, scrubbed)
+		}
+	}
+	return clean
+}
+
+// url returns the path and query of u with sensitive parameters scrubbed
+func (s *scrubber) url(u *url.URL) string {
+	query := u.Query()
+	for key := range query {
+		if s.fields[strings.ToLower(key)] {
+			query.Set(key, scrubbed)
+		}
+	}
+
+	clean := url.URL{Path: u.Path, RawQuery: query.Encode()}
+	return clean.String()
+}
+
+// body scrubs sensitive fields from a JSON body. Other bodies are returned
+// unchanged. Numbers are preserved exactly.
+func (s *scrubber) body(b []byte) []byte {
+	if len(b) == 0 || !json.Valid(b) {
+		return b
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return b
+	}
+
+	clean, err := json.Marshal(s.value(v, false))
+	if err != nil {
+		return b
+	}
+	return clean
+}
+
+// value walks decoded JSON replacing sensitive string values. all scrubs
+// every string field, and is set inside guest objects.
+func (s *scrubber) value(v any, all bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if _, ok := value.(string); ok && (all || s.fields[strings.ToLower(key)]) {
+				v[key] = scrubbed
+				continue
+			}
+			v[key] = s.value(value, all || strings.EqualFold(key, "guest"))
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = s.value(value, all)
+		}
+	}
+	return v
+}
@@ -0,0 +1,317 @@
+// Package recorder provides an http.RoundTripper that records exchanges with
+// the Hostex API to cassette files and replays them, so tests can run
+// offline and deterministically against responses captured once from a real
+// account.
+//
+// Access tokens and guest personal data are scrubbed before anything is
+// written. Plug the recorder into the client through Config.HTTPClient:
+//
+//	rec, err := recorder.New("testdata/reservations.json", recorder.Options{
+//		Mode: recorder.ModeAuto,
+//	})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//
+//	client, _ := hostex.NewClient(hostex.Config{
+//		AccessToken: os.Getenv("HOSTEX_API_KEY"),
+//		HTTPClient:  rec.Client(),
+//	})
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// ErrNoMatch is returned in replay mode for requests that match no unused
+// recorded interaction
+var ErrNoMatch = errors.New("recorder: no recorded interaction matches request")
+
+// Mode selects whether the recorder talks to the real API
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and never touches the
+	// network. It is the default.
+	ModeReplay Mode = iota
+
+	// ModeRecord sends requests to the real API and records the exchanges,
+	// replacing the cassette on Stop
+	ModeRecord
+
+	// ModeAuto replays the cassette if it exists and records it otherwise
+	ModeAuto
+)
+
+// Match selects the parts of a request compared against recorded requests
+type Match int
+
+const (
+	MatchMethod Match = 1 << iota
+	MatchPath
+	MatchQuery
+	MatchBody
+
+	// DefaultMatch compares method, path, query and body
+	DefaultMatch = MatchMethod | MatchPath | MatchQuery | MatchBody
+)
+
+// Options configures a Recorder
+type Options struct {
+	// Mode selects recording or replay (optional, defaults to ModeReplay)
+	Mode Mode
+
+	// Transport sends requests to the real API when recording (optional,
+	// defaults to http.DefaultTransport)
+	Transport http.RoundTripper
+
+	// Match selects the request parts compared on replay (optional,
+	// defaults to DefaultMatch)
+	Match Match
+
+	// Matcher, when set, replaces Match with a custom comparison of the
+	// scrubbed incoming request against a recorded one
+	Matcher func(req *Request, recorded *Request) bool
+
+	// ScrubFields lists JSON fields and query parameters whose values are
+	// scrubbed (optional, defaults to DefaultScrubFields)
+	ScrubFields []string
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	match     Match
+	matcher   func(req *Request, recorded *Request) bool
+	scrub     *scrubber
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New creates a recorder for the cassette at path. In replay mode the
+// cassette must exist.
+func New(path string, opts Options) (*Recorder, error) {
+	mode := opts.Mode
+	if mode == ModeAuto {
+		mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			mode = ModeReplay
+		}
+	}
+
+	transport := opts.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	match := opts.Match
+	if match == 0 {
+		match = DefaultMatch
+	}
+
+	fields := opts.ScrubFields
+	if fields == nil {
+		fields = DefaultScrubFields
+	}
+
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: transport,
+		match:     match,
+		matcher:   opts.Matcher,
+		scrub:     newScrubber(fields),
+		cassette:  &Cassette{Version: cassetteVersion},
+	}
+
+	if mode == ModeReplay {
+		c, err := Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("recorder: failed to load cassette: %w", err)
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	}
+
+	return r, nil
+}
+
+// Mode returns the effective mode, resolving ModeAuto
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an HTTP client that uses the recorder as its transport
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("recorder: failed to read request body: %w", err)
+		}
+	}
+
+	recorded := Request{
+		Method: req.Method,
+		URL:    r.scrub.url(req.URL),
+		Header: r.scrub.header(req.Header),
+		Body:   r.scrub.body(body),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, &recorded)
+	}
+	return r.record(req, body, recorded)
+}
+
+// record sends the request to the real API and records the exchange
+func (r *Recorder) record(req *http.Request, body []byte, recorded Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("recorder: failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.scrub.header(resp.Header),
+			Body:       r.scrub.body(respBody),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay serves the first unused interaction that matches the request
+func (r *Recorder) replay(req *http.Request, recorded *Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.cassette.Interactions {
+		interaction := &r.cassette.Interactions[i]
+		if r.used[i] || !r.matches(recorded, &interaction.Request) {
+			continue
+		}
+		r.used[i] = true
+
+		resp := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+			StatusCode:    resp.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        resp.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(resp.Body)),
+			ContentLength: int64(len(resp.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s in %s", ErrNoMatch, recorded.Method, recorded.URL, r.path)
+}
+
+// matches compares a scrubbed incoming request with a recorded one
+func (r *Recorder) matches(req, recorded *Request) bool {
+	if r.matcher != nil {
+		return r.matcher(req, recorded)
+	}
+
+	if r.match&MatchMethod != 0 && req.Method != recorded.Method {
+		return false
+	}
+
+	reqURL, err1 := url.Parse(req.URL)
+	recURL, err2 := url.Parse(recorded.URL)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	if r.match&MatchPath != 0 && reqURL.Path != recURL.Path {
+		return false
+	}
+	if r.match&MatchQuery != 0 && !reflect.DeepEqual(reqURL.Query(), recURL.Query()) {
+		return false
+	}
+	if r.match&MatchBody != 0 && !equalBodies(req.Body, recorded.Body) {
+		return false
+	}
+	return true
+}
+
+// equalBodies compares JSON bodies semantically and other bodies byte for byte
+func equalBodies(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// Unused returns the recorded interactions that have not been replayed yet
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for i, used := range r.used {
+		if !used {
+			unused = append(unused, r.cassette.Interactions[i])
+		}
+	}
+	return unused
+}
+
+// Stop writes the cassette when recording. It does nothing in replay mode.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.cassette.Save(r.path); err != nil {
+		return fmt.Errorf("recorder: failed to save cassette: %w", err)
+	}
+	return nil
+}
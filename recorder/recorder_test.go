@@ -0,0 +1,155 @@
+package recorder_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/hostextest"
+	"github.com/keithah/hostex-go/recorder"
+)
+
+func newClient(t *testing.T, baseURL string, rec *recorder.Recorder) *hostex.Client {
+	t.Helper()
+
+	client, err := hostex.NewClient(hostex.Config{
+		AccessToken: hostextest.DefaultToken,
+		BaseURL:     baseURL,
+		HTTPClient:  rec.Client(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return client
+}
+
+// record captures a reservation listing and a message send from a fake server
+func record(t *testing.T, path string) string {
+	t.Helper()
+
+	srv := hostextest.NewServer()
+	defer srv.Close()
+	fixtures := hostextest.DefaultFixtures()
+	fixtures.Reservations[0].GuestPhone = "+1 555 0100"
+	fixtures.Reservations[0].GuestEmail = "ada@example.com"
+	srv.Seed(fixtures)
+
+	rec, err := recorder.New(path, recorder.Options{Mode: recorder.ModeAuto})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if rec.Mode() != recorder.ModeRecord {
+		t.Fatalf("Expected ModeAuto to record a missing cassette, got %v", rec.Mode())
+	}
+
+	client := newClient(t, srv.URL, rec)
+	ctx := context.Background()
+	if _, err := client.ListReservations(ctx, &hostex.ListReservationsParams{PropertyID: 1001}); err != nil {
+		t.Fatalf("ListReservations failed: %v", err)
+	}
+	if _, err := client.GetConversation(ctx, "conv-1"); err != nil {
+		t.Fatalf("GetConversation failed: %v", err)
+	}
+	if err := client.SendMessage(ctx, "conv-1", hostex.SendMessageData{Message: "See you soon"}); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	return srv.URL
+}
+
+func TestRecorder_RecordScrubsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "flow.json")
+	record(t, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected cassette to be written: %v", err)
+	}
+
+	for _, secret := range []string{hostextest.DefaultToken, "Ada Lovelace", "+1 555 0100", "ada@example.com"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be scrubbed from the cassette", secret)
+		}
+	}
+	if !strings.Contains(string(data), `"reservation_code": "HMABC123"`) {
+		t.Errorf("Expected JSON bodies to be stored readably, got:\n%s", data)
+	}
+
+	c, err := recorder.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(c.Interactions) != 3 {
+		t.Errorf("Expected 3 interactions, got %d", len(c.Interactions))
+	}
+}
+
+func TestRecorder_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flow.json")
+	baseURL := record(t, path) // the fake server is closed once recording is done
+
+	rec, err := recorder.New(path, recorder.Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	client := newClient(t, baseURL, rec)
+	ctx := context.Background()
+
+	resp, err := client.ListReservations(ctx, &hostex.ListReservationsParams{PropertyID: 1001})
+	if err != nil {
+		t.Fatalf("Replayed ListReservations failed: %v", err)
+	}
+	if len(resp.Reservations) != 1 || resp.Reservations[0].ReservationCode != "HMABC123" {
+		t.Errorf("Unexpected replayed reservations: %+v", resp.Reservations)
+	}
+	if resp.Reservations[0].GuestName != "[REDACTED]" {
+		t.Errorf("Expected scrubbed guest name, got %q", resp.Reservations[0].GuestName)
+	}
+
+	if len(rec.Unused()) != 2 {
+		t.Errorf("Expected 2 unused interactions, got %d", len(rec.Unused()))
+	}
+
+	// A different body does not match the recorded message
+	err = client.SendMessage(ctx, "conv-1", hostex.SendMessageData{Message: "Different text"})
+	if !errors.Is(err, recorder.ErrNoMatch) {
+		t.Errorf("Expected ErrNoMatch, got %v", err)
+	}
+
+	// Each interaction is replayed once
+	if _, err := client.ListReservations(ctx, &hostex.ListReservationsParams{PropertyID: 1001}); !errors.Is(err, recorder.ErrNoMatch) {
+		t.Errorf("Expected used interaction not to match again, got %v", err)
+	}
+}
+
+func TestRecorder_MatchOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flow.json")
+	baseURL := record(t, path)
+
+	rec, err := recorder.New(path, recorder.Options{Match: recorder.MatchMethod | recorder.MatchPath})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	client := newClient(t, baseURL, rec)
+
+	if err := client.SendMessage(context.Background(), "conv-1", hostex.SendMessageData{Message: "Different text"}); err != nil {
+		t.Errorf("Expected body to be ignored, got %v", err)
+	}
+	if _, err := client.ListReservations(context.Background(), &hostex.ListReservationsParams{PropertyID: 42}); err != nil {
+		t.Errorf("Expected query to be ignored, got %v", err)
+	}
+}
+
+func TestRecorder_ReplayMissingCassette(t *testing.T) {
+	_, err := recorder.New(filepath.Join(t.TempDir(), "missing.json"), recorder.Options{Mode: recorder.ModeReplay})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected missing cassette error, got %v", err)
+	}
+}
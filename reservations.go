@@ -1,7 +1,9 @@
 package hostex
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -17,11 +19,11 @@ type ReservationsResponse struct {
 type ListReservationsParams struct {
 	ReservationCode   string
 	PropertyID        int
-	Status            string
-	StartCheckInDate  string
-	EndCheckInDate    string
-	StartCheckOutDate string
-	EndCheckOutDate   string
+	Status            ReservationStatus
+	StartCheckInDate  Date
+	EndCheckInDate    Date
+	StartCheckOutDate Date
+	EndCheckOutDate   Date
 	OrderBy           string
 	Offset            int
 	Limit             int
@@ -32,6 +34,10 @@ func (c *Client) ListReservations(ctx context.Context, params *ListReservationsP
 	urlParams := url.Values{}
 
 	if params != nil {
+		if err := c.validate(params); err != nil {
+			return nil, err
+		}
+
 		if params.ReservationCode != "" {
 			urlParams.Set("reservation_code", params.ReservationCode)
 		}
@@ -39,19 +45,19 @@ func (c *Client) ListReservations(ctx context.Context, params *ListReservationsP
 			urlParams.Set("property_id", strconv.Itoa(params.PropertyID))
 		}
 		if params.Status != "" {
-			urlParams.Set("status", params.Status)
+			urlParams.Set("status", params.Status.String())
 		}
-		if params.StartCheckInDate != "" {
-			urlParams.Set("start_check_in_date", params.StartCheckInDate)
+		if !params.StartCheckInDate.IsZero() {
+			urlParams.Set("start_check_in_date", params.StartCheckInDate.String())
 		}
-		if params.EndCheckInDate != "" {
-			urlParams.Set("end_check_in_date", params.EndCheckInDate)
+		if !params.EndCheckInDate.IsZero() {
+			urlParams.Set("end_check_in_date", params.EndCheckInDate.String())
 		}
-		if params.StartCheckOutDate != "" {
-			urlParams.Set("start_check_out_date", params.StartCheckOutDate)
+		if !params.StartCheckOutDate.IsZero() {
+			urlParams.Set("start_check_out_date", params.StartCheckOutDate.String())
 		}
-		if params.EndCheckOutDate != "" {
-			urlParams.Set("end_check_out_date", params.EndCheckOutDate)
+		if !params.EndCheckOutDate.IsZero() {
+			urlParams.Set("end_check_out_date", params.EndCheckOutDate.String())
 		}
 		if params.OrderBy != "" {
 			urlParams.Set("order_by", params.OrderBy)
@@ -64,7 +70,12 @@ func (c *Client) ListReservations(ctx context.Context, params *ListReservationsP
 		}
 	}
 
-	resp, err := c.doRequest(ctx, "GET", "/reservations", urlParams, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "ListReservations",
+		Method:    "GET",
+		Endpoint:  "/reservations",
+		Params:    urlParams,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +95,16 @@ type CreateReservationResponse struct {
 
 // CreateReservation creates a new direct booking reservation
 func (c *Client) CreateReservation(ctx context.Context, data CreateReservationData) (*CreateReservationResponse, error) {
-	resp, err := c.doRequest(ctx, "POST", "/reservations", nil, data)
+	if err := c.validate(data); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "CreateReservation",
+		Method:    "POST",
+		Endpoint:  "/reservations",
+		Body:      data,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +119,11 @@ func (c *Client) CreateReservation(ctx context.Context, data CreateReservationDa
 
 // CancelReservation cancels a direct booking reservation
 func (c *Client) CancelReservation(ctx context.Context, reservationCode string) error {
-	_, err := c.doRequest(ctx, "DELETE", "/reservations/"+reservationCode, nil, nil)
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "CancelReservation",
+		Method:    "DELETE",
+		Endpoint:  "/reservations/" + reservationCode,
+	})
 	return err
 }
 
@@ -108,18 +132,37 @@ func (c *Client) UpdateLockCode(ctx context.Context, stayCode, lockCode string)
 	body := map[string]string{
 		"lock_code": lockCode,
 	}
-	_, err := c.doRequest(ctx, "PATCH", "/reservations/"+stayCode+"/check_in_details", nil, body)
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "UpdateLockCode",
+		Method:    "PATCH",
+		Endpoint:  "/reservations/" + stayCode + "/check_in_details",
+		Body:      body,
+	})
 	return err
 }
 
-// CustomFieldsResponse represents the response from getting custom fields
+// CustomFieldsResponse represents the response from getting custom fields.
+// Numeric values are decoded as json.Number, so large integer IDs keep their
+// precision.
 type CustomFieldsResponse struct {
 	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
+// UnmarshalJSON implements json.Unmarshaler, decoding numbers as json.Number
+func (r *CustomFieldsResponse) UnmarshalJSON(data []byte) error {
+	type alias CustomFieldsResponse
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode((*alias)(r))
+}
+
 // GetCustomFields retrieves custom fields for a stay
 func (c *Client) GetCustomFields(ctx context.Context, stayCode string) (*CustomFieldsResponse, error) {
-	resp, err := c.doRequest(ctx, "GET", "/reservations/"+stayCode+"/custom_fields", nil, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "GetCustomFields",
+		Method:    "GET",
+		Endpoint:  "/reservations/" + stayCode + "/custom_fields",
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -137,6 +180,11 @@ func (c *Client) UpdateCustomFields(ctx context.Context, stayCode string, custom
 	body := map[string]interface{}{
 		"custom_fields": customFields,
 	}
-	_, err := c.doRequest(ctx, "PATCH", "/reservations/"+stayCode+"/custom_fields", nil, body)
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "UpdateCustomFields",
+		Method:    "PATCH",
+		Endpoint:  "/reservations/" + stayCode + "/custom_fields",
+		Body:      body,
+	})
 	return err
 }
@@ -0,0 +1,30 @@
+package hostex_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestGetCustomFields_KeepsLargeIntegers(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"request_id":"req-1","error_code":200,"data":{"custom_fields":{"lock_id":9007199254740993,"floor":2.5,"parking":"B2"}}}`))
+	})
+
+	resp, err := client.GetCustomFields(context.Background(), "ST-1")
+	if err != nil {
+		t.Fatalf("GetCustomFields failed: %v", err)
+	}
+
+	if got := resp.CustomFields["lock_id"]; got != json.Number("9007199254740993") {
+		t.Errorf("Expected lock_id to keep its precision, got %#v", got)
+	}
+	if got := resp.CustomFields["floor"]; got != json.Number("2.5") {
+		t.Errorf("Expected floor as json.Number, got %#v", got)
+	}
+	if got := resp.CustomFields["parking"]; got != "B2" {
+		t.Errorf("Expected parking to stay a string, got %#v", got)
+	}
+}
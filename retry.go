@@ -0,0 +1,174 @@
+package hostex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values of 1 or less disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. Each further retry doubles it.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between attempts, including delays requested via Retry-After
+	MaxDelay time.Duration
+
+	// Jitter is the fraction (0 to 1) of each delay that is randomized to
+	// avoid many clients retrying in lockstep
+	Jitter float64
+
+	// Retryable reports whether a failed attempt should be retried
+	// (optional, defaults to DefaultRetryable)
+	Retryable func(err error) bool
+
+	// RetryNonIdempotent enables retries for calls that are not safe to
+	// repeat, such as CreateReservation and SendMessage
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy is used when Config.RetryPolicy is nil
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+	Jitter:      0.2,
+}
+
+// NoRetry is a RetryPolicy that disables retries
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// DefaultRetryable retries transient transport errors, rate limiting and
+// server errors. Transport errors are retried only for timeouts, refused or
+// reset connections and connections closed mid-response; other failures such
+// as DNS errors, TLS errors or a replay mismatch in a test transport are not.
+// Context cancellation, client errors and request encoding errors are never
+// retried.
+func DefaultRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return errors.Is(apiErr, ErrRateLimited) || apiErr.StatusCode >= 500 || apiErr.ErrorCode >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// idempotentPOSTs lists POST endpoints that overwrite state and are therefore
+// safe to repeat
+var idempotentPOSTs = map[string]bool{
+	"/availabilities":        true,
+	"/listings/calendar":     true,
+	"/listings/prices":       true,
+	"/listings/inventories":  true,
+	"/listings/restrictions": true,
+}
+
+// isIdempotent reports whether a request can be safely repeated
+func isIdempotent(method, endpoint string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	case http.MethodPost:
+		return idempotentPOSTs[endpoint]
+	}
+	return false
+}
+
+// shouldRetry reports whether another attempt is allowed after a failure
+func (p *RetryPolicy) shouldRetry(attempt int, idempotent bool, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if !idempotent && !p.RetryNonIdempotent {
+		return false
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	return retryable(err)
+}
+
+// delay returns how long to wait before the given retry (1 for the first retry)
+func (p *RetryPolicy) delay(retry int, err error) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > d {
+		d = apiErr.RetryAfter
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	return d
+}
+
+// sleep waits for d or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,222 @@
+package hostex_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+)
+
+var fastRetry = hostex.RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    10 * time.Millisecond,
+}
+
+func TestRetry_TransientServerError(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+
+	client := newTestClientWithConfig(t, hostex.Config{RetryPolicy: &fastRetry}, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"request_id":"req-1","error_code":200,"error_msg":"Done."}`))
+	})
+
+	err := client.UpdateListingPrices(context.Background(), hostex.UpdateListingPricesData{
+		ChannelType: "airbnb",
+		ListingID:   "123",
+		Prices:      []hostex.Price{{Date: hostex.MustParseDate("2024-07-01"), Price: hostex.MustParseMoney("120", "USD")}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateListingPrices failed: %v", err)
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+
+	for i, body := range bodies {
+		if body == "" || body != bodies[0] {
+			t.Errorf("Attempt %d sent body %q, expected %q", i+1, body, bodies[0])
+		}
+	}
+}
+
+func TestRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newTestClientWithConfig(t, hostex.Config{RetryPolicy: &fastRetry}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := client.ListProperties(context.Background(), nil)
+
+	var apiErr *hostex.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Expected 502 APIError, got %v", err)
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetry_NonIdempotentNotRetried(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newTestClientWithConfig(t, hostex.Config{RetryPolicy: &fastRetry}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.CreateReservation(context.Background(), validReservation())
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestRetry_NonIdempotentOptIn(t *testing.T) {
+	var calls atomic.Int32
+
+	policy := fastRetry
+	policy.RetryNonIdempotent = true
+
+	client := newTestClientWithConfig(t, hostex.Config{RetryPolicy: &policy}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	err := client.SendMessage(context.Background(), "conv-1", hostex.SendMessageData{Message: "hi"})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetry_ClientErrorNotRetried(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newTestClientWithConfig(t, hostex.Config{RetryPolicy: &fastRetry}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"request_id":"req-1","error_code":400,"error_msg":"bad request"}`))
+	})
+
+	_, err := client.ListReservations(context.Background(), nil)
+	if !errors.Is(err, hostex.ErrValidation) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var first time.Time
+	var elapsed time.Duration
+
+	policy := fastRetry
+	policy.MaxDelay = 5 * time.Second
+
+	client := newTestClientWithConfig(t, hostex.Config{RetryPolicy: &policy}, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.Write([]byte(`{"request_id":"req-1","error_code":429,"error_msg":"too many requests"}`))
+			return
+		}
+		elapsed = time.Since(first)
+		w.Write([]byte(`{"request_id":"req-2","error_code":200,"error_msg":"Done."}`))
+	})
+
+	if _, err := client.ListWebhooks(context.Background()); err != nil {
+		t.Fatalf("ListWebhooks failed: %v", err)
+	}
+
+	if elapsed < time.Second {
+		t.Errorf("Expected retry to wait at least 1s, waited %v", elapsed)
+	}
+}
+
+func TestRetry_StopsOnContextCancellation(t *testing.T) {
+	var calls atomic.Int32
+
+	policy := fastRetry
+	policy.MaxAttempts = 10
+	policy.BaseDelay = time.Second
+	policy.MaxDelay = time.Second
+
+	client := newTestClientWithConfig(t, hostex.Config{RetryPolicy: &policy}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.ListProperties(ctx, nil); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected retries to stop on context cancellation, took %v", time.Since(start))
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestDefaultRetryable(t *testing.T) {
+	transport := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://api.hostex.io/v3/properties", Err: err}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"timeout", transport(os.ErrDeadlineExceeded), true},
+		{"connection reset", transport(&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"connection refused", transport(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), true},
+		{"unexpected EOF", transport(io.ErrUnexpectedEOF), true},
+		{"EOF", transport(io.EOF), true},
+		{"server error", &hostex.APIError{StatusCode: 503}, true},
+		{"DNS error", transport(&net.DNSError{Err: "no such host", Name: "api.hostex.io", IsNotFound: true}), false},
+		{"other transport error", transport(errors.New("recorder: no matching interaction")), false},
+		{"client error", &hostex.APIError{StatusCode: 400}, false},
+		{"cancelled", transport(context.Canceled), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostex.DefaultRetryable(tt.err); got != tt.want {
+				t.Errorf("DefaultRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
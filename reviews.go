@@ -17,9 +17,9 @@ type ReviewsResponse struct {
 type ListReviewsParams struct {
 	ReservationCode   string
 	PropertyID        int
-	ReviewStatus      string
-	StartCheckOutDate string
-	EndCheckOutDate   string
+	ReviewStatus      ReviewStatus
+	StartCheckOutDate Date
+	EndCheckOutDate   Date
 	Offset            int
 	Limit             int
 }
@@ -29,6 +29,10 @@ func (c *Client) ListReviews(ctx context.Context, params *ListReviewsParams) (*R
 	urlParams := url.Values{}
 
 	if params != nil {
+		if err := c.validate(params); err != nil {
+			return nil, err
+		}
+
 		if params.ReservationCode != "" {
 			urlParams.Set("reservation_code", params.ReservationCode)
 		}
@@ -36,13 +40,13 @@ func (c *Client) ListReviews(ctx context.Context, params *ListReviewsParams) (*R
 			urlParams.Set("property_id", strconv.Itoa(params.PropertyID))
 		}
 		if params.ReviewStatus != "" {
-			urlParams.Set("review_status", params.ReviewStatus)
+			urlParams.Set("review_status", params.ReviewStatus.String())
 		}
-		if params.StartCheckOutDate != "" {
-			urlParams.Set("start_check_out_date", params.StartCheckOutDate)
+		if !params.StartCheckOutDate.IsZero() {
+			urlParams.Set("start_check_out_date", params.StartCheckOutDate.String())
 		}
-		if params.EndCheckOutDate != "" {
-			urlParams.Set("end_check_out_date", params.EndCheckOutDate)
+		if !params.EndCheckOutDate.IsZero() {
+			urlParams.Set("end_check_out_date", params.EndCheckOutDate.String())
 		}
 		if params.Offset > 0 {
 			urlParams.Set("offset", strconv.Itoa(params.Offset))
@@ -52,7 +56,12 @@ func (c *Client) ListReviews(ctx context.Context, params *ListReviewsParams) (*R
 		}
 	}
 
-	resp, err := c.doRequest(ctx, "GET", "/reviews", urlParams, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "ListReviews",
+		Method:    "GET",
+		Endpoint:  "/reviews",
+		Params:    urlParams,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -67,6 +76,15 @@ func (c *Client) ListReviews(ctx context.Context, params *ListReviewsParams) (*R
 
 // CreateReview creates a review or reply for a reservation
 func (c *Client) CreateReview(ctx context.Context, reservationCode string, data CreateReviewData) error {
-	_, err := c.doRequest(ctx, "POST", "/reviews/"+reservationCode, nil, data)
+	if err := c.validate(data); err != nil {
+		return err
+	}
+
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "CreateReview",
+		Method:    "POST",
+		Endpoint:  "/reviews/" + reservationCode,
+		Body:      data,
+	})
 	return err
 }
@@ -0,0 +1,133 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/msgtemplate"
+)
+
+// DefaultMaxLate is the default Rule.MaxLate
+const DefaultMaxLate = 12 * time.Hour
+
+// Anchor is the stay date a rule's send time is relative to
+type Anchor string
+
+// Anchors
+const (
+	CheckIn  Anchor = "check_in"
+	CheckOut Anchor = "check_out"
+)
+
+// Rule schedules a message for every accepted reservation
+type Rule struct {
+	// Name identifies the rule's jobs and must be unique
+	Name string
+
+	// Anchor is the stay date the message is sent relative to
+	Anchor Anchor
+
+	// Days is added to the anchor date; -2 is two days before
+	Days int
+
+	// At is the time of day in the property's time zone, e.g. 10 * time.Hour
+	At time.Duration
+
+	// Template renders the message
+	Template *msgtemplate.Template
+
+	// MaxLate skips a job if its send time is more than MaxLate in the past
+	// when the reservation is first seen, e.g. for last-minute bookings, or
+	// when it is finally delivered, e.g. after an outage (optional, defaults
+	// to DefaultMaxLate)
+	MaxLate time.Duration
+}
+
+// sendAt returns the time the rule sends its message for a reservation
+func (r *Rule) sendAt(res hostex.Reservation, loc *time.Location) time.Time {
+	date := res.CheckInDate
+	if r.Anchor == CheckOut {
+		date = res.CheckOutDate
+	}
+	date = date.AddDays(r.Days)
+
+	at := r.At.Round(time.Second)
+	hour, minute, second := int(at/time.Hour), int(at%time.Hour/time.Minute), int(at%time.Minute/time.Second)
+	return time.Date(date.Year, date.Month, date.Day, hour, minute, second, 0, loc)
+}
+
+// JobStatus is the state of a job
+type JobStatus string
+
+// Job statuses
+const (
+	// JobPending is waiting for its send time or a retry
+	JobPending JobStatus = "pending"
+
+	// JobSent was delivered
+	JobSent JobStatus = "sent"
+
+	// JobCancelled was dropped because the reservation was cancelled or
+	// its rule was removed
+	JobCancelled JobStatus = "cancelled"
+
+	// JobSkipped was scheduled after its send time had passed by more
+	// than the rule's MaxLate, or was still pending more than MaxLate after
+	// it was due
+	JobSkipped JobStatus = "skipped"
+
+	// JobFailed ran out of attempts
+	JobFailed JobStatus = "failed"
+)
+
+// Job is a message to send for one reservation
+type Job struct {
+	// ID is the rule name and reservation code, e.g. "check-in/HMABC123"
+	ID              string      `json:"id"`
+	Rule            string      `json:"rule"`
+	ReservationCode string      `json:"reservation_code"`
+	CheckOutDate    hostex.Date `json:"check_out_date"`
+	SendAt          time.Time   `json:"send_at"`
+	Status          JobStatus   `json:"status"`
+
+	// Attempts counts failed deliveries
+	Attempts int `json:"attempts,omitempty"`
+
+	// NextAttempt is when a failed delivery is retried
+	NextAttempt time.Time `json:"next_attempt,omitzero"`
+
+	// LastError is the error of the last failed delivery, or why the job
+	// was cancelled or skipped
+	LastError string `json:"last_error,omitempty"`
+
+	// DoneAt is when the job left the pending state
+	DoneAt time.Time `json:"done_at,omitzero"`
+}
+
+// due returns when the job should next be attempted
+func (j *Job) due() time.Time {
+	if !j.NextAttempt.IsZero() {
+		return j.NextAttempt
+	}
+	return j.SendAt
+}
+
+// finish moves the job out of the pending state
+func (j *Job) finish(status JobStatus, reason string, now time.Time) {
+	j.Status = status
+	j.DoneAt = now
+	j.NextAttempt = time.Time{}
+	if reason != "" {
+		j.LastError = reason
+	}
+}
+
+// jobID returns the ID of a rule's job for a reservation
+func jobID(rule, reservationCode string) string {
+	return rule + "/" + reservationCode
+}
+
+// state is the persisted job list of a Scheduler
+type state struct {
+	Jobs map[string]*Job `json:"jobs"`
+}
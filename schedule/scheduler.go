@@ -0,0 +1,502 @@
+// Package schedule sends messages at times derived from reservation dates,
+// such as check-in instructions two days before arrival:
+//
+//	s, err := schedule.New(client, schedule.Options{
+//		Rules: []schedule.Rule{
+//			{Name: "check-in", Anchor: schedule.CheckIn, Days: -2, At: 10 * time.Hour, Template: checkInTmpl},
+//			{Name: "checkout", Anchor: schedule.CheckOut, At: 8 * time.Hour, Template: checkoutTmpl},
+//		},
+//		Locations: map[int]*time.Location{12345: lisbon},
+//		StatePath: "/var/lib/myapp/schedule.json",
+//	})
+//	go s.Run(ctx)
+//
+// A Scheduler keeps one job per rule and accepted reservation. Jobs are
+// persisted to StatePath, rescheduled when the stay dates change and
+// cancelled when the reservation is cancelled. Failed deliveries are retried
+// with exponential backoff.
+package schedule
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/internal/statefile"
+	"github.com/keithah/hostex-go/msgtemplate"
+	"github.com/keithah/hostex-go/watch"
+)
+
+// Defaults for Options
+const (
+	DefaultSyncInterval = 15 * time.Minute
+	DefaultMaxAttempts  = 5
+	DefaultBaseDelay    = time.Minute
+	DefaultMaxDelay     = time.Hour
+)
+
+// Service is the part of the API a Scheduler uses
+type Service interface {
+	msgtemplate.Service
+	hostex.ConversationService
+}
+
+// Options configures a Scheduler
+type Options struct {
+	// Rules are the messages to schedule
+	Rules []Rule
+
+	// Locations are the time zones of properties, by property ID
+	Locations map[int]*time.Location
+
+	// Location is the time zone of properties missing from Locations
+	// (optional, defaults to UTC)
+	Location *time.Location
+
+	// Params filters the reservations Sync lists (optional).
+	// StartCheckOutDate defaults to yesterday in Location.
+	Params *hostex.ListReservationsParams
+
+	// LockCodes provides the lock codes for the templates (optional)
+	LockCodes msgtemplate.LockCodeSource
+
+	// StatePath is the file jobs are persisted to (optional; without it
+	// jobs are kept in memory only)
+	StatePath string
+
+	// SyncInterval is how often Run lists reservations (optional, defaults
+	// to DefaultSyncInterval)
+	SyncInterval time.Duration
+
+	// MaxAttempts is the number of deliveries before a job fails
+	// (optional, defaults to DefaultMaxAttempts)
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for each
+	// further retry (optional, defaults to DefaultBaseDelay)
+	BaseDelay time.Duration
+
+	// MaxDelay caps the retry delay (optional, defaults to DefaultMaxDelay)
+	MaxDelay time.Duration
+
+	// Logger receives a record for every failed sync and delivery in Run
+	// (optional)
+	Logger *slog.Logger
+
+	// Clock tells the time and waits (optional, defaults to the system clock)
+	Clock Clock
+}
+
+// Scheduler sends scheduled messages. It is safe for concurrent use.
+type Scheduler struct {
+	svc    Service
+	opts   Options
+	rules  map[string]*Rule
+	loader *msgtemplate.Loader
+
+	// delivering serializes Deliver; mu guards the jobs and is not held
+	// during requests to the API
+	delivering sync.Mutex
+
+	mu    sync.Mutex
+	state state
+}
+
+// New creates a Scheduler, loading jobs from opts.StatePath if it exists
+func New(svc Service, opts Options) (*Scheduler, error) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = DefaultSyncInterval
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = DefaultBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = DefaultMaxDelay
+	}
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+
+	rules := make(map[string]*Rule, len(opts.Rules))
+	for i := range opts.Rules {
+		rule := opts.Rules[i]
+		switch {
+		case rule.Name == "":
+			return nil, fmt.Errorf("schedule: rule %d has no name", i+1)
+		case rules[rule.Name] != nil:
+			return nil, fmt.Errorf("schedule: duplicate rule %s", rule.Name)
+		case rule.Anchor != CheckIn && rule.Anchor != CheckOut:
+			return nil, fmt.Errorf("schedule: rule %s has invalid anchor %q", rule.Name, rule.Anchor)
+		case rule.Template == nil:
+			return nil, fmt.Errorf("schedule: rule %s has no template", rule.Name)
+		}
+		if rule.MaxLate <= 0 {
+			rule.MaxLate = DefaultMaxLate
+		}
+		rules[rule.Name] = &rule
+	}
+
+	s := &Scheduler{
+		svc:    svc,
+		opts:   opts,
+		rules:  rules,
+		loader: msgtemplate.NewLoader(svc, msgtemplate.LoaderOptions{LockCodes: opts.LockCodes}),
+	}
+	if opts.StatePath != "" {
+		if err := statefile.Load(opts.StatePath, &s.state); err != nil {
+			return nil, fmt.Errorf("schedule: %w", err)
+		}
+	}
+	if s.state.Jobs == nil {
+		s.state.Jobs = make(map[string]*Job)
+	}
+	return s, nil
+}
+
+// Jobs returns a copy of every job, ordered by send time
+func (s *Scheduler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.state.Jobs))
+	for _, job := range s.state.Jobs {
+		jobs = append(jobs, *job)
+	}
+	slices.SortFunc(jobs, func(a, b Job) int {
+		return cmp.Or(a.SendAt.Compare(b.SendAt), cmp.Compare(a.ID, b.ID))
+	})
+	return jobs
+}
+
+// Run syncs every SyncInterval and sends each job when it is due, until ctx
+// is done. Failures are logged and retried. It returns ctx.Err().
+func (s *Scheduler) Run(ctx context.Context) error {
+	var nextSync time.Time
+	for {
+		now := s.opts.Clock.Now()
+		if !now.Before(nextSync) {
+			if err := s.Sync(ctx); err != nil {
+				s.log(ctx, "hostex schedule sync failed", err)
+			}
+			nextSync = now.Add(s.opts.SyncInterval)
+		}
+		if _, err := s.Deliver(ctx); err != nil {
+			s.log(ctx, "hostex scheduled message failed", err)
+		}
+
+		wake := nextSync
+		if due, ok := s.nextDue(); ok && due.Before(wake) {
+			wake = due
+		}
+		if now := s.opts.Clock.Now(); !wake.After(now) {
+			// Jobs still due could not be saved; back off instead of spinning
+			wake = now.Add(s.opts.BaseDelay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.opts.Clock.After(wake.Sub(s.opts.Clock.Now())):
+		}
+	}
+}
+
+// Sync lists reservations and schedules, reschedules or cancels their jobs
+func (s *Scheduler) Sync(ctx context.Context) error {
+	now := s.opts.Clock.Now()
+	var params hostex.ListReservationsParams
+	if s.opts.Params != nil {
+		params = *s.opts.Params
+	}
+	if params.StartCheckOutDate.IsZero() {
+		params.StartCheckOutDate = hostex.DateOf(now.In(s.opts.Location)).AddDays(-1)
+	}
+
+	reservations, err := hostex.Collect(s.svc.Reservations(ctx, &params), 0)
+	if err != nil {
+		return fmt.Errorf("schedule: failed to list reservations: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range reservations {
+		s.apply(r, now)
+	}
+
+	for id, job := range s.state.Jobs {
+		switch {
+		case job.Status == JobPending && s.rules[job.Rule] == nil:
+			job.finish(JobCancelled, "rule removed", now)
+		case job.Status != JobPending && job.CheckOutDate.Before(params.StartCheckOutDate):
+			// The reservation is no longer listed, so the job is not
+			// recreated
+			delete(s.state.Jobs, id)
+		}
+	}
+	return s.save()
+}
+
+// Update schedules, reschedules or cancels the jobs of one reservation
+func (s *Scheduler) Update(ctx context.Context, r hostex.Reservation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apply(r, s.opts.Clock.Now())
+	return s.save()
+}
+
+// OnReservationChange updates the jobs of a changed reservation. Use it as
+// watch.WatcherOptions.OnChange to react to changes between syncs. A removed
+// reservation is looked up again, as it may only have stopped matching the
+// watcher's filters; if it is gone its jobs are cancelled.
+func (s *Scheduler) OnReservationChange(ctx context.Context, change watch.ReservationChange) error {
+	if change.Type != watch.Removed {
+		return s.Update(ctx, change.Reservation)
+	}
+
+	r := change.Reservation
+	resp, err := s.svc.ListReservations(ctx, &hostex.ListReservationsParams{ReservationCode: r.ReservationCode})
+	if err != nil {
+		return fmt.Errorf("schedule: failed to look up %s: %w", r.ReservationCode, err)
+	}
+	i := slices.IndexFunc(resp.Reservations, func(found hostex.Reservation) bool {
+		return found.ReservationCode == r.ReservationCode
+	})
+	if i < 0 {
+		r.Status = hostex.ReservationStatusCancelled
+	} else {
+		r = resp.Reservations[i]
+	}
+	return s.Update(ctx, r)
+}
+
+// Deliver sends the jobs that are due, oldest first, and returns the jobs
+// that were sent. Each job is persisted as soon as it is sent. The jobs are
+// not locked while a message is sent, so Jobs, Sync and Update do not wait
+// for the API.
+func (s *Scheduler) Deliver(ctx context.Context) ([]Job, error) {
+	s.delivering.Lock()
+	defer s.delivering.Unlock()
+
+	s.mu.Lock()
+	now := s.opts.Clock.Now()
+	var due []*Job
+	for _, job := range s.state.Jobs {
+		if job.Status == JobPending && !job.due().After(now) {
+			due = append(due, job)
+		}
+	}
+	slices.SortFunc(due, func(a, b *Job) int {
+		return cmp.Or(a.due().Compare(b.due()), cmp.Compare(a.ID, b.ID))
+	})
+	ids := make([]string, len(due))
+	for i, job := range due {
+		ids[i] = job.ID
+	}
+	s.mu.Unlock()
+
+	var sent []Job
+	var errs []error
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		job, err := s.deliver(ctx, id, now)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if job.Status == JobSent {
+			sent = append(sent, job)
+		}
+
+		s.mu.Lock()
+		err = s.save()
+		s.mu.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// apply creates, reschedules or cancels the jobs of a reservation
+func (s *Scheduler) apply(r hostex.Reservation, now time.Time) {
+	if r.ReservationCode == "" {
+		return
+	}
+
+	for _, rule := range s.rules {
+		id := jobID(rule.Name, r.ReservationCode)
+		job := s.state.Jobs[id]
+
+		if r.Status != hostex.ReservationStatusAccepted {
+			if job != nil && job.Status == JobPending {
+				job.finish(JobCancelled, "reservation "+string(r.Status), now)
+			}
+			continue
+		}
+
+		sendAt := rule.sendAt(r, s.location(r.PropertyID))
+		switch {
+		case job == nil || job.Status == JobCancelled:
+			// New, or reinstated after a cancellation
+			job = &Job{ID: id, Rule: rule.Name, ReservationCode: r.ReservationCode}
+			s.state.Jobs[id] = job
+		case (job.Status == JobPending || job.Status == JobSkipped) && !job.SendAt.Equal(sendAt):
+			// The stay dates changed
+		default:
+			job.CheckOutDate = r.CheckOutDate
+			continue
+		}
+
+		*job = Job{ID: id, Rule: rule.Name, ReservationCode: r.ReservationCode, CheckOutDate: r.CheckOutDate, SendAt: sendAt, Status: JobPending}
+		if now.Sub(sendAt) > rule.MaxLate {
+			job.finish(JobSkipped, "", now)
+		}
+	}
+}
+
+// deliver sends a job, first checking it is not more than its rule's
+// MaxLate overdue, the reservation is still accepted and its dates have not
+// moved. It returns the job as it was left.
+func (s *Scheduler) deliver(ctx context.Context, id string, now time.Time) (Job, error) {
+	job, _ := s.update(id, func(job *Job) error {
+		rule := s.rules[job.Rule]
+		switch {
+		case rule == nil:
+			job.finish(JobCancelled, "rule removed", now)
+		case now.Sub(job.due()) > rule.MaxLate:
+			// Still pending long after it was due, e.g. after an outage
+			job.finish(JobSkipped, fmt.Sprintf("not sent within %v of %v", rule.MaxLate, job.due()), now)
+		}
+		return nil
+	})
+	if job.Status != JobPending {
+		return job, nil
+	}
+	rule := s.rules[job.Rule]
+
+	resp, err := s.svc.ListReservations(ctx, &hostex.ListReservationsParams{ReservationCode: job.ReservationCode})
+	if err != nil {
+		return s.update(id, func(job *Job) error { return s.retry(job, err, now) })
+	}
+	i := slices.IndexFunc(resp.Reservations, func(r hostex.Reservation) bool {
+		return r.ReservationCode == job.ReservationCode
+	})
+	if i < 0 {
+		return s.update(id, func(job *Job) error {
+			job.finish(JobCancelled, "reservation not found", now)
+			return nil
+		})
+	}
+	r := resp.Reservations[i]
+
+	// The reservation may have been cancelled or moved since the job was
+	// scheduled
+	s.mu.Lock()
+	s.apply(r, now)
+	s.mu.Unlock()
+	job, _ = s.update(id, func(job *Job) error { return nil })
+	if job.Status != JobPending || job.due().After(now) {
+		return job, nil
+	}
+
+	data, err := s.loader.Load(ctx, r)
+	if err == nil {
+		err = rule.Template.Send(ctx, s.svc, data)
+	}
+	return s.update(id, func(job *Job) error {
+		if err != nil {
+			return s.retry(job, err, now)
+		}
+		job.LastError = ""
+		job.finish(JobSent, "", now)
+		return nil
+	})
+}
+
+// update runs fn on a job with the jobs locked and returns a copy of the job
+// as fn left it
+func (s *Scheduler) update(id string, fn func(job *Job) error) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.state.Jobs[id]
+	if job == nil {
+		// Dropped by a concurrent Sync
+		return Job{ID: id}, nil
+	}
+	err := fn(job)
+	return *job, err
+}
+
+// retry records a failed delivery and schedules the next attempt, or fails
+// the job once it runs out of attempts
+func (s *Scheduler) retry(job *Job, err error, now time.Time) error {
+	job.Attempts++
+	job.LastError = err.Error()
+	if job.Attempts >= s.opts.MaxAttempts {
+		job.finish(JobFailed, "", now)
+	} else {
+		delay := s.opts.BaseDelay << (job.Attempts - 1)
+		if delay <= 0 || delay > s.opts.MaxDelay {
+			delay = s.opts.MaxDelay
+		}
+		job.NextAttempt = now.Add(delay)
+	}
+	return fmt.Errorf("schedule: failed to send %s: %w", job.ID, err)
+}
+
+// nextDue returns when the next pending job is due
+func (s *Scheduler) nextDue() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, job := range s.state.Jobs {
+		if job.Status == JobPending && (next.IsZero() || job.due().Before(next)) {
+			next = job.due()
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// location returns the time zone of a property
+func (s *Scheduler) location(propertyID int) *time.Location {
+	if loc := s.opts.Locations[propertyID]; loc != nil {
+		return loc
+	}
+	return s.opts.Location
+}
+
+// save persists the jobs if a state path is configured
+func (s *Scheduler) save() error {
+	if s.opts.StatePath == "" {
+		return nil
+	}
+	if err := statefile.Save(s.opts.StatePath, &s.state); err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+	return nil
+}
+
+// log records a failure if a logger is configured
+func (s *Scheduler) log(ctx context.Context, msg string, err error) {
+	if ctx.Err() == nil && s.opts.Logger != nil {
+		s.opts.Logger.ErrorContext(ctx, msg, slog.String("error", err.Error()))
+	}
+}
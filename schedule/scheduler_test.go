@@ -0,0 +1,283 @@
+package schedule_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/hostextest"
+	"github.com/keithah/hostex-go/msgtemplate"
+	"github.com/keithah/hostex-go/schedule"
+)
+
+// eastern is the time zone of the fixture property
+var eastern = time.FixedZone("EDT", -4*60*60)
+
+var rules = []schedule.Rule{
+	{
+		Name:     "check-in",
+		Anchor:   schedule.CheckIn,
+		Days:     -2,
+		At:       10 * time.Hour,
+		Template: msgtemplate.MustParse("check-in", "See you on {{date .Reservation.CheckInDate}}! The door code is {{.LockCode}}.", nil),
+	},
+	{
+		Name:     "checkout",
+		Anchor:   schedule.CheckOut,
+		At:       8 * time.Hour,
+		Template: msgtemplate.MustParse("checkout", "Checkout is at 11am today. Safe travels!", nil),
+	},
+}
+
+func newServer(t *testing.T) *hostextest.Server {
+	t.Helper()
+	srv := hostextest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Seed(hostextest.DefaultFixtures())
+	return srv
+}
+
+func newScheduler(t *testing.T, srv *hostextest.Server, clock schedule.Clock, opts schedule.Options) *schedule.Scheduler {
+	t.Helper()
+	opts.Rules = rules
+	opts.Locations = map[int]*time.Location{1001: eastern}
+	opts.Clock = clock
+	s, err := schedule.New(srv.Client(), opts)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return s
+}
+
+// lastMessage returns the content of the latest message in the fixture conversation
+func lastMessage(srv *hostextest.Server) string {
+	msgs := srv.Messages("conv-1")
+	return msgs[len(msgs)-1].Content
+}
+
+func statuses(jobs []schedule.Job) string {
+	var out []string
+	for _, j := range jobs {
+		out = append(out, j.ID+"="+string(j.Status))
+	}
+	return strings.Join(out, " ")
+}
+
+func TestScheduler_Run(t *testing.T) {
+	srv := newServer(t)
+	clock := schedule.NewFakeClock(time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC))
+	s := newScheduler(t, srv, clock, schedule.Options{
+		LockCodes: msgtemplate.LockCodes{"ST-ABC123": "4321"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Run returned %v", err)
+		}
+	}()
+	clock.BlockUntil(1)
+
+	jobs := s.Jobs()
+	if len(jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got %v", statuses(jobs))
+	}
+	if want := time.Date(2024, 6, 29, 10, 0, 0, 0, eastern); !jobs[0].SendAt.Equal(want) {
+		t.Errorf("Expected check-in message at %v, got %v", want, jobs[0].SendAt)
+	}
+	if want := time.Date(2024, 7, 5, 8, 0, 0, 0, eastern); !jobs[1].SendAt.Equal(want) {
+		t.Errorf("Expected checkout message at %v, got %v", want, jobs[1].SendAt)
+	}
+
+	// Nothing is sent a minute early
+	clock.Advance(jobs[0].SendAt.Sub(clock.Now()) - time.Minute)
+	clock.BlockUntil(1)
+	srv.AssertNotCalled(t, "POST /conversations/{id}")
+
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	if got := lastMessage(srv); got != "See you on Monday, July 1, 2024! The door code is 4321." {
+		t.Errorf("Unexpected check-in message %q", got)
+	}
+
+	// Cancelling the reservation cancels the checkout message at the next sync
+	srv.UpdateReservation("HMABC123", func(r *hostex.Reservation) { r.Status = hostex.ReservationStatusCancelled })
+	clock.Advance(schedule.DefaultSyncInterval)
+	clock.BlockUntil(1)
+
+	if got := statuses(s.Jobs()); got != "check-in/HMABC123=sent checkout/HMABC123=cancelled" {
+		t.Errorf("Unexpected jobs: %s", got)
+	}
+	srv.AssertCalled(t, "POST /conversations/{id}", 1)
+}
+
+func TestScheduler_Retry(t *testing.T) {
+	srv := newServer(t)
+	clock := schedule.NewFakeClock(time.Date(2024, 6, 29, 14, 0, 0, 0, time.UTC))
+	locks := msgtemplate.LockCodes{}
+	s := newScheduler(t, srv, clock, schedule.Options{LockCodes: locks, MaxAttempts: 3})
+	ctx := context.Background()
+
+	if err := s.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// Without a lock code the message is held back and retried
+	if _, err := s.Deliver(ctx); !errors.Is(err, msgtemplate.ErrMissingVariables) {
+		t.Fatalf("Expected missing lock code, got %v", err)
+	}
+	job := s.Jobs()[0]
+	if job.Status != schedule.JobPending || job.Attempts != 1 || !job.NextAttempt.Equal(clock.Now().Add(schedule.DefaultBaseDelay)) {
+		t.Fatalf("Expected a retry in a minute, got %+v", job)
+	}
+
+	locks["ST-ABC123"] = "4321"
+	if sent, err := s.Deliver(ctx); len(sent) != 0 || err != nil {
+		t.Fatalf("Expected no delivery before the retry, got %v, %v", sent, err)
+	}
+
+	clock.Advance(schedule.DefaultBaseDelay)
+	sent, err := s.Deliver(ctx)
+	if err != nil || len(sent) != 1 || sent[0].ID != "check-in/HMABC123" {
+		t.Fatalf("Expected the retry to send, got %v, %v", sent, err)
+	}
+
+	// Delivery failures also use up attempts
+	clock.Advance(6 * 24 * time.Hour)
+	srv.Fail("POST /conversations/{id}", hostextest.Failure{StatusCode: 400, Times: 3})
+	for range 3 {
+		s.Deliver(ctx)
+		clock.Advance(time.Hour)
+	}
+	if job := s.Jobs()[1]; job.Status != schedule.JobFailed || job.Attempts != 3 || job.LastError == "" {
+		t.Errorf("Expected the checkout message to fail, got %+v", job)
+	}
+}
+
+func TestScheduler_Lifecycle(t *testing.T) {
+	srv := newServer(t)
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	ctx := context.Background()
+
+	// A booking seen after its check-in message was due skips that message
+	clock := schedule.NewFakeClock(time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC))
+	s := newScheduler(t, srv, clock, schedule.Options{StatePath: path})
+	if err := s.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got := statuses(s.Jobs()); got != "check-in/HMABC123=skipped checkout/HMABC123=pending" {
+		t.Fatalf("Unexpected jobs: %s", got)
+	}
+
+	// Moved dates reschedule the jobs, and jobs survive a restart
+	srv.UpdateReservation("HMABC123", func(r *hostex.Reservation) {
+		r.CheckInDate = hostex.MustParseDate("2024-07-10")
+		r.CheckOutDate = hostex.MustParseDate("2024-07-12")
+	})
+	r, _ := srv.Reservation("HMABC123")
+	if err := s.Update(ctx, r); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	restarted := newScheduler(t, srv, clock, schedule.Options{StatePath: path})
+	jobs := restarted.Jobs()
+	if got := statuses(jobs); got != "check-in/HMABC123=pending checkout/HMABC123=pending" {
+		t.Fatalf("Unexpected jobs after restart: %s", got)
+	}
+	if want := time.Date(2024, 7, 12, 8, 0, 0, 0, eastern); !jobs[1].SendAt.Equal(want) {
+		t.Errorf("Expected checkout message at %v, got %v", want, jobs[1].SendAt)
+	}
+
+	// A cancellation between syncs is caught before sending
+	srv.UpdateReservation("HMABC123", func(r *hostex.Reservation) { r.Status = hostex.ReservationStatusCancelled })
+	clock.Advance(jobs[0].SendAt.Sub(clock.Now()))
+	if sent, err := restarted.Deliver(ctx); len(sent) != 0 || err != nil {
+		t.Fatalf("Expected nothing to be sent, got %v, %v", sent, err)
+	}
+	if got := statuses(restarted.Jobs()); got != "check-in/HMABC123=cancelled checkout/HMABC123=cancelled" {
+		t.Errorf("Unexpected jobs: %s", got)
+	}
+	srv.AssertNotCalled(t, "POST /conversations/{id}")
+}
+
+func TestScheduler_SkipsStaleJobs(t *testing.T) {
+	srv := newServer(t)
+	clock := schedule.NewFakeClock(time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC))
+	s := newScheduler(t, srv, clock, schedule.Options{
+		LockCodes: msgtemplate.LockCodes{"ST-ABC123": "4321"},
+	})
+	ctx := context.Background()
+	if err := s.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// The process was down from before the check-in message was due until
+	// after the guest arrived
+	clock.Advance(s.Jobs()[0].SendAt.Sub(clock.Now()) + schedule.DefaultMaxLate + time.Minute)
+	if sent, err := s.Deliver(ctx); len(sent) != 0 || err != nil {
+		t.Fatalf("Expected nothing to be sent, got %v, %v", sent, err)
+	}
+	jobs := s.Jobs()
+	if got := statuses(jobs); got != "check-in/HMABC123=skipped checkout/HMABC123=pending" {
+		t.Errorf("Unexpected jobs: %s", got)
+	}
+	if jobs[0].LastError == "" {
+		t.Error("Expected the skipped job to say why")
+	}
+	srv.AssertNotCalled(t, "POST /conversations/{id}")
+}
+
+// blockingSender stands in for the client and runs during every SendMessage
+type blockingSender struct {
+	*hostex.Client
+	during func()
+}
+
+func (b *blockingSender) SendMessage(ctx context.Context, conversationID string, data hostex.SendMessageData) error {
+	b.during()
+	return b.Client.SendMessage(ctx, conversationID, data)
+}
+
+func TestScheduler_DeliverDoesNotBlockJobs(t *testing.T) {
+	srv := newServer(t)
+	clock := schedule.NewFakeClock(time.Date(2024, 6, 29, 14, 0, 0, 0, time.UTC))
+	svc := &blockingSender{Client: srv.Client()}
+	s, err := schedule.New(svc, schedule.Options{
+		Rules:     rules,
+		Locations: map[int]*time.Location{1001: eastern},
+		LockCodes: msgtemplate.LockCodes{"ST-ABC123": "4321"},
+		Clock:     clock,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := s.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// Jobs can be read while a message is being sent
+	var during string
+	svc.during = func() {
+		done := make(chan string)
+		go func() { done <- statuses(s.Jobs()) }()
+		select {
+		case during = <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("Jobs blocked while a message was being sent")
+		}
+	}
+	if sent, err := s.Deliver(ctx); err != nil || len(sent) != 1 {
+		t.Fatalf("Expected the check-in message to be sent, got %v, %v", sent, err)
+	}
+	if during != "check-in/HMABC123=pending checkout/HMABC123=pending" {
+		t.Errorf("Unexpected jobs during the send: %s", during)
+	}
+}
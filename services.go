@@ -0,0 +1,100 @@
+package hostex
+
+import (
+	"context"
+	"io"
+	"iter"
+)
+
+// The interfaces below group the Client methods by resource so that code
+// depending on part of the API can accept a narrow interface and be tested
+// with a mock, such as the one in the hostexmock package.
+
+// PropertyService covers properties, room types and property availability
+type PropertyService interface {
+	ListProperties(ctx context.Context, params *ListPropertiesParams) (*PropertiesResponse, error)
+	Properties(ctx context.Context, params *ListPropertiesParams) iter.Seq2[Property, error]
+	AllProperties(ctx context.Context, params *ListPropertiesParams) ([]Property, error)
+	ListRoomTypes(ctx context.Context, params *ListRoomTypesParams) (*RoomTypesResponse, error)
+	RoomTypes(ctx context.Context, params *ListRoomTypesParams) iter.Seq2[RoomType, error]
+	AllRoomTypes(ctx context.Context, params *ListRoomTypesParams) ([]RoomType, error)
+	ListAvailabilities(ctx context.Context, params ListAvailabilitiesParams) (*AvailabilitiesResponse, error)
+	UpdateAvailabilities(ctx context.Context, data UpdateAvailabilitiesData) error
+}
+
+// ReservationService covers reservations and the custom options used to
+// create direct bookings
+type ReservationService interface {
+	ListReservations(ctx context.Context, params *ListReservationsParams) (*ReservationsResponse, error)
+	Reservations(ctx context.Context, params *ListReservationsParams) iter.Seq2[Reservation, error]
+	AllReservations(ctx context.Context, params *ListReservationsParams) ([]Reservation, error)
+	CreateReservation(ctx context.Context, data CreateReservationData) (*CreateReservationResponse, error)
+	CancelReservation(ctx context.Context, reservationCode string) error
+	UpdateLockCode(ctx context.Context, stayCode, lockCode string) error
+	GetCustomFields(ctx context.Context, stayCode string) (*CustomFieldsResponse, error)
+	UpdateCustomFields(ctx context.Context, stayCode string, customFields map[string]interface{}) error
+	ListCustomChannels(ctx context.Context) (*CustomChannelsResponse, error)
+	ListIncomeMethods(ctx context.Context) (*IncomeMethodsResponse, error)
+}
+
+// ConversationService covers guest conversations and messaging
+type ConversationService interface {
+	ListConversations(ctx context.Context, params *ListConversationsParams) (*ConversationsResponse, error)
+	Conversations(ctx context.Context, params *ListConversationsParams) iter.Seq2[Conversation, error]
+	AllConversations(ctx context.Context, params *ListConversationsParams) ([]Conversation, error)
+	GetConversation(ctx context.Context, conversationID string) (*ConversationDetails, error)
+	SendMessage(ctx context.Context, conversationID string, data SendMessageData) error
+}
+
+// ImageSender sends images to guest conversations. It is kept out of
+// ConversationService so that existing implementations of that interface
+// still satisfy it.
+type ImageSender interface {
+	SendImage(ctx context.Context, conversationID string, r io.Reader) error
+	SendTextAndImage(ctx context.Context, conversationID, text string, r io.Reader) error
+}
+
+// ListingService covers channel listing calendars, prices, inventories and restrictions
+type ListingService interface {
+	GetListingCalendar(ctx context.Context, data GetListingCalendarData) (*ListingCalendarResponse, error)
+	UpdateListingPrices(ctx context.Context, data UpdateListingPricesData) error
+	UpdateListingInventories(ctx context.Context, data UpdateListingInventoriesData) error
+	UpdateListingRestrictions(ctx context.Context, data UpdateListingRestrictionsData) error
+}
+
+// ReviewService covers guest and host reviews
+type ReviewService interface {
+	ListReviews(ctx context.Context, params *ListReviewsParams) (*ReviewsResponse, error)
+	Reviews(ctx context.Context, params *ListReviewsParams) iter.Seq2[Review, error]
+	AllReviews(ctx context.Context, params *ListReviewsParams) ([]Review, error)
+	CreateReview(ctx context.Context, reservationCode string, data CreateReviewData) error
+}
+
+// WebhookService covers webhook registration
+type WebhookService interface {
+	ListWebhooks(ctx context.Context) (*WebhooksResponse, error)
+	CreateWebhook(ctx context.Context, webhookURL string) (*CreateWebhookResponse, error)
+	DeleteWebhook(ctx context.Context, webhookID int) error
+	EnsureWebhooks(ctx context.Context, desiredURLs []string, opts *EnsureWebhooksOptions) (*EnsureWebhooksReport, error)
+}
+
+// API is the full Hostex API as implemented by Client
+type API interface {
+	PropertyService
+	ReservationService
+	ConversationService
+	ListingService
+	ReviewService
+	WebhookService
+}
+
+var (
+	_ PropertyService     = (*Client)(nil)
+	_ ReservationService  = (*Client)(nil)
+	_ ConversationService = (*Client)(nil)
+	_ ImageSender         = (*Client)(nil)
+	_ ListingService      = (*Client)(nil)
+	_ ReviewService       = (*Client)(nil)
+	_ WebhookService      = (*Client)(nil)
+	_ API                 = (*Client)(nil)
+)
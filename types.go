@@ -1,6 +1,10 @@
 package hostex
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // Property represents a Hostex property
 type Property struct {
@@ -14,8 +18,8 @@ type Property struct {
 
 // Channel represents a booking channel for a property
 type Channel struct {
-	ChannelType string `json:"channel_type"`
-	ListingID   string `json:"listing_id"`
+	ChannelType ChannelType `json:"channel_type"`
+	ListingID   string      `json:"listing_id"`
 }
 
 // RoomType represents a room type in Hostex
@@ -28,62 +32,172 @@ type RoomType struct {
 
 // Reservation represents a booking reservation
 type Reservation struct {
-	ReservationCode  string     `json:"reservation_code"`
-	StayCode         string     `json:"stay_code"`
-	ChannelID        string     `json:"channel_id,omitempty"`
-	PropertyID       int        `json:"property_id"`
-	ChannelType      string     `json:"channel_type"`
-	ListingID        string     `json:"listing_id,omitempty"`
-	CheckInDate      string     `json:"check_in_date"`
-	CheckOutDate     string     `json:"check_out_date"`
-	NumberOfGuests   int        `json:"number_of_guests,omitempty"`
-	NumberOfAdults   int        `json:"number_of_adults,omitempty"`
-	NumberOfChildren int        `json:"number_of_children,omitempty"`
-	NumberOfInfants  int        `json:"number_of_infants,omitempty"`
-	NumberOfPets     int        `json:"number_of_pets,omitempty"`
-	Status           string     `json:"status"`
-	GuestName        string     `json:"guest_name,omitempty"`
-	GuestPhone       string     `json:"guest_phone,omitempty"`
-	GuestEmail       string     `json:"guest_email,omitempty"`
-	CancelledAt      *time.Time `json:"cancelled_at,omitempty"`
-	BookedAt         *time.Time `json:"booked_at,omitempty"`
-	CreatedAt        *time.Time `json:"created_at,omitempty"`
-	Creator          string     `json:"creator,omitempty"`
-	ConversationID   string     `json:"conversation_id,omitempty"`
-	Tags             []string   `json:"tags,omitempty"`
-	CustomFields     any        `json:"custom_fields,omitempty"`
-	InReservationBox bool       `json:"in_reservation_box,omitempty"`
-}
-
-// CreateReservationData contains data for creating a new reservation
+	ReservationCode  string            `json:"reservation_code"`
+	StayCode         string            `json:"stay_code"`
+	ChannelID        string            `json:"channel_id,omitempty"`
+	PropertyID       int               `json:"property_id"`
+	ChannelType      ChannelType       `json:"channel_type"`
+	ListingID        string            `json:"listing_id,omitempty"`
+	CheckInDate      Date              `json:"check_in_date"`
+	CheckOutDate     Date              `json:"check_out_date"`
+	NumberOfGuests   int               `json:"number_of_guests,omitempty"`
+	NumberOfAdults   int               `json:"number_of_adults,omitempty"`
+	NumberOfChildren int               `json:"number_of_children,omitempty"`
+	NumberOfInfants  int               `json:"number_of_infants,omitempty"`
+	NumberOfPets     int               `json:"number_of_pets,omitempty"`
+	Status           ReservationStatus `json:"status"`
+	GuestName        string            `json:"guest_name,omitempty"`
+	GuestPhone       string            `json:"guest_phone,omitempty"`
+	GuestEmail       string            `json:"guest_email,omitempty"`
+	CancelledAt      *time.Time        `json:"cancelled_at,omitempty"`
+	BookedAt         *time.Time        `json:"booked_at,omitempty"`
+	CreatedAt        *time.Time        `json:"created_at,omitempty"`
+	Creator          string            `json:"creator,omitempty"`
+	ConversationID   string            `json:"conversation_id,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	CustomFields     any               `json:"custom_fields,omitempty"`
+	InReservationBox bool              `json:"in_reservation_box,omitempty"`
+}
+
+// CreateReservationData contains data for creating a new reservation. The
+// amounts must share one currency, which is sent as the currency field.
 type CreateReservationData struct {
-	PropertyID       string `json:"property_id"`
-	CustomChannelID  int    `json:"custom_channel_id"`
-	CheckInDate      string `json:"check_in_date"`
-	CheckOutDate     string `json:"check_out_date"`
-	GuestName        string `json:"guest_name"`
-	Currency         string `json:"currency"`
-	RateAmount       int    `json:"rate_amount"`
-	CommissionAmount int    `json:"commission_amount"`
-	ReceivedAmount   int    `json:"received_amount"`
-	IncomeMethodID   int    `json:"income_method_id"`
-	NumberOfGuests   int    `json:"number_of_guests,omitempty"`
-	Email            string `json:"email,omitempty"`
-	Mobile           string `json:"mobile,omitempty"`
-	Remarks          string `json:"remarks,omitempty"`
+	PropertyID      string `json:"property_id"`
+	CustomChannelID int    `json:"custom_channel_id"`
+	CheckInDate     Date   `json:"check_in_date"`
+	CheckOutDate    Date   `json:"check_out_date"`
+	GuestName       string `json:"guest_name"`
+
+	// RateAmount is the total charged for the stay, sent in major units,
+	// e.g. 840 for 840.00 USD
+	RateAmount Money `json:"rate_amount"`
+
+	// CommissionAmount is the channel's commission, sent in major units
+	CommissionAmount Money `json:"commission_amount"`
+
+	// ReceivedAmount is the amount received by the host, sent in major units
+	ReceivedAmount Money `json:"received_amount"`
+
+	IncomeMethodID int    `json:"income_method_id"`
+	NumberOfGuests int    `json:"number_of_guests,omitempty"`
+	Email          string `json:"email,omitempty"`
+	Mobile         string `json:"mobile,omitempty"`
+	Remarks        string `json:"remarks,omitempty"`
+}
+
+// createReservationAlias has the fields of CreateReservationData without its methods
+type createReservationAlias CreateReservationData
+
+// Currency returns the currency shared by the amounts, or an error if they
+// are in different currencies
+func (d CreateReservationData) Currency() (string, error) {
+	currency := ""
+	for _, amount := range []Money{d.RateAmount, d.CommissionAmount, d.ReceivedAmount} {
+		switch {
+		case amount.Currency == "" || amount.Currency == currency:
+		case currency == "":
+			currency = amount.Currency
+		default:
+			return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, currency, amount.Currency)
+		}
+	}
+	return currency, nil
+}
+
+// SetRate sets RateAmount. It fails if the currency differs from the other amounts.
+func (d *CreateReservationData) SetRate(m Money) error {
+	next := *d
+	next.RateAmount = m
+	return d.set(next)
+}
+
+// SetCommission sets CommissionAmount. It fails if the currency differs
+// from the other amounts.
+func (d *CreateReservationData) SetCommission(m Money) error {
+	next := *d
+	next.CommissionAmount = m
+	return d.set(next)
+}
+
+// SetReceived sets ReceivedAmount. It fails if the currency differs from
+// the other amounts.
+func (d *CreateReservationData) SetReceived(m Money) error {
+	next := *d
+	next.ReceivedAmount = m
+	return d.set(next)
+}
+
+// set replaces d with next if its amounts share a currency
+func (d *CreateReservationData) set(next CreateReservationData) error {
+	if _, err := next.Currency(); err != nil {
+		return err
+	}
+	*d = next
+	return nil
+}
+
+// MarshalJSON encodes the reservation with the amounts in major units and
+// their shared currency in the currency field
+func (d CreateReservationData) MarshalJSON() ([]byte, error) {
+	currency, err := d.Currency()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		createReservationAlias
+		Currency string `json:"currency"`
+	}{createReservationAlias(d), currency})
+}
+
+// UnmarshalJSON decodes a reservation, reading the amounts in major units
+// of the currency field
+func (d *CreateReservationData) UnmarshalJSON(data []byte) error {
+	// The amounts shadow the embedded fields, as they can only be decoded
+	// once the currency is known
+	var v struct {
+		createReservationAlias
+		Currency         string          `json:"currency"`
+		RateAmount       json.RawMessage `json:"rate_amount"`
+		CommissionAmount json.RawMessage `json:"commission_amount"`
+		ReceivedAmount   json.RawMessage `json:"received_amount"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*d = CreateReservationData(v.createReservationAlias)
+	amounts := []struct {
+		raw json.RawMessage
+		dst *Money
+	}{
+		{v.RateAmount, &d.RateAmount},
+		{v.CommissionAmount, &d.CommissionAmount},
+		{v.ReceivedAmount, &d.ReceivedAmount},
+	}
+	for _, a := range amounts {
+		*a.dst = NewMoney(0, v.Currency)
+		if len(a.raw) == 0 {
+			continue
+		}
+		if err := a.dst.UnmarshalJSON(a.raw); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Conversation represents a guest conversation
 type Conversation struct {
-	ID            string    `json:"id"`
-	ChannelType   string    `json:"channel_type"`
-	Guest         Guest     `json:"guest"`
-	PropertyID    int       `json:"property_id,omitempty"`
-	PropertyTitle string    `json:"property_title,omitempty"`
-	CheckInDate   string    `json:"check_in_date,omitempty"`
-	CheckOutDate  string    `json:"check_out_date,omitempty"`
-	LastMessageAt time.Time `json:"last_message_at,omitempty"`
-	UnreadCount   int       `json:"unread_count,omitempty"`
+	ID            string      `json:"id"`
+	ChannelType   ChannelType `json:"channel_type"`
+	Guest         Guest       `json:"guest"`
+	PropertyID    int         `json:"property_id,omitempty"`
+	PropertyTitle string      `json:"property_title,omitempty"`
+	CheckInDate   Date        `json:"check_in_date,omitzero"`
+	CheckOutDate  Date        `json:"check_out_date,omitzero"`
+	LastMessageAt time.Time   `json:"last_message_at,omitempty"`
+	UnreadCount   int         `json:"unread_count,omitempty"`
 }
 
 // Guest represents a guest
@@ -95,11 +209,11 @@ type Guest struct {
 
 // Message represents a conversation message
 type Message struct {
-	ID         string    `json:"id"`
-	SenderRole string    `json:"sender_role"` // "host" or "guest"
-	Content    string    `json:"content"`
-	CreatedAt  time.Time `json:"created_at"`
-	ImageURL   string    `json:"image_url,omitempty"`
+	ID         string     `json:"id"`
+	SenderRole SenderRole `json:"sender_role"`
+	Content    string     `json:"content"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ImageURL   string     `json:"image_url,omitempty"`
 }
 
 // SendMessageData contains data for sending a message
@@ -110,14 +224,14 @@ type SendMessageData struct {
 
 // Review represents a guest or host review
 type Review struct {
-	ReservationCode string      `json:"reservation_code"`
-	PropertyID      int         `json:"property_id"`
-	ChannelType     string      `json:"channel_type"`
-	CheckOutDate    string      `json:"check_out_date"`
-	ReviewStatus    string      `json:"review_status"`
-	HostReview      *ReviewData `json:"host_review,omitempty"`
-	GuestReview     *ReviewData `json:"guest_review,omitempty"`
-	HostReply       *ReplyData  `json:"host_reply,omitempty"`
+	ReservationCode string       `json:"reservation_code"`
+	PropertyID      int          `json:"property_id"`
+	ChannelType     ChannelType  `json:"channel_type"`
+	CheckOutDate    Date         `json:"check_out_date"`
+	ReviewStatus    ReviewStatus `json:"review_status"`
+	HostReview      *ReviewData  `json:"host_review,omitempty"`
+	GuestReview     *ReviewData  `json:"guest_review,omitempty"`
+	HostReply       *ReplyData   `json:"host_reply,omitempty"`
 }
 
 // ReviewData contains review details
@@ -162,70 +276,73 @@ type IncomeMethod struct {
 
 // Availability represents property availability
 type Availability struct {
-	Date      string `json:"date"`
-	Available bool   `json:"available"`
+	Date      Date `json:"date"`
+	Available bool `json:"available"`
 }
 
 // UpdateAvailabilitiesData contains data for updating availability
 type UpdateAvailabilitiesData struct {
-	PropertyIDs []int    `json:"property_ids"`
-	StartDate   string   `json:"start_date,omitempty"`
-	EndDate     string   `json:"end_date,omitempty"`
-	Dates       []string `json:"dates,omitempty"`
-	Available   bool     `json:"available"`
+	PropertyIDs []int  `json:"property_ids"`
+	StartDate   Date   `json:"start_date,omitzero"`
+	EndDate     Date   `json:"end_date,omitzero"`
+	Dates       []Date `json:"dates,omitempty"`
+	Available   bool   `json:"available"`
 }
 
 // ListingCalendarData contains data for getting listing calendar
 type GetListingCalendarData struct {
-	StartDate string    `json:"start_date"`
-	EndDate   string    `json:"end_date"`
+	StartDate Date      `json:"start_date"`
+	EndDate   Date      `json:"end_date"`
 	Listings  []Listing `json:"listings"`
 }
 
 // Listing represents a listing on a channel
 type Listing struct {
-	ChannelType string `json:"channel_type"`
-	ListingID   string `json:"listing_id"`
+	ChannelType ChannelType `json:"channel_type"`
+	ListingID   string      `json:"listing_id"`
 }
 
 // UpdateListingPricesData contains data for updating listing prices
 type UpdateListingPricesData struct {
-	ChannelType string  `json:"channel_type"`
-	ListingID   string  `json:"listing_id"`
-	Prices      []Price `json:"prices"`
+	ChannelType ChannelType `json:"channel_type"`
+	ListingID   string      `json:"listing_id"`
+	Prices      []Price     `json:"prices"`
 }
 
 // Price represents a price for a specific date
 type Price struct {
-	Date  string `json:"date"`
-	Price int    `json:"price"`
+	Date Date `json:"date"`
+
+	// Price is sent in major units of the listing's currency, e.g. 120 for
+	// 120.00 USD
+	Price Money `json:"price"`
 }
 
 // UpdateListingInventoriesData contains data for updating listing inventories
 type UpdateListingInventoriesData struct {
-	ChannelType string      `json:"channel_type"`
+	ChannelType ChannelType `json:"channel_type"`
 	ListingID   string      `json:"listing_id"`
 	Inventories []Inventory `json:"inventories"`
 }
 
 // Inventory represents inventory for a specific date
 type Inventory struct {
-	Date      string `json:"date"`
-	Inventory int    `json:"inventory"`
+	Date      Date `json:"date"`
+	Inventory int  `json:"inventory"`
 }
 
 // UpdateListingRestrictionsData contains data for updating listing restrictions
 type UpdateListingRestrictionsData struct {
-	ChannelType  string        `json:"channel_type"`
+	ChannelType  ChannelType   `json:"channel_type"`
 	ListingID    string        `json:"listing_id"`
 	Restrictions []Restriction `json:"restrictions"`
 }
 
 // Restriction represents restrictions for a specific date
 type Restriction struct {
-	Date              string `json:"date"`
-	MinStay           int    `json:"min_stay,omitempty"`
-	MaxStay           int    `json:"max_stay,omitempty"`
-	ClosedToArrival   bool   `json:"closed_to_arrival,omitempty"`
-	ClosedToDeparture bool   `json:"closed_to_departure,omitempty"`
+	Date              Date `json:"date"`
+	MinStay           int  `json:"min_stay,omitempty"`
+	MaxStay           int  `json:"max_stay,omitempty"`
+	ClosedToArrival   bool `json:"closed_to_arrival,omitempty"`
+	ClosedToDeparture bool `json:"closed_to_departure,omitempty"`
 }
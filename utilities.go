@@ -12,7 +12,11 @@ type CustomChannelsResponse struct {
 
 // ListCustomChannels retrieves custom channels from Custom Options Page
 func (c *Client) ListCustomChannels(ctx context.Context) (*CustomChannelsResponse, error) {
-	resp, err := c.doRequest(ctx, "GET", "/custom_channels", nil, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "ListCustomChannels",
+		Method:    "GET",
+		Endpoint:  "/custom_channels",
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -32,7 +36,11 @@ type IncomeMethodsResponse struct {
 
 // ListIncomeMethods retrieves income methods from Custom Options Page
 func (c *Client) ListIncomeMethods(ctx context.Context) (*IncomeMethodsResponse, error) {
-	resp, err := c.doRequest(ctx, "GET", "/income_methods", nil, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "ListIncomeMethods",
+		Method:    "GET",
+		Endpoint:  "/income_methods",
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,268 @@
+package hostex
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes a problem with a single field of a request
+type FieldError struct {
+	// Field is the JSON name of the field, e.g. "prices[2].date"
+	Field string
+
+	// Message describes the problem
+	Message string
+}
+
+// Error implements the error interface
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when a request fails local validation before
+// it is sent. It matches ErrValidation with errors.Is.
+type ValidationError struct {
+	// Type is the name of the validated type, e.g. "CreateReservationData"
+	Type string
+
+	// Fields lists every problem found
+	Fields []FieldError
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("invalid %s: %s", e.Type, strings.Join(msgs, "; "))
+}
+
+// Is reports whether target is ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// validator collects field errors for a ValidationError
+type validator struct {
+	fields []FieldError
+}
+
+// check records a field error when ok is false
+func (v *validator) check(ok bool, field, message string) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Message: message})
+	}
+}
+
+// date records a field error when a required date is missing or invalid
+func (v *validator) date(d Date, field string) {
+	switch {
+	case d.IsZero():
+		v.check(false, field, "is required")
+	case !d.IsValid():
+		v.check(false, field, "is not a valid date")
+	}
+}
+
+// dateRange checks a required start and end date with end not before start
+func (v *validator) dateRange(start, end Date, startField, endField string) {
+	v.date(start, startField)
+	v.date(end, endField)
+	if start.IsValid() && end.IsValid() {
+		v.check(!end.Before(start), endField, "must not be before "+startField)
+	}
+}
+
+// optionalDateRange checks an optional start and end date with end not
+// before start when both are set
+func (v *validator) optionalDateRange(start, end Date, startField, endField string) {
+	v.check(start.IsValid() || start.IsZero(), startField, "is not a valid date")
+	v.check(end.IsValid() || end.IsZero(), endField, "is not a valid date")
+	if start.IsValid() && end.IsValid() {
+		v.check(!end.Before(start), endField, "must not be before "+startField)
+	}
+}
+
+// err returns the collected errors as a ValidationError, or nil
+func (v *validator) err(typeName string) error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Type: typeName, Fields: v.fields}
+}
+
+// validatable is implemented by request payloads that can be checked locally
+type validatable interface {
+	Validate() error
+}
+
+// validate checks a payload unless validation is disabled on the client
+func (c *Client) validate(v validatable) error {
+	if c.skipValidation {
+		return nil
+	}
+	return v.Validate()
+}
+
+// Validate checks the reservation before it is sent
+func (d CreateReservationData) Validate() error {
+	var v validator
+
+	v.check(d.PropertyID != "", "property_id", "is required")
+	v.check(d.CustomChannelID > 0, "custom_channel_id", "is required")
+	v.check(d.IncomeMethodID > 0, "income_method_id", "is required")
+	v.check(strings.TrimSpace(d.GuestName) != "", "guest_name", "is required")
+	v.date(d.CheckInDate, "check_in_date")
+	v.date(d.CheckOutDate, "check_out_date")
+	if d.CheckInDate.IsValid() && d.CheckOutDate.IsValid() {
+		v.check(d.CheckOutDate.After(d.CheckInDate), "check_out_date", "must be after check_in_date")
+	}
+	v.check(d.NumberOfGuests >= 0, "number_of_guests", "must not be negative")
+
+	currency, err := d.Currency()
+	if err != nil {
+		v.check(false, "currency", err.Error())
+	} else {
+		v.check(currency != "", "currency", "is required on the amounts")
+	}
+	v.check(!d.RateAmount.IsNegative(), "rate_amount", "must not be negative")
+	v.check(!d.CommissionAmount.IsNegative(), "commission_amount", "must not be negative")
+	v.check(!d.ReceivedAmount.IsNegative(), "received_amount", "must not be negative")
+
+	return v.err("CreateReservationData")
+}
+
+// Validate checks the availability query before it is sent
+func (p ListAvailabilitiesParams) Validate() error {
+	var v validator
+
+	v.check(strings.TrimSpace(p.PropertyIDs) != "", "property_ids", "is required")
+	v.dateRange(p.StartDate, p.EndDate, "start_date", "end_date")
+
+	return v.err("ListAvailabilitiesParams")
+}
+
+// Validate checks the availability update before it is sent. Either Dates
+// or StartDate and EndDate must be set, but not both.
+func (d UpdateAvailabilitiesData) Validate() error {
+	var v validator
+
+	v.check(len(d.PropertyIDs) > 0, "property_ids", "is required")
+	for i, id := range d.PropertyIDs {
+		v.check(id > 0, fmt.Sprintf("property_ids[%d]", i), "must be positive")
+	}
+
+	hasRange := !d.StartDate.IsZero() || !d.EndDate.IsZero()
+	switch {
+	case hasRange && len(d.Dates) > 0:
+		v.check(false, "dates", "must not be combined with start_date and end_date")
+	case hasRange:
+		v.dateRange(d.StartDate, d.EndDate, "start_date", "end_date")
+	case len(d.Dates) == 0:
+		v.check(false, "dates", "or start_date and end_date are required")
+	}
+	for i, date := range d.Dates {
+		v.date(date, fmt.Sprintf("dates[%d]", i))
+	}
+
+	return v.err("UpdateAvailabilitiesData")
+}
+
+// Validate checks the calendar query before it is sent
+func (d GetListingCalendarData) Validate() error {
+	var v validator
+
+	v.dateRange(d.StartDate, d.EndDate, "start_date", "end_date")
+	v.check(len(d.Listings) > 0, "listings", "is required")
+	for i, l := range d.Listings {
+		v.check(l.ChannelType != "", fmt.Sprintf("listings[%d].channel_type", i), "is required")
+		v.check(l.ListingID != "", fmt.Sprintf("listings[%d].listing_id", i), "is required")
+	}
+
+	return v.err("GetListingCalendarData")
+}
+
+// Validate checks the price update before it is sent
+func (d UpdateListingPricesData) Validate() error {
+	var v validator
+
+	v.check(d.ChannelType != "", "channel_type", "is required")
+	v.check(d.ListingID != "", "listing_id", "is required")
+	v.check(len(d.Prices) > 0, "prices", "is required")
+	for i, p := range d.Prices {
+		v.date(p.Date, fmt.Sprintf("prices[%d].date", i))
+		v.check(!p.Price.IsNegative(), fmt.Sprintf("prices[%d].price", i), "must not be negative")
+	}
+
+	return v.err("UpdateListingPricesData")
+}
+
+// Validate checks the inventory update before it is sent
+func (d UpdateListingInventoriesData) Validate() error {
+	var v validator
+
+	v.check(d.ChannelType != "", "channel_type", "is required")
+	v.check(d.ListingID != "", "listing_id", "is required")
+	v.check(len(d.Inventories) > 0, "inventories", "is required")
+	for i, inv := range d.Inventories {
+		v.date(inv.Date, fmt.Sprintf("inventories[%d].date", i))
+		v.check(inv.Inventory >= 0, fmt.Sprintf("inventories[%d].inventory", i), "must not be negative")
+	}
+
+	return v.err("UpdateListingInventoriesData")
+}
+
+// Validate checks the restriction update before it is sent
+func (d UpdateListingRestrictionsData) Validate() error {
+	var v validator
+
+	v.check(d.ChannelType != "", "channel_type", "is required")
+	v.check(d.ListingID != "", "listing_id", "is required")
+	v.check(len(d.Restrictions) > 0, "restrictions", "is required")
+	for i, r := range d.Restrictions {
+		field := fmt.Sprintf("restrictions[%d]", i)
+		v.date(r.Date, field+".date")
+		v.check(r.MinStay >= 0, field+".min_stay", "must not be negative")
+		v.check(r.MaxStay >= 0, field+".max_stay", "must not be negative")
+		v.check(r.MaxStay == 0 || r.MinStay <= r.MaxStay, field+".min_stay", "must not exceed max_stay")
+	}
+
+	return v.err("UpdateListingRestrictionsData")
+}
+
+// Validate checks the review before it is sent
+func (d CreateReviewData) Validate() error {
+	var v validator
+
+	v.check(d.HostReviewScore == 0 || (d.HostReviewScore >= 1 && d.HostReviewScore <= 5), "host_review_score", "must be between 1 and 5")
+	v.check(d.HostReviewContent == "" || d.HostReviewScore != 0, "host_review_score", "is required with host_review_content")
+	v.check(d.HostReviewScore != 0 || d.HostReviewContent != "" || d.HostReplyContent != "", "host_review_content", "or host_reply_content is required")
+
+	return v.err("CreateReviewData")
+}
+
+// Validate checks the list parameters before the request is sent
+func (p ListReservationsParams) Validate() error {
+	var v validator
+
+	v.check(p.Status == "" || p.Status.IsValid(), "status", fmt.Sprintf("unknown reservation status %q", p.Status))
+	v.optionalDateRange(p.StartCheckInDate, p.EndCheckInDate, "start_check_in_date", "end_check_in_date")
+	v.optionalDateRange(p.StartCheckOutDate, p.EndCheckOutDate, "start_check_out_date", "end_check_out_date")
+	v.check(p.Offset >= 0, "offset", "must not be negative")
+	v.check(p.Limit >= 0, "limit", "must not be negative")
+
+	return v.err("ListReservationsParams")
+}
+
+// Validate checks the list parameters before the request is sent
+func (p ListReviewsParams) Validate() error {
+	var v validator
+
+	v.check(p.ReviewStatus == "" || p.ReviewStatus.IsValid(), "review_status", fmt.Sprintf("unknown review status %q", p.ReviewStatus))
+	v.optionalDateRange(p.StartCheckOutDate, p.EndCheckOutDate, "start_check_out_date", "end_check_out_date")
+	v.check(p.Offset >= 0, "offset", "must not be negative")
+	v.check(p.Limit >= 0, "limit", "must not be negative")
+
+	return v.err("ListReviewsParams")
+}
@@ -0,0 +1,177 @@
+package hostex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+)
+
+func validReservation() hostex.CreateReservationData {
+	return hostex.CreateReservationData{
+		PropertyID:      "12345",
+		CustomChannelID: 1,
+		IncomeMethodID:  2,
+		GuestName:       "Jane Doe",
+		CheckInDate:     hostex.MustParseDate("2024-07-01"),
+		CheckOutDate:    hostex.MustParseDate("2024-07-07"),
+		RateAmount:      hostex.MustParseMoney("840.00", "USD"),
+	}
+}
+
+func fieldNames(t *testing.T, err error) []string {
+	t.Helper()
+
+	var verr *hostex.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	if !errors.Is(err, hostex.ErrValidation) {
+		t.Errorf("Expected ValidationError to match ErrValidation")
+	}
+
+	names := make([]string, len(verr.Fields))
+	for i, f := range verr.Fields {
+		names[i] = f.Field
+	}
+	return names
+}
+
+func TestValidate_CreateReservation(t *testing.T) {
+	if err := validReservation().Validate(); err != nil {
+		t.Fatalf("Expected valid reservation, got %v", err)
+	}
+
+	data := validReservation()
+	data.CheckOutDate = hostex.MustParseDate("2024-06-30")
+	data.GuestName = " "
+	data.CommissionAmount = hostex.NewMoney(100, "EUR")
+
+	got := fieldNames(t, data.Validate())
+	want := []string{"guest_name", "check_out_date", "currency"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected fields %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected fields %v, got %v", want, got)
+			break
+		}
+	}
+}
+
+func TestValidate_Payloads(t *testing.T) {
+	day := hostex.MustParseDate("2024-07-01")
+
+	tests := []struct {
+		name  string
+		data  interface{ Validate() error }
+		field string
+	}{
+		{
+			name: "availabilities with dates and range",
+			data: hostex.UpdateAvailabilitiesData{
+				PropertyIDs: []int{1}, Available: true,
+				Dates: []hostex.Date{day}, StartDate: day, EndDate: day.AddDays(3),
+			},
+			field: "dates",
+		},
+		{
+			name:  "availabilities without dates",
+			data:  hostex.UpdateAvailabilitiesData{PropertyIDs: []int{1}},
+			field: "dates",
+		},
+		{
+			name:  "availabilities with reversed range",
+			data:  hostex.UpdateAvailabilitiesData{PropertyIDs: []int{1}, StartDate: day, EndDate: day.AddDays(-1)},
+			field: "end_date",
+		},
+		{
+			name: "prices with negative amount",
+			data: hostex.UpdateListingPricesData{
+				ChannelType: hostex.ChannelAirbnb, ListingID: "1",
+				Prices: []hostex.Price{{Date: day, Price: hostex.MustParseMoney("-1", "USD")}},
+			},
+			field: "prices[0].price",
+		},
+		{
+			name: "restrictions with min stay above max stay",
+			data: hostex.UpdateListingRestrictionsData{
+				ChannelType: hostex.ChannelAirbnb, ListingID: "1",
+				Restrictions: []hostex.Restriction{{Date: day, MinStay: 2}, {Date: day, MinStay: 5, MaxStay: 3}},
+			},
+			field: "restrictions[1].min_stay",
+		},
+		{
+			name:  "availability query with reversed range",
+			data:  hostex.ListAvailabilitiesParams{PropertyIDs: "1", StartDate: day, EndDate: day.AddDays(-1)},
+			field: "end_date",
+		},
+		{
+			name:  "availability query without dates",
+			data:  hostex.ListAvailabilitiesParams{PropertyIDs: "1", StartDate: day},
+			field: "end_date",
+		},
+		{
+			name:  "reservations with reversed check-in range",
+			data:  hostex.ListReservationsParams{StartCheckInDate: day, EndCheckInDate: day.AddDays(-1)},
+			field: "end_check_in_date",
+		},
+		{
+			name:  "reservations with reversed check-out range",
+			data:  hostex.ListReservationsParams{StartCheckOutDate: day.AddDays(1), EndCheckOutDate: day},
+			field: "end_check_out_date",
+		},
+		{
+			name:  "reviews with reversed check-out range",
+			data:  hostex.ListReviewsParams{StartCheckOutDate: day.AddDays(1), EndCheckOutDate: day},
+			field: "end_check_out_date",
+		},
+		{
+			name:  "review score out of range",
+			data:  hostex.CreateReviewData{HostReviewScore: 6, HostReviewContent: "Great guest"},
+			field: "host_review_score",
+		},
+		{
+			name:  "empty review",
+			data:  hostex.CreateReviewData{},
+			field: "host_review_content",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldNames(t, tt.data.Validate())
+			if len(got) != 1 || got[0] != tt.field {
+				t.Errorf("Expected error on %s, got %v", tt.field, got)
+			}
+		})
+	}
+}
+
+func TestValidate_ClientFailsFast(t *testing.T) {
+	called := false
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		okHandler(w, r)
+	}
+
+	client := newTestClient(t, handler)
+	err := client.CreateReview(context.Background(), "ABC123", hostex.CreateReviewData{HostReviewScore: 0, HostReviewContent: "Lovely"})
+	if !errors.Is(err, hostex.ErrValidation) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+	if called {
+		t.Error("Expected invalid payload to fail before sending a request")
+	}
+
+	client = newTestClientWithConfig(t, hostex.Config{DisableValidation: true, RetryPolicy: &hostex.NoRetry}, handler)
+	if err := client.CreateReview(context.Background(), "ABC123", hostex.CreateReviewData{HostReviewScore: 9}); err != nil {
+		t.Errorf("Expected validation to be skipped, got %v", err)
+	}
+	if !called {
+		t.Error("Expected request to be sent with validation disabled")
+	}
+}
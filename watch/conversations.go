@@ -0,0 +1,351 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/internal/statefile"
+)
+
+// DefaultConcurrency is the default number of conversations fetched at once
+const DefaultConcurrency = 4
+
+// GuestMessage is a new message from a guest
+type GuestMessage struct {
+	// Conversation is the conversation as listed in the poll that found
+	// the message
+	Conversation hostex.Conversation
+
+	Message hostex.Message
+
+	// DetectedAt is when the poll that found the message ran
+	DetectedAt time.Time
+}
+
+// ConversationWatcherOptions configures a ConversationWatcher
+type ConversationWatcherOptions struct {
+	// Interval is the time between polls in Run (optional, defaults to
+	// DefaultInterval)
+	Interval time.Duration
+
+	// Concurrency is the number of conversations fetched at once (optional,
+	// defaults to DefaultConcurrency)
+	Concurrency int
+
+	// StatePath is the file the high-water marks are persisted to
+	// (optional; without it every start begins afresh)
+	StatePath string
+
+	// Since makes the first poll, with no persisted state, report guest
+	// messages sent after it. By default the first poll only records the
+	// current state.
+	Since time.Time
+
+	// FullScan pages through every conversation on each poll. By default a
+	// poll stops at the first conversation whose last message is not newer
+	// than the previous poll, relying on conversations being listed most
+	// recent first.
+	FullScan bool
+
+	// OnMessage is called for every new guest message, oldest conversation
+	// first and in order within a conversation. If it returns an error the
+	// poll stops and the message is reported again on the next poll; see
+	// ErrDeferred for putting a message off.
+	OnMessage func(ctx context.Context, msg GuestMessage) error
+
+	// Messages receives every new guest message, after OnMessage. Sends
+	// block until the message is received or the poll's context is done.
+	Messages chan<- GuestMessage
+
+	// Logger receives a record for every failed poll in Run, and a debug
+	// record for every deferred one (optional)
+	Logger *slog.Logger
+
+	// Now returns the current time (optional, defaults to time.Now)
+	Now func() time.Time
+}
+
+// messageMark is the high-water mark of a conversation: the time of the
+// latest message handled and the IDs of the messages handled at that time.
+// Without IDs every message at that time counts as handled.
+type messageMark struct {
+	At  time.Time `json:"at"`
+	IDs []string  `json:"ids,omitempty"`
+}
+
+// isNew reports whether a message is past the mark
+func (m *messageMark) isNew(msg hostex.Message) bool {
+	return msg.CreatedAt.After(m.At) || (msg.CreatedAt.Equal(m.At) && m.IDs != nil && !slices.Contains(m.IDs, msg.ID))
+}
+
+// advance moves the mark past a handled message
+func (m *messageMark) advance(msg hostex.Message) {
+	switch {
+	case msg.CreatedAt.After(m.At):
+		m.At, m.IDs = msg.CreatedAt, []string{msg.ID}
+	case msg.CreatedAt.Equal(m.At):
+		m.IDs = append(m.IDs, msg.ID)
+	}
+}
+
+// conversationState is the persisted state of a ConversationWatcher
+type conversationState struct {
+	PolledAt time.Time `json:"polled_at"`
+
+	// HighWater is the latest LastMessageAt up to which every conversation
+	// has been handled
+	HighWater time.Time `json:"high_water"`
+
+	Conversations map[string]messageMark `json:"conversations"`
+}
+
+// ConversationWatcher polls the inbox and reports new guest messages. Only
+// conversations whose LastMessageAt moved since the last poll are fetched.
+type ConversationWatcher struct {
+	svc  hostex.ConversationService
+	opts ConversationWatcherOptions
+
+	// poll serializes polls; mu guards the state and is not held while
+	// messages are reported
+	poll sync.Mutex
+
+	mu    sync.Mutex
+	state conversationState
+}
+
+// NewConversationWatcher creates a watcher, loading its state from
+// opts.StatePath if it exists. OnMessage or Messages must be set.
+func NewConversationWatcher(svc hostex.ConversationService, opts ConversationWatcherOptions) (*ConversationWatcher, error) {
+	if opts.OnMessage == nil && opts.Messages == nil {
+		return nil, errors.New("watch: OnMessage or Messages is required")
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	w := &ConversationWatcher{svc: svc, opts: opts}
+	if opts.StatePath != "" {
+		if err := statefile.Load(opts.StatePath, &w.state); err != nil {
+			return nil, fmt.Errorf("watch: %w", err)
+		}
+	}
+	if w.state.Conversations == nil {
+		w.state.Conversations = make(map[string]messageMark)
+	}
+	return w, nil
+}
+
+// HighWater returns the time up to which every conversation has been handled
+func (w *ConversationWatcher) HighWater() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.state.HighWater
+}
+
+// Run polls every Interval until ctx is done, starting immediately. Failed
+// polls are logged and retried on the next tick. It returns ctx.Err().
+func (w *ConversationWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Poll(ctx); ctx.Err() == nil {
+			logPoll(ctx, w.opts.Logger, "conversation", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetched is the result of fetching a changed conversation
+type fetched struct {
+	conv    hostex.Conversation
+	details *hostex.ConversationDetails
+	err     error
+}
+
+// Poll lists the conversations once, fetches those with new messages and
+// reports the new guest messages. It returns the messages that were
+// reported. A conversation that cannot be fetched is retried on the next
+// poll while the others are still reported. Nothing is recorded unless the
+// listing completes.
+func (w *ConversationWatcher) Poll(ctx context.Context) ([]GuestMessage, error) {
+	w.poll.Lock()
+	defer w.poll.Unlock()
+
+	w.mu.Lock()
+	highWater := w.state.HighWater
+	initial := w.state.PolledAt.IsZero() && len(w.state.Conversations) == 0
+	saved := maps.Clone(w.state.Conversations)
+	w.mu.Unlock()
+
+	now := w.opts.Now()
+
+	// marks holds the marks set by this poll until they are committed
+	marks := make(map[string]messageMark)
+	var changed []hostex.Conversation
+	var latest time.Time
+	for conv, err := range w.svc.Conversations(ctx, nil) {
+		if err != nil {
+			return nil, err
+		}
+		if !w.opts.FullScan && !highWater.IsZero() && !conv.LastMessageAt.After(highWater) {
+			break
+		}
+		if conv.LastMessageAt.After(latest) {
+			latest = conv.LastMessageAt
+		}
+
+		mark, ok := saved[conv.ID]
+		if !ok && initial {
+			if w.opts.Since.IsZero() || !conv.LastMessageAt.After(w.opts.Since) {
+				marks[conv.ID] = messageMark{At: conv.LastMessageAt}
+				continue
+			}
+			mark = messageMark{At: w.opts.Since}
+			marks[conv.ID] = mark
+		}
+		if conv.LastMessageAt.After(mark.At) || !ok {
+			changed = append(changed, conv)
+		}
+	}
+
+	// Oldest activity first, so a failure holds back the newest messages
+	slices.SortStableFunc(changed, func(a, b hostex.Conversation) int {
+		return a.LastMessageAt.Compare(b.LastMessageAt)
+	})
+
+	var reported []GuestMessage
+	var errs []error
+	for _, f := range w.fetch(ctx, changed) {
+		if f.err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch conversation %s: %w", f.conv.ID, f.err))
+			continue
+		}
+
+		mark, ok := marks[f.conv.ID]
+		if !ok {
+			mark = saved[f.conv.ID]
+		}
+		msgs, mark, err := w.handle(ctx, f, mark, now)
+		marks[f.conv.ID] = mark
+		reported = append(reported, msgs...)
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+	}
+
+	// The marks only cover messages that were reported, so they are kept
+	// even if a later conversation failed
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	maps.Copy(w.state.Conversations, marks)
+	if len(errs) == 0 {
+		if latest.After(w.state.HighWater) {
+			w.state.HighWater = latest
+		}
+		w.state.PolledAt = now
+	}
+	return reported, errors.Join(errors.Join(errs...), w.save())
+}
+
+// fetch gets the details of the conversations, at most Concurrency at a
+// time. The results are in the order of convs.
+func (w *ConversationWatcher) fetch(ctx context.Context, convs []hostex.Conversation) []fetched {
+	results := make([]fetched, len(convs))
+	sem := make(chan struct{}, w.opts.Concurrency)
+
+	var wg sync.WaitGroup
+	for i, conv := range convs {
+		results[i].conv = conv
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i].err = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			results[i].details, results[i].err = w.svc.GetConversation(ctx, conv.ID)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// handle reports the new guest messages of a fetched conversation and
+// returns its mark advanced past every message handled
+func (w *ConversationWatcher) handle(ctx context.Context, f fetched, mark messageMark, now time.Time) ([]GuestMessage, messageMark, error) {
+	msgs := slices.Clone(f.details.Messages)
+	slices.SortStableFunc(msgs, func(a, b hostex.Message) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	var reported []GuestMessage
+	for _, msg := range msgs {
+		if !mark.isNew(msg) {
+			continue
+		}
+		if msg.SenderRole == hostex.SenderGuest {
+			gm := GuestMessage{Conversation: f.conv, Message: msg, DetectedAt: now}
+			if err := w.emit(ctx, gm); err != nil {
+				return reported, mark, err
+			}
+			reported = append(reported, gm)
+		}
+		mark.advance(msg)
+	}
+
+	if f.conv.LastMessageAt.After(mark.At) {
+		mark = messageMark{At: f.conv.LastMessageAt}
+	}
+	return reported, mark, nil
+}
+
+// emit reports a message to the callback and the channel
+func (w *ConversationWatcher) emit(ctx context.Context, msg GuestMessage) error {
+	if w.opts.OnMessage != nil {
+		if err := w.opts.OnMessage(ctx, msg); err != nil {
+			return err
+		}
+	}
+	if w.opts.Messages != nil {
+		select {
+		case w.opts.Messages <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// save persists the state if a state path is configured
+func (w *ConversationWatcher) save() error {
+	if w.opts.StatePath == "" {
+		return nil
+	}
+	if err := statefile.Save(w.opts.StatePath, &w.state); err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+package watch_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/hostexmock"
+	"github.com/keithah/hostex-go/hostextest"
+	"github.com/keithah/hostex-go/watch"
+)
+
+// newInboxServer returns a fake server with three conversations and a clock
+// that advances a minute per message
+func newInboxServer(t *testing.T) *hostextest.Server {
+	t.Helper()
+
+	srv := hostextest.NewServer()
+	t.Cleanup(srv.Close)
+
+	var mu sync.Mutex
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	srv.Now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(time.Minute)
+		return now
+	}
+
+	fixtures := hostextest.DefaultFixtures()
+	for _, id := range []string{"conv-2", "conv-3"} {
+		fixtures.Conversations = append(fixtures.Conversations, hostex.Conversation{
+			ID:            id,
+			ChannelType:   hostex.ChannelBookingSite,
+			Guest:         hostex.Guest{Name: "Guest " + id},
+			LastMessageAt: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	srv.Seed(fixtures)
+	return srv
+}
+
+// inbox records the messages passed to OnMessage
+type inbox struct {
+	mu       sync.Mutex
+	messages []watch.GuestMessage
+	fail     error
+}
+
+func (i *inbox) onMessage(ctx context.Context, msg watch.GuestMessage) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.fail != nil {
+		return i.fail
+	}
+	i.messages = append(i.messages, msg)
+	return nil
+}
+
+func pollInbox(t *testing.T, w *watch.ConversationWatcher) []watch.GuestMessage {
+	t.Helper()
+	msgs, err := w.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	return msgs
+}
+
+func contents(msgs []watch.GuestMessage) []string {
+	var out []string
+	for _, m := range msgs {
+		out = append(out, m.Conversation.ID+": "+m.Message.Content)
+	}
+	return out
+}
+
+func TestConversationWatcher_NewGuestMessages(t *testing.T) {
+	srv := newInboxServer(t)
+	client := srv.Client()
+	box := &inbox{}
+
+	w, err := watch.NewConversationWatcher(client, watch.ConversationWatcherOptions{OnMessage: box.onMessage})
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+
+	// The first poll only records the high-water marks
+	if msgs := pollInbox(t, w); len(msgs) != 0 {
+		t.Fatalf("Expected no messages on the first poll, got %v", contents(msgs))
+	}
+	srv.AssertNotCalled(t, "GET /conversations/{id}")
+
+	srv.ReceiveMessage("conv-2", "Where do I park?")
+	srv.ReceiveMessage("conv-1", "We land at noon")
+	if err := client.SendMessage(context.Background(), "conv-1", hostex.SendMessageData{Message: "Great, see you then"}); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	srv.ReceiveMessage("conv-1", "Thanks!")
+
+	srv.ResetRequests()
+	got := contents(pollInbox(t, w))
+	want := []string{"conv-2: Where do I park?", "conv-1: We land at noon", "conv-1: Thanks!"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Unexpected messages:\n got %v\nwant %v", got, want)
+	}
+	// conv-3 has no new activity and is not fetched
+	srv.AssertCalled(t, "GET /conversations/{id}", 2)
+
+	srv.ResetRequests()
+	if msgs := pollInbox(t, w); len(msgs) != 0 {
+		t.Errorf("Expected no messages without activity, got %v", contents(msgs))
+	}
+	srv.AssertNotCalled(t, "GET /conversations/{id}")
+
+	if len(box.messages) != 3 {
+		t.Errorf("Expected OnMessage to see 3 messages, got %d", len(box.messages))
+	}
+	if !w.HighWater().After(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected high-water mark to advance, got %v", w.HighWater())
+	}
+}
+
+func TestConversationWatcher_PersistsHighWater(t *testing.T) {
+	srv := newInboxServer(t)
+	path := filepath.Join(t.TempDir(), "inbox.json")
+	opts := watch.ConversationWatcherOptions{StatePath: path, OnMessage: (&inbox{}).onMessage}
+
+	w, err := watch.NewConversationWatcher(srv.Client(), opts)
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+	pollInbox(t, w)
+	srv.ReceiveMessage("conv-1", "First")
+	pollInbox(t, w)
+
+	srv.ReceiveMessage("conv-1", "While you were away")
+
+	restarted, err := watch.NewConversationWatcher(srv.Client(), opts)
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+	if got := contents(pollInbox(t, restarted)); len(got) != 1 || got[0] != "conv-1: While you were away" {
+		t.Errorf("Expected only the message sent while stopped, got %v", got)
+	}
+}
+
+func TestConversationWatcher_Since(t *testing.T) {
+	srv := newInboxServer(t)
+	ch := make(chan watch.GuestMessage, 10)
+
+	w, err := watch.NewConversationWatcher(srv.Client(), watch.ConversationWatcherOptions{
+		Since:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Messages: ch,
+	})
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+	pollInbox(t, w)
+
+	if len(ch) != 1 {
+		t.Fatalf("Expected 1 message on the channel, got %d", len(ch))
+	}
+	if msg := <-ch; msg.Message.ID != "msg-1" {
+		t.Errorf("Expected the fixture message, got %+v", msg.Message)
+	}
+}
+
+func TestConversationWatcher_Failures(t *testing.T) {
+	srv := newInboxServer(t)
+	box := &inbox{}
+
+	w, err := watch.NewConversationWatcher(srv.Client(), watch.ConversationWatcherOptions{OnMessage: box.onMessage, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+	pollInbox(t, w)
+	srv.ReceiveMessage("conv-2", "Hello?")
+	srv.ReceiveMessage("conv-3", "Anyone there?")
+
+	// A failed callback reports the message again on the next poll
+	box.fail = errors.New("responder down")
+	if _, err := w.Poll(context.Background()); !errors.Is(err, box.fail) {
+		t.Fatalf("Expected callback error, got %v", err)
+	}
+	box.fail = nil
+
+	// A conversation that cannot be fetched does not hold back the others
+	srv.Fail("GET /conversations/{id}", hostextest.Failure{StatusCode: http.StatusInternalServerError})
+	msgs, err := w.Poll(context.Background())
+	if err == nil {
+		t.Fatal("Expected an error for the failed fetch")
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Expected the other conversation to be reported, got %v", contents(msgs))
+	}
+
+	retried := pollInbox(t, w)
+	if len(retried) != 1 || retried[0].Conversation.ID == msgs[0].Conversation.ID {
+		t.Errorf("Expected the failed conversation to be retried, got %v", contents(retried))
+	}
+	if len(box.messages) != 2 {
+		t.Errorf("Expected each message to be reported once, got %v", contents(box.messages))
+	}
+}
+
+func TestConversationWatcher_BoundedConcurrency(t *testing.T) {
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var convs []hostex.Conversation
+	for i := range 12 {
+		convs = append(convs, hostex.Conversation{ID: fmt.Sprintf("conv-%d", i), LastMessageAt: base})
+	}
+
+	var active, peak atomic.Int32
+	mock := &hostexmock.Client{
+		ListConversationsFunc: func(ctx context.Context, params *hostex.ListConversationsParams) (*hostex.ConversationsResponse, error) {
+			return &hostex.ConversationsResponse{Conversations: convs, Total: len(convs)}, nil
+		},
+		GetConversationFunc: func(ctx context.Context, id string) (*hostex.ConversationDetails, error) {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return &hostex.ConversationDetails{Messages: []hostex.Message{
+				{ID: id + "-m", SenderRole: hostex.SenderGuest, Content: "hi", CreatedAt: base.Add(time.Hour)},
+			}}, nil
+		},
+	}
+
+	w, err := watch.NewConversationWatcher(mock, watch.ConversationWatcherOptions{
+		Since:       base.Add(-time.Hour),
+		Concurrency: 3,
+		OnMessage:   (&inbox{}).onMessage,
+	})
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+	if msgs := pollInbox(t, w); len(msgs) != 12 {
+		t.Errorf("Expected 12 messages, got %d", len(msgs))
+	}
+	if mock.CallCount("GetConversation") != 12 {
+		t.Errorf("Expected 12 fetches, got %d", mock.CallCount("GetConversation"))
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("Expected at most 3 concurrent fetches, got %d", p)
+	}
+}
+
+func TestConversationWatcher_FailedListingRecordsNothing(t *testing.T) {
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var convs []hostex.Conversation
+	for i := range 4 {
+		convs = append(convs, hostex.Conversation{ID: fmt.Sprintf("conv-%d", i), LastMessageAt: since.Add(time.Hour)})
+	}
+
+	var fail atomic.Bool
+	fail.Store(true)
+	mock := &hostexmock.Client{
+		// Two conversations per page; the second page fails while fail is set
+		ListConversationsFunc: func(ctx context.Context, params *hostex.ListConversationsParams) (*hostex.ConversationsResponse, error) {
+			if params.Offset >= 2 && fail.Load() {
+				return nil, errors.New("page unavailable")
+			}
+			end := min(params.Offset+2, len(convs))
+			return &hostex.ConversationsResponse{Conversations: convs[params.Offset:end], Total: len(convs)}, nil
+		},
+		GetConversationFunc: func(ctx context.Context, id string) (*hostex.ConversationDetails, error) {
+			return &hostex.ConversationDetails{Messages: []hostex.Message{
+				{ID: id + "-old", SenderRole: hostex.SenderGuest, Content: "old", CreatedAt: since.Add(-time.Hour)},
+				{ID: id + "-new", SenderRole: hostex.SenderGuest, Content: "new", CreatedAt: since.Add(time.Hour)},
+			}}, nil
+		},
+	}
+
+	box := &inbox{}
+	w, err := watch.NewConversationWatcher(mock, watch.ConversationWatcherOptions{Since: since, OnMessage: box.onMessage})
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+	if _, err := w.Poll(context.Background()); err == nil {
+		t.Fatal("Expected the failed page to fail the poll")
+	}
+
+	// The retry is still a first poll for every conversation, so none
+	// replays its history
+	fail.Store(false)
+	got := contents(pollInbox(t, w))
+	want := []string{"conv-0: new", "conv-1: new", "conv-2: new", "conv-3: new"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Unexpected messages:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestConversationWatcher_CallbackCanReadState(t *testing.T) {
+	srv := newInboxServer(t)
+
+	var w *watch.ConversationWatcher
+	onMessage := func(ctx context.Context, msg watch.GuestMessage) error {
+		done := make(chan struct{})
+		go func() {
+			w.HighWater()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("HighWater blocked while a message was being reported")
+		}
+		return nil
+	}
+
+	var err error
+	w, err = watch.NewConversationWatcher(srv.Client(), watch.ConversationWatcherOptions{OnMessage: onMessage})
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+	pollInbox(t, w)
+	srv.ReceiveMessage("conv-1", "Hello?")
+	if msgs := pollInbox(t, w); len(msgs) != 1 {
+		t.Errorf("Expected 1 message, got %v", contents(msgs))
+	}
+}
+
+func TestConversationWatcher_RunLogsDeferralsAtDebug(t *testing.T) {
+	srv := newInboxServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	onMessage := func(ctx context.Context, msg watch.GuestMessage) error {
+		if calls.Add(1) == 3 {
+			cancel()
+		}
+		return fmt.Errorf("later: %w", watch.ErrDeferred)
+	}
+
+	var logs bytes.Buffer
+	w, err := watch.NewConversationWatcher(srv.Client(), watch.ConversationWatcherOptions{
+		Since:     time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Interval:  time.Millisecond,
+		OnMessage: onMessage,
+		Logger:    slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+	if err != nil {
+		t.Fatalf("NewConversationWatcher failed: %v", err)
+	}
+	if err := w.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run returned %v", err)
+	}
+
+	// The message is reported again on every poll without an error record
+	if calls.Load() < 3 {
+		t.Errorf("Expected the message to be reported again, got %d calls", calls.Load())
+	}
+	if bytes.Contains(logs.Bytes(), []byte("level=ERROR")) || !bytes.Contains(logs.Bytes(), []byte("poll deferred")) {
+		t.Errorf("Expected only deferral records, got:\n%s", logs.String())
+	}
+}
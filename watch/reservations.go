@@ -0,0 +1,553 @@
+package watch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/internal/statefile"
+)
+
+// Window is a check-in date range relative to the current date
+type Window struct {
+	// Before is the number of days before today the window starts
+	Before int
+
+	// After is the number of days after today the window ends
+	After int
+}
+
+// ReservationChange is a detected change to a reservation
+type ReservationChange struct {
+	Type ChangeType
+
+	// Reservation is the reservation as of this poll; for Removed it is
+	// the reservation as last seen
+	Reservation hostex.Reservation
+
+	// Previous is the reservation as of the last poll; nil for Created.
+	// For the first poll after a restart it holds only the reservation
+	// code, status and dates, as guest details are not persisted.
+	Previous *hostex.Reservation
+
+	// Fields lists the changed fields; empty for Created and Removed. For
+	// the first poll after a restart, Old is nil for every field except the
+	// status and dates.
+	Fields []FieldChange
+
+	// DetectedAt is when the poll that found the change ran
+	DetectedAt time.Time
+}
+
+// WatcherOptions configures a Watcher
+type WatcherOptions struct {
+	// Params filters the polled reservations, e.g. by property or with
+	// OrderBy (optional). Offset and Limit set where paging starts and the
+	// page size.
+	Params *hostex.ListReservationsParams
+
+	// CheckInWindow limits each poll to reservations checking in within a
+	// rolling window, overriding the check-in dates in Params (optional)
+	CheckInWindow *Window
+
+	// Location is the time zone that defines today for CheckInWindow
+	// (optional, defaults to UTC)
+	Location *time.Location
+
+	// Interval is the time between polls in Run (optional, defaults to
+	// DefaultInterval)
+	Interval time.Duration
+
+	// StatePath is the file the snapshot is persisted to (optional; without
+	// it every start begins from an empty snapshot)
+	StatePath string
+
+	// OnChange is called for every change, in order. If it returns an error
+	// the poll stops and the change is detected again on the next poll; see
+	// ErrDeferred for putting a change off.
+	OnChange func(ctx context.Context, change ReservationChange) error
+
+	// Changes receives every change, after OnChange. Sends block until the
+	// change is received or the poll's context is done.
+	Changes chan<- ReservationChange
+
+	// EmitInitial reports every reservation as Created on the first poll
+	// with an empty snapshot. By default the first poll only records the
+	// snapshot.
+	EmitInitial bool
+
+	// Logger receives a record for every failed poll in Run, and a debug
+	// record for every deferred one (optional)
+	Logger *slog.Logger
+
+	// Now returns the current time (optional, defaults to time.Now)
+	Now func() time.Time
+}
+
+// reservationRecord is the persisted form of a reservation. Only the
+// status and dates are stored as they are; every other compared field is
+// stored as a digest, so no guest details are written to disk.
+type reservationRecord struct {
+	Status       hostex.ReservationStatus `json:"status"`
+	CheckInDate  hostex.Date              `json:"check_in_date"`
+	CheckOutDate hostex.Date              `json:"check_out_date"`
+	Digests      map[string]string        `json:"digests,omitempty"`
+}
+
+// reservationState is the persisted snapshot of a Watcher
+type reservationState struct {
+	PolledAt     time.Time                    `json:"polled_at"`
+	Reservations map[string]reservationRecord `json:"reservations"`
+}
+
+// Watcher polls reservations and reports changes between polls
+type Watcher struct {
+	svc  hostex.ReservationService
+	opts WatcherOptions
+
+	// poll serializes polls; mu guards the snapshot and is not held while
+	// changes are reported
+	poll sync.Mutex
+
+	mu    sync.Mutex
+	state reservationState
+
+	// reservations holds the reservations seen since the start. Those only
+	// known from the state file are stubs built by reservationRecord.stub.
+	reservations map[string]hostex.Reservation
+	restored     map[string]bool
+}
+
+// NewWatcher creates a watcher, loading the snapshot from opts.StatePath if
+// it exists. OnChange or Changes must be set.
+func NewWatcher(svc hostex.ReservationService, opts WatcherOptions) (*Watcher, error) {
+	if opts.OnChange == nil && opts.Changes == nil {
+		return nil, errors.New("watch: OnChange or Changes is required")
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	w := &Watcher{
+		svc:          svc,
+		opts:         opts,
+		reservations: make(map[string]hostex.Reservation),
+		restored:     make(map[string]bool),
+	}
+	if opts.StatePath != "" {
+		if err := statefile.Load(opts.StatePath, &w.state); err != nil {
+			return nil, fmt.Errorf("watch: %w", err)
+		}
+	}
+	if w.state.Reservations == nil {
+		w.state.Reservations = make(map[string]reservationRecord)
+	}
+	for code, rec := range w.state.Reservations {
+		w.reservations[code] = rec.stub(code)
+		w.restored[code] = true
+	}
+	return w, nil
+}
+
+// Snapshot returns the reservations as of the last poll, by reservation code.
+// Before the first poll after a restart they hold only the reservation code,
+// status and dates.
+func (w *Watcher) Snapshot() map[string]hostex.Reservation {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return maps.Clone(w.reservations)
+}
+
+// Run polls every Interval until ctx is done, starting immediately. Failed
+// polls are logged and retried on the next tick. It returns ctx.Err().
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Poll(ctx); ctx.Err() == nil {
+			logPoll(ctx, w.opts.Logger, "reservation", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches the reservations once, reports the changes since the last
+// poll and persists the snapshot. It returns the changes that were reported.
+// Reservations that no longer appear in the list, for example because they
+// were deleted or no longer match Params, are reported as Removed.
+func (w *Watcher) Poll(ctx context.Context) ([]ReservationChange, error) {
+	w.poll.Lock()
+	defer w.poll.Unlock()
+
+	now := w.opts.Now()
+	params := w.params(now)
+
+	var current []hostex.Reservation
+	seen := make(map[string]bool)
+	for r, err := range w.svc.Reservations(ctx, &params) {
+		if err != nil {
+			return nil, err
+		}
+		if r.ReservationCode == "" || seen[r.ReservationCode] {
+			continue
+		}
+		seen[r.ReservationCode] = true
+		current = append(current, r)
+	}
+
+	w.mu.Lock()
+	changes := w.diff(current, seen, params, now)
+	w.mu.Unlock()
+
+	// Changes are reported without holding the lock, so callbacks can use
+	// Snapshot
+	var emitted []ReservationChange
+	for _, change := range changes {
+		if err := w.emit(ctx, change); err != nil {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			return emitted, errors.Join(err, w.save())
+		}
+		w.mu.Lock()
+		w.apply(change)
+		w.mu.Unlock()
+		emitted = append(emitted, change)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.state.PolledAt = now
+	return emitted, w.save()
+}
+
+// diff compares a complete poll with the snapshot and returns the changes to
+// report. Reservations that changed in ways that are not reported are
+// updated in place. w.mu must be held.
+func (w *Watcher) diff(current []hostex.Reservation, seen map[string]bool, params hostex.ListReservationsParams, now time.Time) []ReservationChange {
+	initial := w.state.PolledAt.IsZero() && len(w.state.Reservations) == 0
+	var changes []ReservationChange
+	for _, r := range current {
+		prev, ok := w.reservations[r.ReservationCode]
+		if !ok {
+			if initial && !w.opts.EmitInitial {
+				w.set(r)
+				continue
+			}
+			changes = append(changes, ReservationChange{Type: Created, Reservation: r, DetectedAt: now})
+			continue
+		}
+
+		var fields []FieldChange
+		if w.restored[r.ReservationCode] {
+			fields = w.state.Reservations[r.ReservationCode].diff(r)
+		} else {
+			fields = DiffReservations(prev, r)
+		}
+		if len(fields) == 0 {
+			// Keep the latest copy of fields that are not compared
+			w.set(r)
+			continue
+		}
+		change := ReservationChange{Type: Updated, Reservation: r, Previous: &prev, Fields: fields, DetectedAt: now}
+		if r.Status == hostex.ReservationStatusCancelled && prev.Status != hostex.ReservationStatusCancelled {
+			change.Type = Cancelled
+		}
+		changes = append(changes, change)
+	}
+
+	for _, code := range slices.Sorted(maps.Keys(w.reservations)) {
+		if seen[code] {
+			continue
+		}
+		r := w.reservations[code]
+		if !params.StartCheckInDate.IsZero() && r.CheckInDate.Before(params.StartCheckInDate) {
+			// Reservations that have left the window for good are forgotten
+			w.forget(code)
+			continue
+		}
+		changes = append(changes, ReservationChange{Type: Removed, Reservation: r, Previous: &r, DetectedAt: now})
+	}
+	return changes
+}
+
+// apply records a reported change in the snapshot. w.mu must be held.
+func (w *Watcher) apply(change ReservationChange) {
+	if change.Type == Removed {
+		w.forget(change.Reservation.ReservationCode)
+		return
+	}
+	w.set(change.Reservation)
+}
+
+// set records the current version of a reservation. w.mu must be held.
+func (w *Watcher) set(r hostex.Reservation) {
+	w.reservations[r.ReservationCode] = r
+	w.state.Reservations[r.ReservationCode] = newReservationRecord(r)
+	delete(w.restored, r.ReservationCode)
+}
+
+// forget drops a reservation from the snapshot. w.mu must be held.
+func (w *Watcher) forget(code string) {
+	delete(w.reservations, code)
+	delete(w.state.Reservations, code)
+	delete(w.restored, code)
+}
+
+// params returns the list parameters for a poll at now
+func (w *Watcher) params(now time.Time) hostex.ListReservationsParams {
+	var params hostex.ListReservationsParams
+	if w.opts.Params != nil {
+		params = *w.opts.Params
+	}
+	if win := w.opts.CheckInWindow; win != nil {
+		today := hostex.DateOf(now.In(w.opts.Location))
+		params.StartCheckInDate = today.AddDays(-win.Before)
+		params.EndCheckInDate = today.AddDays(win.After)
+	}
+	return params
+}
+
+// emit reports a change to the callback and the channel
+func (w *Watcher) emit(ctx context.Context, change ReservationChange) error {
+	if w.opts.OnChange != nil {
+		if err := w.opts.OnChange(ctx, change); err != nil {
+			return err
+		}
+	}
+	if w.opts.Changes != nil {
+		select {
+		case w.opts.Changes <- change:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// save persists the snapshot if a state path is configured
+func (w *Watcher) save() error {
+	if w.opts.StatePath == "" {
+		return nil
+	}
+	if err := statefile.Save(w.opts.StatePath, &w.state); err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	return nil
+}
+
+// reservationFields lists the fields compared by DiffReservations
+var reservationFields = []struct {
+	name string
+	get  func(r *hostex.Reservation) any
+}{
+	{"property_id", func(r *hostex.Reservation) any { return r.PropertyID }},
+	{"listing_id", func(r *hostex.Reservation) any { return r.ListingID }},
+	{"channel_type", func(r *hostex.Reservation) any { return r.ChannelType }},
+	{"check_in_date", func(r *hostex.Reservation) any { return r.CheckInDate }},
+	{"check_out_date", func(r *hostex.Reservation) any { return r.CheckOutDate }},
+	{"number_of_guests", func(r *hostex.Reservation) any { return r.NumberOfGuests }},
+	{"number_of_adults", func(r *hostex.Reservation) any { return r.NumberOfAdults }},
+	{"number_of_children", func(r *hostex.Reservation) any { return r.NumberOfChildren }},
+	{"number_of_infants", func(r *hostex.Reservation) any { return r.NumberOfInfants }},
+	{"number_of_pets", func(r *hostex.Reservation) any { return r.NumberOfPets }},
+	{"status", func(r *hostex.Reservation) any { return r.Status }},
+	{"guest_name", func(r *hostex.Reservation) any { return r.GuestName }},
+	{"guest_phone", func(r *hostex.Reservation) any { return r.GuestPhone }},
+	{"guest_email", func(r *hostex.Reservation) any { return r.GuestEmail }},
+	{"cancelled_at", func(r *hostex.Reservation) any { return timeValue(r.CancelledAt) }},
+	{"conversation_id", func(r *hostex.Reservation) any { return r.ConversationID }},
+	{"in_reservation_box", func(r *hostex.Reservation) any { return r.InReservationBox }},
+}
+
+// newReservationRecord returns the persisted form of a reservation
+func newReservationRecord(r hostex.Reservation) reservationRecord {
+	rec := reservationRecord{
+		Status:       r.Status,
+		CheckInDate:  r.CheckInDate,
+		CheckOutDate: r.CheckOutDate,
+		Digests:      make(map[string]string),
+	}
+	for _, f := range comparedFields(r) {
+		rec.Digests[f.Field] = digest(f.New)
+	}
+	return rec
+}
+
+// stub returns a reservation with the fields kept in the record
+func (rec reservationRecord) stub(code string) hostex.Reservation {
+	return hostex.Reservation{
+		ReservationCode: code,
+		Status:          rec.Status,
+		CheckInDate:     rec.CheckInDate,
+		CheckOutDate:    rec.CheckOutDate,
+	}
+}
+
+// diff returns the fields of r that differ from the record. Old is set only
+// for the status and dates.
+func (rec reservationRecord) diff(r hostex.Reservation) []FieldChange {
+	var changes []FieldChange
+	compared := comparedFields(r)
+	for _, f := range compared {
+		var old any
+		switch f.Field {
+		case "status":
+			old = rec.Status
+		case "check_in_date":
+			old = rec.CheckInDate
+		case "check_out_date":
+			old = rec.CheckOutDate
+		}
+		if old != nil {
+			if !equal(old, f.New) {
+				changes = append(changes, FieldChange{Field: f.Field, Old: old, New: f.New})
+			}
+			continue
+		}
+		if rec.Digests[f.Field] != digest(f.New) {
+			changes = append(changes, FieldChange{Field: f.Field, New: f.New})
+		}
+	}
+
+	// Custom fields that were removed
+	for _, name := range slices.Sorted(maps.Keys(rec.Digests)) {
+		if !slices.ContainsFunc(compared, func(f FieldChange) bool { return f.Field == name }) {
+			changes = append(changes, FieldChange{Field: name})
+		}
+	}
+	return changes
+}
+
+// comparedFields returns the fields compared by DiffReservations, with their
+// values in New
+func comparedFields(r hostex.Reservation) []FieldChange {
+	var fields []FieldChange
+	for _, f := range reservationFields {
+		fields = append(fields, FieldChange{Field: f.name, New: f.get(&r)})
+	}
+	fields = append(fields, FieldChange{Field: "tags", New: sortedSet(r.Tags)})
+
+	custom, ok := asObject(r.CustomFields)
+	if !ok {
+		return append(fields, FieldChange{Field: "custom_fields", New: r.CustomFields})
+	}
+	for _, k := range slices.Sorted(maps.Keys(custom)) {
+		fields = append(fields, FieldChange{Field: "custom_fields." + k, New: custom[k]})
+	}
+	return fields
+}
+
+// digest returns a short hash of a field value. Times are hashed as UTC
+// so that equal instants match.
+func digest(v any) string {
+	if t, ok := v.(time.Time); ok {
+		v = t.UTC()
+	}
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// DiffReservations returns the fields that differ between two versions of a
+// reservation: dates, guest counts, status, guest details, tags and custom
+// fields. Tags are compared as a set; custom field objects are compared key
+// by key.
+func DiffReservations(old, new hostex.Reservation) []FieldChange {
+	var changes []FieldChange
+	for _, f := range reservationFields {
+		o, n := f.get(&old), f.get(&new)
+		if !equal(o, n) {
+			changes = append(changes, FieldChange{Field: f.name, Old: o, New: n})
+		}
+	}
+
+	if !slices.Equal(sortedSet(old.Tags), sortedSet(new.Tags)) {
+		changes = append(changes, FieldChange{Field: "tags", Old: old.Tags, New: new.Tags})
+	}
+
+	return append(changes, diffCustomFields(old.CustomFields, new.CustomFields)...)
+}
+
+// diffCustomFields compares custom fields key by key when both are objects
+func diffCustomFields(old, new any) []FieldChange {
+	oldMap, oldOK := asObject(old)
+	newMap, newOK := asObject(new)
+	if !oldOK || !newOK {
+		if equal(old, new) {
+			return nil
+		}
+		return []FieldChange{{Field: "custom_fields", Old: old, New: new}}
+	}
+
+	keys := slices.Collect(maps.Keys(oldMap))
+	for k := range newMap {
+		if _, ok := oldMap[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	var changes []FieldChange
+	for _, k := range keys {
+		if o, n := oldMap[k], newMap[k]; !equal(o, n) {
+			changes = append(changes, FieldChange{Field: "custom_fields." + k, Old: o, New: n})
+		}
+	}
+	return changes
+}
+
+// asObject returns v as a JSON object; nil counts as an empty object
+func asObject(v any) (map[string]any, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, true
+	case map[string]any:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// equal compares field values, treating equal instants as equal times
+func equal(a, b any) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// timeValue dereferences an optional time so that nil stays nil
+func timeValue(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// sortedSet returns the distinct values of s, sorted
+func sortedSet(s []string) []string {
+	return slices.Compact(slices.Sorted(slices.Values(s)))
+}
@@ -0,0 +1,295 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/hostextest"
+	"github.com/keithah/hostex-go/watch"
+)
+
+func newServer(t *testing.T) *hostextest.Server {
+	t.Helper()
+	srv := hostextest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Seed(hostextest.DefaultFixtures())
+	return srv
+}
+
+// collector records the changes passed to OnChange
+type collector struct {
+	changes []watch.ReservationChange
+	fail    error
+}
+
+func (c *collector) onChange(ctx context.Context, change watch.ReservationChange) error {
+	if c.fail != nil {
+		return c.fail
+	}
+	c.changes = append(c.changes, change)
+	return nil
+}
+
+func poll(t *testing.T, w *watch.Watcher) []watch.ReservationChange {
+	t.Helper()
+	changes, err := w.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	return changes
+}
+
+func fieldNames(fields []watch.FieldChange) string {
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Field)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestWatcher_DetectsChanges(t *testing.T) {
+	srv := newServer(t)
+	client := srv.Client()
+	ctx := context.Background()
+	c := &collector{}
+
+	w, err := watch.NewWatcher(client, watch.WatcherOptions{OnChange: c.onChange})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+
+	// The first poll only records the snapshot
+	if changes := poll(t, w); len(changes) != 0 {
+		t.Fatalf("Expected no changes on the first poll, got %+v", changes)
+	}
+	if len(w.Snapshot()) != 1 {
+		t.Fatalf("Expected 1 reservation in the snapshot, got %d", len(w.Snapshot()))
+	}
+
+	created, err := client.CreateReservation(ctx, hostex.CreateReservationData{
+		PropertyID:      "1001",
+		CustomChannelID: 1,
+		IncomeMethodID:  1,
+		GuestName:       "Grace Hopper",
+		CheckInDate:     hostex.MustParseDate("2024-08-01"),
+		CheckOutDate:    hostex.MustParseDate("2024-08-03"),
+		RateAmount:      hostex.MustParseMoney("300.00", "USD"),
+	})
+	if err != nil {
+		t.Fatalf("CreateReservation failed: %v", err)
+	}
+	srv.UpdateReservation("HMABC123", func(r *hostex.Reservation) {
+		r.CheckOutDate = hostex.MustParseDate("2024-07-06")
+		r.NumberOfGuests = 3
+		r.Tags = append(r.Tags, "vip")
+	})
+
+	changes := poll(t, w)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Type != watch.Updated || changes[0].Reservation.ReservationCode != "HMABC123" {
+		t.Errorf("Expected HMABC123 to be updated, got %+v", changes[0])
+	}
+	if got := fieldNames(changes[0].Fields); got != "check_out_date,number_of_guests,tags" {
+		t.Errorf("Unexpected changed fields: %s", got)
+	}
+	if changes[0].Previous == nil || changes[0].Previous.CheckOutDate != hostex.MustParseDate("2024-07-05") {
+		t.Errorf("Expected previous version, got %+v", changes[0].Previous)
+	}
+	if changes[1].Type != watch.Created || changes[1].Reservation.ReservationCode != created.Reservation.ReservationCode {
+		t.Errorf("Expected the new booking to be created, got %+v", changes[1])
+	}
+
+	if err := client.CancelReservation(ctx, created.Reservation.ReservationCode); err != nil {
+		t.Fatalf("CancelReservation failed: %v", err)
+	}
+	changes = poll(t, w)
+	if len(changes) != 1 || changes[0].Type != watch.Cancelled {
+		t.Fatalf("Expected a cancellation, got %+v", changes)
+	}
+
+	if changes := poll(t, w); len(changes) != 0 {
+		t.Errorf("Expected no changes without updates, got %+v", changes)
+	}
+	if len(c.changes) != 3 {
+		t.Errorf("Expected OnChange to see 3 changes, got %d", len(c.changes))
+	}
+}
+
+func TestWatcher_PersistsSnapshot(t *testing.T) {
+	srv := newServer(t)
+	path := filepath.Join(t.TempDir(), "state", "reservations.json")
+	opts := watch.WatcherOptions{StatePath: path, OnChange: (&collector{}).onChange}
+
+	w, err := watch.NewWatcher(srv.Client(), opts)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	poll(t, w)
+
+	// Guest details are not written to disk
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read state: %v", err)
+	}
+	if !strings.Contains(string(data), "HMABC123") || strings.Contains(string(data), "Lovelace") {
+		t.Errorf("Expected the state to hold codes but no guest details, got %s", data)
+	}
+
+	srv.UpdateReservation("HMABC123", func(r *hostex.Reservation) { r.GuestName = "Ada King" })
+
+	// A restarted watcher reports only what changed while it was down
+	restarted, err := watch.NewWatcher(srv.Client(), opts)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	changes := poll(t, restarted)
+	if len(changes) != 1 || fieldNames(changes[0].Fields) != "guest_name" {
+		t.Errorf("Expected only the guest name change, got %+v", changes)
+	}
+	if changes[0].Fields[0].New != "Ada King" {
+		t.Errorf("Expected the new guest name, got %v", changes[0].Fields[0])
+	}
+}
+
+func TestWatcher_ReportsRemoved(t *testing.T) {
+	srv := newServer(t)
+	var w *watch.Watcher
+	var snapshots []int
+	w, err := watch.NewWatcher(srv.Client(), watch.WatcherOptions{
+		Params: &hostex.ListReservationsParams{Status: hostex.ReservationStatusAccepted},
+		OnChange: func(ctx context.Context, change watch.ReservationChange) error {
+			// The snapshot can be read while changes are reported
+			snapshots = append(snapshots, len(w.Snapshot()))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	poll(t, w)
+
+	// A reservation that no longer matches the filter is reported once
+	srv.UpdateReservation("HMABC123", func(r *hostex.Reservation) { r.Status = hostex.ReservationStatusCancelled })
+	changes := poll(t, w)
+	if len(changes) != 1 || changes[0].Type != watch.Removed || changes[0].Reservation.ReservationCode != "HMABC123" {
+		t.Fatalf("Expected HMABC123 to be removed, got %+v", changes)
+	}
+	if len(snapshots) != 1 || snapshots[0] != 1 {
+		t.Errorf("Expected the callback to see the snapshot before the removal, got %v", snapshots)
+	}
+	if len(w.Snapshot()) != 0 {
+		t.Errorf("Expected the removed reservation to be dropped, got %d", len(w.Snapshot()))
+	}
+	if changes := poll(t, w); len(changes) != 0 {
+		t.Errorf("Expected no further changes, got %+v", changes)
+	}
+}
+
+func TestWatcher_ChannelAndEmitInitial(t *testing.T) {
+	srv := newServer(t)
+	ch := make(chan watch.ReservationChange, 10)
+
+	w, err := watch.NewWatcher(srv.Client(), watch.WatcherOptions{Changes: ch, EmitInitial: true})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	poll(t, w)
+
+	if len(ch) != 1 {
+		t.Fatalf("Expected 1 change on the channel, got %d", len(ch))
+	}
+	if change := <-ch; change.Type != watch.Created || change.Reservation.ReservationCode != "HMABC123" {
+		t.Errorf("Expected HMABC123 to be reported as created, got %+v", change)
+	}
+}
+
+func TestWatcher_FailedCallbackRetries(t *testing.T) {
+	srv := newServer(t)
+	c := &collector{}
+
+	w, err := watch.NewWatcher(srv.Client(), watch.WatcherOptions{OnChange: c.onChange})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	poll(t, w)
+	srv.UpdateReservation("HMABC123", func(r *hostex.Reservation) { r.Status = hostex.ReservationStatusCancelled })
+
+	c.fail = errors.New("downstream unavailable")
+	if _, err := w.Poll(context.Background()); !errors.Is(err, c.fail) {
+		t.Fatalf("Expected callback error, got %v", err)
+	}
+
+	c.fail = nil
+	changes := poll(t, w)
+	if len(changes) != 1 || changes[0].Type != watch.Cancelled {
+		t.Errorf("Expected the cancellation to be reported again, got %+v", changes)
+	}
+}
+
+func TestWatcher_CheckInWindow(t *testing.T) {
+	srv := newServer(t)
+	now := time.Date(2024, 6, 25, 12, 0, 0, 0, time.UTC)
+
+	w, err := watch.NewWatcher(srv.Client(), watch.WatcherOptions{
+		CheckInWindow: &watch.Window{Before: 1, After: 30},
+		OnChange:      (&collector{}).onChange,
+		Now:           func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	poll(t, w)
+
+	req := srv.RequestsTo("GET /reservations")
+	if len(req) != 1 {
+		t.Fatalf("Expected 1 list request, got %d", len(req))
+	}
+	q := req[0].Query
+	if q.Get("start_check_in_date") != "2024-06-24" || q.Get("end_check_in_date") != "2024-07-25" {
+		t.Errorf("Unexpected check-in window: %v", q)
+	}
+	if len(w.Snapshot()) != 1 {
+		t.Errorf("Expected the reservation in the window to be recorded, got %d", len(w.Snapshot()))
+	}
+
+	// Reservations that fall out of the window are forgotten
+	now = now.AddDate(0, 1, 0)
+	poll(t, w)
+	if len(w.Snapshot()) != 0 {
+		t.Errorf("Expected past reservations to be dropped, got %d", len(w.Snapshot()))
+	}
+}
+
+func TestDiffReservations(t *testing.T) {
+	old := hostex.Reservation{
+		Tags:         []string{"vip", "repeat"},
+		CustomFields: map[string]any{"door_code": "1234", "parking": true},
+	}
+	same := hostex.Reservation{
+		Tags:         []string{"repeat", "vip"},
+		CustomFields: map[string]any{"parking": true, "door_code": "1234"},
+	}
+	if diff := watch.DiffReservations(old, same); len(diff) != 0 {
+		t.Errorf("Expected tag order and key order to be ignored, got %v", diff)
+	}
+
+	changed := hostex.Reservation{
+		Tags:         []string{"vip"},
+		CustomFields: map[string]any{"door_code": "9876", "notes": "late arrival"},
+	}
+	diff := watch.DiffReservations(old, changed)
+	if got := fieldNames(diff); got != "tags,custom_fields.door_code,custom_fields.notes,custom_fields.parking" {
+		t.Errorf("Unexpected diff: %v", diff)
+	}
+	if diff[1].String() != "custom_fields.door_code: 1234 -> 9876" {
+		t.Errorf("Unexpected formatting: %s", diff[1])
+	}
+}
@@ -0,0 +1,94 @@
+// Package watch detects changes in a Hostex account by polling the API. It
+// suits accounts that cannot receive webhooks and serves as a safety net for
+// missed deliveries.
+//
+// A Watcher pages through reservations on every poll, compares them with the
+// snapshot from the previous poll and reports what was created, updated,
+// cancelled or removed, field by field:
+//
+//	w, err := watch.NewWatcher(client, watch.WatcherOptions{
+//		CheckInWindow: &watch.Window{Before: 7, After: 180},
+//		StatePath:     "/var/lib/myapp/reservations.json",
+//		OnChange: func(ctx context.Context, c watch.ReservationChange) error {
+//			log.Printf("%s %s: %v", c.Type, c.Reservation.ReservationCode, c.Fields)
+//			return nil
+//		},
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	go w.Run(ctx)
+//
+// Snapshots are persisted to StatePath after every poll so a restart picks up
+// where it left off instead of reporting every reservation again. The state
+// file holds reservation codes, statuses and dates; other fields are stored
+// as digests so that no guest details are written to disk.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrDeferred is wrapped by OnChange and OnMessage errors that put an event
+// off rather than fail it. The poll still stops and the event is reported
+// again, but Run logs the poll at debug level instead of as an error.
+var ErrDeferred = errors.New("watch: deferred")
+
+// ChangeType is the kind of a detected change
+type ChangeType string
+
+// Change types
+const (
+	Created   ChangeType = "created"
+	Updated   ChangeType = "updated"
+	Cancelled ChangeType = "cancelled"
+
+	// Removed is reported for a reservation that no longer appears in a
+	// poll, e.g. because it was deleted or no longer matches the filters
+	Removed ChangeType = "removed"
+)
+
+// FieldChange is a change to one field. Field is the JSON name of the field,
+// e.g. "check_in_date" or "custom_fields.door_code".
+type FieldChange struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// String formats the change as "field: old -> new"
+func (c FieldChange) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Field, c.Old, c.New)
+}
+
+// DefaultInterval is the default time between polls
+const DefaultInterval = 5 * time.Minute
+
+// deferred reports whether every error joined in err is ErrDeferred
+func deferred(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if !deferred(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return errors.Is(err, ErrDeferred)
+}
+
+// logPoll logs the error of a poll in Run: deferred polls at debug level and
+// failed ones as errors
+func logPoll(ctx context.Context, logger *slog.Logger, kind string, err error) {
+	switch {
+	case err == nil || logger == nil:
+	case deferred(err):
+		logger.DebugContext(ctx, "hostex "+kind+" poll deferred", slog.String("reason", err.Error()))
+	default:
+		logger.ErrorContext(ctx, "hostex "+kind+" poll failed", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,423 @@
+package webhook
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Default DedupOptions values
+const (
+	DefaultDedupCapacity     = 10000
+	DefaultDedupTTL          = 24 * time.Hour
+	DefaultDedupClaimTimeout = 5 * time.Minute
+)
+
+// ClaimResult is the outcome of DedupStore.Claim
+type ClaimResult int
+
+const (
+	// Claimed means the caller owns the event and must call Complete or
+	// Release once it has been handled
+	Claimed ClaimResult = iota
+
+	// InProgress means another caller holds an unexpired claim on the event
+	InProgress
+
+	// Completed means the event has already been processed
+	Completed
+)
+
+// String returns the name of the claim result
+func (r ClaimResult) String() string {
+	switch r {
+	case Claimed:
+		return "claimed"
+	case InProgress:
+		return "in_progress"
+	case Completed:
+		return "completed"
+	default:
+		return fmt.Sprintf("ClaimResult(%d)", int(r))
+	}
+}
+
+// DedupStore records which events have been processed so that redelivered
+// events are handled at most once. Implementations must be safe for
+// concurrent use.
+type DedupStore interface {
+	// Claim reserves key for processing. Only one caller at a time gets
+	// Claimed for a key; a claim that is neither completed nor released
+	// expires so that a crashed handler does not block the event forever.
+	Claim(ctx context.Context, key string) (ClaimResult, error)
+
+	// Complete marks a claimed key as processed
+	Complete(ctx context.Context, key string) error
+
+	// Release gives up a claim without marking the key processed, so the
+	// next delivery is handled again
+	Release(ctx context.Context, key string) error
+}
+
+// EventKey returns the deduplication key of a delivery: the event ID, or a
+// hash of the body for events without one
+func EventKey(event Event, body []byte) string {
+	if id := event.Meta().ID; id != "" {
+		return id
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// DedupOptions configures the built-in dedup stores
+type DedupOptions struct {
+	// Capacity is the maximum number of keys kept; the least recently used
+	// key is evicted first (optional, defaults to DefaultDedupCapacity)
+	Capacity int
+
+	// TTL is how long a processed key is remembered (optional, defaults to
+	// DefaultDedupTTL)
+	TTL time.Duration
+
+	// ClaimTimeout is how long a claim lasts without being completed or
+	// released (optional, defaults to DefaultDedupClaimTimeout)
+	ClaimTimeout time.Duration
+
+	// Now returns the current time (optional, defaults to time.Now)
+	Now func() time.Time
+}
+
+// withDefaults fills in unset options
+func (o DedupOptions) withDefaults() DedupOptions {
+	if o.Capacity <= 0 {
+		o.Capacity = DefaultDedupCapacity
+	}
+	if o.TTL <= 0 {
+		o.TTL = DefaultDedupTTL
+	}
+	if o.ClaimTimeout <= 0 {
+		o.ClaimTimeout = DefaultDedupClaimTimeout
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+// dedupEntry is a key tracked by a memory store
+type dedupEntry struct {
+	key     string
+	done    bool
+	expires time.Time
+}
+
+// MemoryStore is an in-memory DedupStore with LRU eviction and expiry. Keys
+// are lost when the process exits.
+type MemoryStore struct {
+	opts DedupOptions
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+var _ DedupStore = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore(opts DedupOptions) *MemoryStore {
+	return &MemoryStore{
+		opts:    opts.withDefaults(),
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Claim implements DedupStore
+func (s *MemoryStore) Claim(ctx context.Context, key string) (ClaimResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.claim(key), nil
+}
+
+// Complete implements DedupStore
+func (s *MemoryStore) Complete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.complete(key, s.opts.Now().Add(s.opts.TTL))
+	return nil
+}
+
+// Release implements DedupStore
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.release(key)
+	return nil
+}
+
+// Len returns the number of keys tracked, including expired keys that have
+// not been evicted yet
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// claim implements Claim; s.mu must be held
+func (s *MemoryStore) claim(key string) ClaimResult {
+	now := s.opts.Now()
+	if el, ok := s.entries[key]; ok {
+		entry := el.Value.(*dedupEntry)
+		if now.Before(entry.expires) {
+			s.lru.MoveToFront(el)
+			if entry.done {
+				return Completed
+			}
+			return InProgress
+		}
+		s.remove(el)
+	}
+
+	s.put(&dedupEntry{key: key, expires: now.Add(s.opts.ClaimTimeout)})
+	return Claimed
+}
+
+// complete marks key processed until expires; s.mu must be held
+func (s *MemoryStore) complete(key string, expires time.Time) {
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	s.put(&dedupEntry{key: key, done: true, expires: expires})
+}
+
+// release drops an unfinished claim on key; s.mu must be held
+func (s *MemoryStore) release(key string) {
+	if el, ok := s.entries[key]; ok && !el.Value.(*dedupEntry).done {
+		s.remove(el)
+	}
+}
+
+// put adds an entry, evicting the least recently used ones over capacity
+func (s *MemoryStore) put(entry *dedupEntry) {
+	s.entries[entry.key] = s.lru.PushFront(entry)
+	for s.lru.Len() > s.opts.Capacity {
+		s.remove(s.lru.Back())
+	}
+}
+
+// remove drops an entry
+func (s *MemoryStore) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.entries, el.Value.(*dedupEntry).key)
+}
+
+// completed returns the unexpired processed entries, oldest first
+func (s *MemoryStore) completed() []dedupEntry {
+	now := s.opts.Now()
+	var entries []dedupEntry
+	for el := s.lru.Back(); el != nil; el = el.Prev() {
+		entry := el.Value.(*dedupEntry)
+		if entry.done && now.Before(entry.expires) {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
+
+// fileRecord is a line of a FileStore file
+type fileRecord struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+}
+
+// FileStore is a DedupStore that persists processed keys to a file so they
+// survive restarts. Claims are held in memory, so a FileStore must not be
+// shared by several processes.
+//
+// Completed keys are appended to the file as JSON lines and synced before
+// Complete returns. The file is compacted when it is opened and whenever it
+// has grown to twice the store's capacity.
+type FileStore struct {
+	path string
+	mem  *MemoryStore
+
+	mu      sync.Mutex
+	file    *os.File
+	written int
+}
+
+var _ DedupStore = (*FileStore)(nil)
+
+// NewFileStore opens the store at path, creating the file and its directory
+// if needed
+func NewFileStore(path string, opts DedupOptions) (*FileStore, error) {
+	s := &FileStore{path: path, mem: NewMemoryStore(opts)}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Claim implements DedupStore
+func (s *FileStore) Claim(ctx context.Context, key string) (ClaimResult, error) {
+	return s.mem.Claim(ctx, key)
+}
+
+// Complete implements DedupStore
+func (s *FileStore) Complete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("webhook: dedup store %s is closed", s.path)
+	}
+
+	record := fileRecord{Key: key, Expires: s.mem.opts.Now().Add(s.mem.opts.TTL)}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("webhook: failed to encode dedup record: %w", err)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("webhook: failed to write dedup record: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("webhook: failed to sync dedup store: %w", err)
+	}
+
+	s.mem.mu.Lock()
+	s.mem.complete(key, record.Expires)
+	s.mem.mu.Unlock()
+
+	s.written++
+	if s.written >= 2*s.mem.opts.Capacity {
+		return s.compact()
+	}
+	return nil
+}
+
+// Release implements DedupStore
+func (s *FileStore) Release(ctx context.Context, key string) error {
+	return s.mem.Release(ctx, key)
+}
+
+// Close closes the underlying file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// load reads the processed keys from the file
+func (s *FileStore) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("webhook: failed to open dedup store: %w", err)
+	}
+	defer f.Close()
+
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+
+	now := s.mem.opts.Now()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record fileRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A torn final line from a crash mid-write is skipped
+			continue
+		}
+		if record.Key != "" && now.Before(record.Expires) {
+			s.mem.complete(record.Key, record.Expires)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("webhook: failed to read dedup store: %w", err)
+	}
+	return nil
+}
+
+// compact rewrites the file with the unexpired processed keys and reopens it
+// for appending
+func (s *FileStore) compact() error {
+	s.mem.mu.Lock()
+	entries := s.mem.completed()
+	s.mem.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("webhook: failed to create dedup store directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("webhook: failed to compact dedup store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(fileRecord{Key: entry.key, Expires: entry.expires}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("webhook: failed to compact dedup store: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("webhook: failed to compact dedup store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("webhook: failed to compact dedup store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("webhook: failed to compact dedup store: %w", err)
+	}
+
+	// The file is closed first so the rename also works where open files
+	// cannot be replaced
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		// Keep appending to the uncompacted file
+		return errors.Join(fmt.Errorf("webhook: failed to compact dedup store: %w", err), s.open())
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	s.written = len(entries)
+	return nil
+}
+
+// open opens the file for appending, creating it if needed
+func (s *FileStore) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("webhook: failed to open dedup store: %w", err)
+	}
+	s.file = f
+	return nil
+}
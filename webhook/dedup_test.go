@@ -0,0 +1,194 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go/webhook"
+)
+
+// fakeClock is a manually advanced clock for DedupOptions.Now
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func claim(t *testing.T, store webhook.DedupStore, key string, want webhook.ClaimResult) {
+	t.Helper()
+	got, err := store.Claim(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Claim(%s) failed: %v", key, err)
+	}
+	if got != want {
+		t.Errorf("Claim(%s) = %v, want %v", key, got, want)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	store := webhook.NewMemoryStore(webhook.DedupOptions{
+		Capacity:     2,
+		TTL:          time.Hour,
+		ClaimTimeout: time.Minute,
+		Now:          clock.Now,
+	})
+
+	claim(t, store, "evt-1", webhook.Claimed)
+	claim(t, store, "evt-1", webhook.InProgress)
+
+	// A released claim can be taken again
+	store.Release(ctx, "evt-1")
+	claim(t, store, "evt-1", webhook.Claimed)
+	store.Complete(ctx, "evt-1")
+	claim(t, store, "evt-1", webhook.Completed)
+
+	// Release does not forget processed keys
+	store.Release(ctx, "evt-1")
+	claim(t, store, "evt-1", webhook.Completed)
+
+	// Abandoned claims expire
+	claim(t, store, "evt-2", webhook.Claimed)
+	clock.Advance(2 * time.Minute)
+	claim(t, store, "evt-2", webhook.Claimed)
+
+	// Processed keys expire after the TTL
+	clock.Advance(time.Hour)
+	claim(t, store, "evt-1", webhook.Claimed)
+
+	// The least recently used key is evicted over capacity
+	store.Complete(ctx, "evt-1")
+	store.Complete(ctx, "evt-2")
+	claim(t, store, "evt-1", webhook.Completed)
+	claim(t, store, "evt-3", webhook.Claimed)
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 keys, got %d", store.Len())
+	}
+	claim(t, store, "evt-2", webhook.Claimed)
+}
+
+func TestFileStore_Persists(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	path := filepath.Join(t.TempDir(), "state", "dedup.jsonl")
+	opts := webhook.DedupOptions{TTL: time.Hour, Now: clock.Now}
+
+	store, err := webhook.NewFileStore(path, opts)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	claim(t, store, "evt-1", webhook.Claimed)
+	if err := store.Complete(ctx, "evt-1"); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	claim(t, store, "evt-2", webhook.Claimed)
+	store.Close()
+
+	reopened, err := webhook.NewFileStore(path, opts)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	defer reopened.Close()
+
+	claim(t, reopened, "evt-1", webhook.Completed)
+	// Claims are not persisted
+	claim(t, reopened, "evt-2", webhook.Claimed)
+
+	clock.Advance(2 * time.Hour)
+	expired, err := webhook.NewFileStore(path, opts)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	defer expired.Close()
+	claim(t, expired, "evt-1", webhook.Claimed)
+}
+
+func TestHandler_Dedup(t *testing.T) {
+	h := webhook.NewHandler(webhook.Options{Dedup: webhook.NewMemoryStore(webhook.DedupOptions{})})
+
+	var calls atomic.Int32
+	fail := true
+	h.OnReservationCreated(func(ctx context.Context, e *webhook.ReservationEvent) error {
+		calls.Add(1)
+		if fail {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	// A failed delivery is processed again when redelivered
+	if rec := deliver(h, http.MethodPost, reservationCreated); rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", rec.Code)
+	}
+	fail = false
+	for range 3 {
+		if rec := deliver(h, http.MethodPost, reservationCreated); rec.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d", rec.Code)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected handler to run twice, ran %d times", calls.Load())
+	}
+}
+
+func TestHandler_DedupConcurrent(t *testing.T) {
+	h := webhook.NewHandler(webhook.Options{Dedup: webhook.NewMemoryStore(webhook.DedupOptions{})})
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	h.OnMessageReceived(func(ctx context.Context, e *webhook.MessageEvent) error {
+		calls.Add(1)
+		<-release
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 10)
+	for range cap(codes) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- deliver(h, http.MethodPost, messageReceived).Code
+		}()
+	}
+
+	// Concurrent duplicates are turned away while the first is in progress
+	unavailable := 0
+	for range cap(codes) - 1 {
+		if <-codes == http.StatusServiceUnavailable {
+			unavailable++
+		}
+	}
+	close(release)
+	wg.Wait()
+	close(codes)
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", calls.Load())
+	}
+	if unavailable != cap(codes)-1 || <-codes != http.StatusOK {
+		t.Errorf("Expected one 200 and %d 503 responses, got %d 503s", cap(codes)-1, unavailable)
+	}
+}
@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/keithah/hostex-go"
+)
+
+// EventType identifies the kind of a webhook event
+type EventType string
+
+// Known event types
+const (
+	EventReservationCreated   EventType = "reservation_created"
+	EventReservationUpdated   EventType = "reservation_updated"
+	EventReservationCancelled EventType = "reservation_cancelled"
+	EventMessageReceived      EventType = "message_received"
+	EventReviewPosted         EventType = "review_posted"
+)
+
+// ErrInvalidPayload is returned by Parse for bodies that are not a webhook event
+var ErrInvalidPayload = errors.New("webhook: invalid payload")
+
+// Event is a decoded webhook event: *ReservationEvent, *MessageEvent,
+// *ReviewEvent or *UnknownEvent
+type Event interface {
+	// Type returns the event type
+	Type() EventType
+
+	// Meta returns the fields shared by all events
+	Meta() Metadata
+}
+
+// Metadata holds the fields shared by all events
+type Metadata struct {
+	// ID uniquely identifies the delivery and is stable across retries
+	ID string `json:"id"`
+
+	// Event is the event type
+	Event EventType `json:"event"`
+
+	// Timestamp is when the event occurred
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Type returns the event type
+func (m Metadata) Type() EventType {
+	return m.Event
+}
+
+// Meta returns m
+func (m Metadata) Meta() Metadata {
+	return m
+}
+
+// setMetadata replaces m; it lets Parse restore the envelope fields after
+// decoding the event data
+func (m *Metadata) setMetadata(v Metadata) {
+	*m = v
+}
+
+// ReservationEvent is sent when a reservation is created, updated or cancelled
+type ReservationEvent struct {
+	Metadata
+	Reservation hostex.Reservation `json:"reservation"`
+}
+
+// MessageEvent is sent when a guest message arrives in a conversation
+type MessageEvent struct {
+	Metadata
+	ConversationID string         `json:"conversation_id"`
+	Message        hostex.Message `json:"message"`
+}
+
+// ReviewEvent is sent when a guest posts a review
+type ReviewEvent struct {
+	Metadata
+	Review hostex.Review `json:"review"`
+}
+
+// UnknownEvent is an event of a type this package does not know. Data holds
+// the raw event data.
+type UnknownEvent struct {
+	Metadata
+	Data json.RawMessage `json:"data"`
+}
+
+// envelope is the JSON structure of a webhook delivery
+type envelope struct {
+	Metadata
+	Data json.RawMessage `json:"data"`
+}
+
+// Parse decodes a webhook request body into a typed event
+func Parse(body []byte) (Event, error) {
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if env.Event == "" {
+		return nil, fmt.Errorf("%w: missing event type", ErrInvalidPayload)
+	}
+
+	var event Event
+	switch env.Event {
+	case EventReservationCreated, EventReservationUpdated, EventReservationCancelled:
+		event = &ReservationEvent{Metadata: env.Metadata}
+	case EventMessageReceived:
+		event = &MessageEvent{Metadata: env.Metadata}
+	case EventReviewPosted:
+		event = &ReviewEvent{Metadata: env.Metadata}
+	default:
+		return &UnknownEvent{Metadata: env.Metadata, Data: env.Data}, nil
+	}
+
+	if len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, event); err != nil {
+			return nil, fmt.Errorf("%w: %s data: %v", ErrInvalidPayload, env.Event, err)
+		}
+		event.(interface{ setMetadata(Metadata) }).setMetadata(env.Metadata)
+	}
+	return event, nil
+}
+
+// Marshal encodes an event as a webhook delivery body; Parse decodes it back
+func Marshal(event Event) ([]byte, error) {
+	data, err := eventData(event)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to encode %s event: %w", event.Type(), err)
+	}
+	return json.Marshal(envelope{Metadata: event.Meta(), Data: data})
+}
+
+// eventData encodes the data of an event without its metadata
+func eventData(event Event) (json.RawMessage, error) {
+	if u, ok := event.(*UnknownEvent); ok {
+		return u.Data, nil
+	}
+
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "id")
+	delete(fields, "event")
+	delete(fields, "timestamp")
+	return json.Marshal(fields)
+}
@@ -0,0 +1,292 @@
+// Package webhook receives Hostex webhook deliveries. Handler is an
+// http.Handler that decodes each delivery into a typed event and dispatches
+// it to the functions registered for its event type.
+//
+//	h := webhook.NewHandler(webhook.Options{})
+//	h.OnReservationCreated(func(ctx context.Context, e *webhook.ReservationEvent) error {
+//		return notifyCleaners(ctx, e.Reservation)
+//	})
+//	h.OnMessageReceived(func(ctx context.Context, e *webhook.MessageEvent) error {
+//		return triage(ctx, e.ConversationID, e.Message)
+//	})
+//	http.Handle("/hostex/webhook", h)
+//
+// A delivery is acknowledged with 200 OK once every handler has succeeded.
+// If a handler fails the response is 500 so that Hostex delivers the event
+// again; malformed deliveries are rejected with 400.
+//
+// Hostex may deliver an event more than once. Set Options.Dedup to a
+// DedupStore such as NewMemoryStore or NewFileStore to process each event at
+// most once.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// DefaultMaxBodyBytes is the default limit on the size of a delivery
+const DefaultMaxBodyBytes = 1 << 20
+
+// HandlerFunc handles an event. Returning an error makes the delivery fail
+// so that it is retried.
+type HandlerFunc func(ctx context.Context, event Event) error
+
+// Delivery is a webhook request as Hostex sent it
+type Delivery struct {
+	// Body is the raw request body
+	Body []byte
+
+	// Header holds the request headers
+	Header http.Header
+}
+
+// deliveryKey is the context key of the Delivery being handled
+type deliveryKey struct{}
+
+// withDelivery returns a context carrying d
+func withDelivery(ctx context.Context, d *Delivery) context.Context {
+	return context.WithValue(ctx, deliveryKey{}, d)
+}
+
+// DeliveryFromContext returns the raw delivery of the event being handled.
+// It is set for handlers run by Handler.ServeHTTP and for sinks run by a
+// Queue.
+func DeliveryFromContext(ctx context.Context) (*Delivery, bool) {
+	d, ok := ctx.Value(deliveryKey{}).(*Delivery)
+	return d, ok
+}
+
+// rawDelivery returns the raw delivery of event from ctx, if ctx carries
+// the delivery the event was parsed from
+func rawDelivery(ctx context.Context, event Event) (*Delivery, bool) {
+	d, ok := DeliveryFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	var env envelope
+	if err := json.Unmarshal(d.Body, &env); err != nil {
+		return nil, false
+	}
+	meta := event.Meta()
+	if env.ID != meta.ID || env.Event != meta.Event || !env.Timestamp.Equal(meta.Timestamp) {
+		return nil, false
+	}
+	return d, true
+}
+
+// Options configures a Handler
+type Options struct {
+	// MaxBodyBytes limits the size of a delivery (optional, defaults to
+	// DefaultMaxBodyBytes)
+	MaxBodyBytes int64
+
+	// Logger receives a record for every rejected or failed delivery
+	// (optional)
+	Logger *slog.Logger
+
+	// Dedup, when set, makes sure each event is processed at most once even
+	// if Hostex delivers it several times. Events are keyed by EventKey.
+	Dedup DedupStore
+}
+
+// Handler is an http.Handler for Hostex webhook deliveries. Handlers can be
+// registered while it is serving.
+type Handler struct {
+	maxBodyBytes int64
+	logger       *slog.Logger
+	dedup        DedupStore
+
+	mu        sync.RWMutex
+	handlers  map[EventType][]HandlerFunc
+	unhandled HandlerFunc
+}
+
+// NewHandler creates a Handler with no event handlers registered
+func NewHandler(opts Options) *Handler {
+	maxBodyBytes := opts.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	return &Handler{
+		maxBodyBytes: maxBodyBytes,
+		logger:       opts.Logger,
+		dedup:        opts.Dedup,
+		handlers:     make(map[EventType][]HandlerFunc),
+	}
+}
+
+// On registers fn for events of type t. Handlers for the same type run in
+// the order they were registered.
+func (h *Handler) On(t EventType, fn HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[t] = append(h.handlers[t], fn)
+}
+
+// OnUnhandled registers fn for events that have no handler registered,
+// including event types this package does not know. Without it such events
+// are acknowledged and dropped.
+func (h *Handler) OnUnhandled(fn HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unhandled = fn
+}
+
+// OnReservationCreated registers fn for new reservations
+func (h *Handler) OnReservationCreated(fn func(ctx context.Context, e *ReservationEvent) error) {
+	h.On(EventReservationCreated, reservationHandler(fn))
+}
+
+// OnReservationUpdated registers fn for changed reservations
+func (h *Handler) OnReservationUpdated(fn func(ctx context.Context, e *ReservationEvent) error) {
+	h.On(EventReservationUpdated, reservationHandler(fn))
+}
+
+// OnReservationCancelled registers fn for cancelled reservations
+func (h *Handler) OnReservationCancelled(fn func(ctx context.Context, e *ReservationEvent) error) {
+	h.On(EventReservationCancelled, reservationHandler(fn))
+}
+
+// OnMessageReceived registers fn for new guest messages
+func (h *Handler) OnMessageReceived(fn func(ctx context.Context, e *MessageEvent) error) {
+	h.On(EventMessageReceived, func(ctx context.Context, event Event) error {
+		return fn(ctx, event.(*MessageEvent))
+	})
+}
+
+// OnReviewPosted registers fn for new guest reviews
+func (h *Handler) OnReviewPosted(fn func(ctx context.Context, e *ReviewEvent) error) {
+	h.On(EventReviewPosted, func(ctx context.Context, event Event) error {
+		return fn(ctx, event.(*ReviewEvent))
+	})
+}
+
+// reservationHandler adapts a typed reservation handler
+func reservationHandler(fn func(ctx context.Context, e *ReservationEvent) error) HandlerFunc {
+	return func(ctx context.Context, event Event) error {
+		return fn(ctx, event.(*ReservationEvent))
+	}
+}
+
+// Dispatch runs the handlers registered for the event's type. Every handler
+// runs even if an earlier one fails; the errors are joined.
+func (h *Handler) Dispatch(ctx context.Context, event Event) error {
+	h.mu.RLock()
+	handlers := h.handlers[event.Type()]
+	unhandled := h.unhandled
+	h.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		if unhandled == nil {
+			return nil
+		}
+		return unhandled(ctx, event)
+	}
+
+	var errs []error
+	for _, fn := range handlers {
+		if err := fn(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.reject(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		h.reject(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	event, err := Parse(body)
+	if err != nil {
+		h.reject(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := withDelivery(r.Context(), &Delivery{Body: body, Header: r.Header.Clone()})
+	status, err := h.process(ctx, event, EventKey(event, body))
+	if err != nil {
+		if h.logger != nil {
+			h.logger.ErrorContext(r.Context(), "hostex webhook handler failed",
+				slog.String("event", string(event.Type())),
+				slog.String("event_id", event.Meta().ID),
+				slog.String("error", err.Error()))
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.WriteHeader(status)
+}
+
+// process dispatches an event, claiming it in the dedup store first. It
+// returns the response status for the delivery.
+func (h *Handler) process(ctx context.Context, event Event, key string) (int, error) {
+	if h.dedup == nil {
+		if err := h.Dispatch(ctx, event); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusOK, nil
+	}
+
+	claim, err := h.dedup.Claim(ctx, key)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to claim event: %w", err)
+	}
+	switch claim {
+	case Completed:
+		return http.StatusOK, nil
+	case InProgress:
+		// Another delivery is being processed; ask Hostex to retry in case
+		// it fails
+		return http.StatusServiceUnavailable, fmt.Errorf("event %s is already being processed", key)
+	}
+
+	if err := h.Dispatch(ctx, event); err != nil {
+		if releaseErr := h.dedup.Release(ctx, key); releaseErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to release event: %w", releaseErr))
+		}
+		return http.StatusInternalServerError, err
+	}
+
+	// The handlers have run, so the delivery is acknowledged even if the
+	// store cannot record it
+	if err := h.dedup.Complete(ctx, key); err != nil && h.logger != nil {
+		h.logger.ErrorContext(ctx, "hostex webhook dedup store failed",
+			slog.String("event_id", key),
+			slog.String("error", err.Error()))
+	}
+	return http.StatusOK, nil
+}
+
+// reject responds with a client error and logs it
+func (h *Handler) reject(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if h.logger != nil {
+		h.logger.WarnContext(r.Context(), "hostex webhook rejected",
+			slog.Int("status", status),
+			slog.String("error", err.Error()))
+	}
+	http.Error(w, http.StatusText(status), status)
+}
@@ -0,0 +1,147 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/webhook"
+)
+
+const reservationCreated = `{
+	"id": "evt-1",
+	"event": "reservation_created",
+	"timestamp": "2024-05-01T09:30:00Z",
+	"data": {
+		"reservation": {
+			"reservation_code": "HMABC123",
+			"property_id": 1001,
+			"channel_type": "airbnb",
+			"check_in_date": "2024-07-01",
+			"check_out_date": "2024-07-05",
+			"status": "accepted"
+		}
+	}
+}`
+
+const messageReceived = `{
+	"id": "evt-2",
+	"event": "message_received",
+	"timestamp": "2024-05-01T10:00:00Z",
+	"data": {
+		"conversation_id": "conv-1",
+		"message": {"id": "msg-9", "sender_role": "guest", "content": "Is parking available?", "created_at": "2024-05-01T10:00:00Z"}
+	}
+}`
+
+func deliver(h http.Handler, method, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "/webhook", strings.NewReader(body)))
+	return rec
+}
+
+func TestParse(t *testing.T) {
+	event, err := webhook.Parse([]byte(reservationCreated))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	e, ok := event.(*webhook.ReservationEvent)
+	if !ok {
+		t.Fatalf("Expected *ReservationEvent, got %T", event)
+	}
+	if e.ID != "evt-1" || e.Type() != webhook.EventReservationCreated {
+		t.Errorf("Unexpected metadata: %+v", e.Meta())
+	}
+	if e.Reservation.ReservationCode != "HMABC123" || e.Reservation.CheckInDate != hostex.MustParseDate("2024-07-01") {
+		t.Errorf("Unexpected reservation: %+v", e.Reservation)
+	}
+
+	unknown, err := webhook.Parse([]byte(`{"id":"evt-3","event":"listing_calendar_updated","data":{"listing_id":"1"}}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if u, ok := unknown.(*webhook.UnknownEvent); !ok || string(u.Data) != `{"listing_id":"1"}` {
+		t.Errorf("Expected UnknownEvent with raw data, got %#v", unknown)
+	}
+
+	for _, invalid := range []string{`not json`, `{"id":"evt-4"}`, `{"event":"reservation_updated","data":{"reservation":"oops"}}`} {
+		if _, err := webhook.Parse([]byte(invalid)); !errors.Is(err, webhook.ErrInvalidPayload) {
+			t.Errorf("Expected ErrInvalidPayload for %s, got %v", invalid, err)
+		}
+	}
+}
+
+func TestHandler_Dispatch(t *testing.T) {
+	h := webhook.NewHandler(webhook.Options{})
+
+	var created []string
+	var messages []string
+	h.OnReservationCreated(func(ctx context.Context, e *webhook.ReservationEvent) error {
+		created = append(created, e.Reservation.ReservationCode)
+		return nil
+	})
+	h.OnMessageReceived(func(ctx context.Context, e *webhook.MessageEvent) error {
+		messages = append(messages, e.ConversationID+": "+e.Message.Content)
+		return nil
+	})
+
+	if rec := deliver(h, http.MethodPost, reservationCreated); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+	if rec := deliver(h, http.MethodPost, messageReceived); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+	if len(created) != 1 || created[0] != "HMABC123" {
+		t.Errorf("Unexpected reservations: %v", created)
+	}
+	if len(messages) != 1 || messages[0] != "conv-1: Is parking available?" {
+		t.Errorf("Unexpected messages: %v", messages)
+	}
+
+	// Events without a handler are acknowledged
+	if rec := deliver(h, http.MethodPost, `{"id":"evt-5","event":"review_posted","data":{}}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected unhandled event to be acknowledged, got %d", rec.Code)
+	}
+
+	var unhandled []webhook.EventType
+	h.OnUnhandled(func(ctx context.Context, e webhook.Event) error {
+		unhandled = append(unhandled, e.Type())
+		return nil
+	})
+	deliver(h, http.MethodPost, `{"id":"evt-6","event":"something_new","data":{}}`)
+	if len(unhandled) != 1 || unhandled[0] != "something_new" {
+		t.Errorf("Expected unknown event to reach OnUnhandled, got %v", unhandled)
+	}
+}
+
+func TestHandler_Responses(t *testing.T) {
+	h := webhook.NewHandler(webhook.Options{MaxBodyBytes: 64})
+	h.OnReservationCancelled(func(ctx context.Context, e *webhook.ReservationEvent) error {
+		return errors.New("database unavailable")
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"handler error", http.MethodPost, `{"id":"1","event":"reservation_cancelled"}`, http.StatusInternalServerError},
+		{"malformed", http.MethodPost, `{`, http.StatusBadRequest},
+		{"too large", http.MethodPost, `{"id":"1","event":"x","data":"` + strings.Repeat("a", 100) + `"}`, http.StatusRequestEntityTooLarge},
+		{"wrong method", http.MethodGet, ``, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := deliver(h, tt.method, tt.body); rec.Code != tt.status {
+				t.Errorf("Expected %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,659 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Default QueueOptions values
+const (
+	DefaultQueueMaxAttempts = 10
+	DefaultQueueBaseDelay   = time.Second
+	DefaultQueueMaxDelay    = 5 * time.Minute
+)
+
+// Files in QueueOptions.Dir
+const (
+	queueLogFile   = "queue.wal"
+	deadLetterFile = "dead-letter.jsonl"
+)
+
+// compactAfter is the number of records appended to the log after which it
+// is rewritten with only the pending deliveries
+const compactAfter = 1000
+
+// QueueOptions configures a Queue
+type QueueOptions struct {
+	// Dir holds the write-ahead log and the dead-letter file
+	Dir string
+
+	// Sinks receive every event, keyed by a name that identifies the sink
+	// in the log. Removing a sink dead-letters its pending deliveries on
+	// the next start.
+	Sinks map[string]Sink
+
+	// MaxAttempts is the number of deliveries to a sink before an event is
+	// dead-lettered (optional, defaults to DefaultQueueMaxAttempts)
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. Each further retry
+	// doubles it. (optional, defaults to DefaultQueueBaseDelay)
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between retries (optional, defaults to
+	// DefaultQueueMaxDelay)
+	MaxDelay time.Duration
+
+	// Logger receives a record for every failed delivery (optional)
+	Logger *slog.Logger
+}
+
+// DeadLetter is an event a sink could not take
+type DeadLetter struct {
+	// Sink is the name of the sink
+	Sink string `json:"sink"`
+
+	// Attempts is the number of deliveries made
+	Attempts int `json:"attempts"`
+
+	// Error is the error of the last delivery
+	Error string `json:"error"`
+
+	// FailedAt is when the event was given up on
+	FailedAt time.Time `json:"failed_at"`
+
+	// Event is the delivery body as Hostex sent it
+	Event json.RawMessage `json:"event"`
+
+	// Header holds the headers of the delivery, if the event was enqueued
+	// by a Handler
+	Header http.Header `json:"header,omitempty"`
+}
+
+// Decode parses the dead-lettered event
+func (d DeadLetter) Decode() (Event, error) {
+	return Parse(d.Event)
+}
+
+// walRecord is a line of the write-ahead log
+type walRecord struct {
+	Op       string      `json:"op"`
+	ID       uint64      `json:"id"`
+	Sinks    []string    `json:"sinks,omitempty"`
+	Body     []byte      `json:"body,omitempty"`
+	Header   http.Header `json:"header,omitempty"`
+	Sink     string      `json:"sink,omitempty"`
+	Attempts int         `json:"attempts,omitempty"`
+	Next     time.Time   `json:"next,omitzero"`
+}
+
+// Write-ahead log operations
+const (
+	opEnqueue = "enqueue"
+	opRetry   = "retry"
+	opAck     = "ack"
+	opDead    = "dead"
+)
+
+// delivery is a pending delivery of an event to one sink
+type delivery struct {
+	id       uint64
+	sink     string
+	body     []byte
+	header   http.Header
+	event    Event
+	attempts int
+	next     time.Time
+}
+
+// Queue persists events to a write-ahead log and forwards them to its sinks
+// in the background, retrying failed deliveries with exponential backoff.
+// Deliveries that keep failing are moved to a dead-letter file.
+//
+// Enqueue returns once the event is synced to disk, so using it as a
+// webhook handler acknowledges a delivery to Hostex only after the event is
+// safe. The delivery body and headers are stored as Hostex sent them and
+// passed on to the sinks:
+//
+//	q, err := webhook.NewQueue(webhook.QueueOptions{
+//		Dir:   "/var/lib/myapp/hostex-queue",
+//		Sinks: map[string]webhook.Sink{"crm": &webhook.HTTPSink{URL: crmURL}},
+//	})
+//	...
+//	h.OnUnhandled(q.Enqueue)
+//	go q.Run(ctx)
+//
+// Each sink has its own worker, so a failing sink does not hold up the
+// others. Delivery is at least once: a sink sees an event again if the
+// process stops between delivering it and logging the result, and retried
+// events can overtake later ones. Sinks must not modify events.
+type Queue struct {
+	dir         string
+	sinks       map[string]Sink
+	names       []string
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	logger      *slog.Logger
+	notify      map[string]chan struct{}
+
+	mu      sync.Mutex
+	wal     *os.File
+	dead    *os.File
+	seq     uint64
+	records int
+	base    int
+	pending map[string][]*delivery
+	running bool
+	closed  bool
+}
+
+// NewQueue opens the queue in opts.Dir, recovering the events that were
+// pending when it was last used
+func NewQueue(opts QueueOptions) (*Queue, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("webhook: queue directory is required")
+	}
+	if len(opts.Sinks) == 0 {
+		return nil, errors.New("webhook: queue needs at least one sink")
+	}
+
+	q := &Queue{
+		dir:         opts.Dir,
+		sinks:       opts.Sinks,
+		maxAttempts: opts.MaxAttempts,
+		baseDelay:   opts.BaseDelay,
+		maxDelay:    opts.MaxDelay,
+		logger:      opts.Logger,
+		notify:      make(map[string]chan struct{}),
+		pending:     make(map[string][]*delivery),
+	}
+	if q.maxAttempts <= 0 {
+		q.maxAttempts = DefaultQueueMaxAttempts
+	}
+	if q.baseDelay <= 0 {
+		q.baseDelay = DefaultQueueBaseDelay
+	}
+	if q.maxDelay <= 0 {
+		q.maxDelay = DefaultQueueMaxDelay
+	}
+	for name := range opts.Sinks {
+		q.names = append(q.names, name)
+		q.notify[name] = make(chan struct{}, 1)
+	}
+	slices.Sort(q.names)
+
+	if err := os.MkdirAll(q.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("webhook: failed to create queue directory: %w", err)
+	}
+	dead, err := os.OpenFile(filepath.Join(q.dir, deadLetterFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to open dead-letter file: %w", err)
+	}
+	q.dead = dead
+
+	orphans, err := q.recover()
+	if err != nil {
+		dead.Close()
+		return nil, err
+	}
+	if err := q.compact(); err != nil {
+		dead.Close()
+		return nil, err
+	}
+
+	// The compacted log no longer holds the deliveries for removed sinks,
+	// so they are dead-lettered at most once
+	for _, d := range orphans {
+		if err := q.deadLetter(d, "sink "+d.sink+" is no longer configured"); err != nil {
+			q.Close()
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// Enqueue persists an event for delivery to every sink. Its signature
+// matches HandlerFunc so it can be registered on a Handler directly. Events
+// enqueued outside a Handler are stored in the webhook delivery format.
+func (q *Queue) Enqueue(ctx context.Context, event Event) error {
+	var body []byte
+	var header http.Header
+	if d, ok := rawDelivery(ctx, event); ok {
+		body, header = d.Body, d.Header
+	} else {
+		var err error
+		if body, err = Marshal(event); err != nil {
+			return err
+		}
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return errors.New("webhook: queue is closed")
+	}
+	id := q.seq + 1
+	if err := q.append(walRecord{Op: opEnqueue, ID: id, Sinks: q.names, Body: body, Header: header}); err != nil {
+		q.mu.Unlock()
+		return err
+	}
+	q.seq = id
+	for _, name := range q.names {
+		q.pending[name] = append(q.pending[name], &delivery{id: id, sink: name, body: body, header: header, event: event})
+	}
+	q.mu.Unlock()
+
+	for _, name := range q.names {
+		q.wake(name)
+	}
+	return nil
+}
+
+// Run delivers events to the sinks until ctx is done, then waits for
+// in-flight deliveries to return and returns ctx.Err()
+func (q *Queue) Run(ctx context.Context) error {
+	q.mu.Lock()
+	if q.running || q.closed {
+		q.mu.Unlock()
+		return errors.New("webhook: queue is already running or closed")
+	}
+	q.running = true
+	q.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, name := range q.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, name)
+		}()
+	}
+	wg.Wait()
+
+	q.mu.Lock()
+	q.running = false
+	q.mu.Unlock()
+	return ctx.Err()
+}
+
+// Pending returns the number of deliveries not yet made, across all sinks
+func (q *Queue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pendingLocked()
+}
+
+// DeadLetters reads the dead-letter file
+func (q *Queue) DeadLetters() ([]DeadLetter, error) {
+	f, err := os.Open(filepath.Join(q.dir, deadLetterFile))
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to open dead-letter file: %w", err)
+	}
+	defer f.Close()
+
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var letter DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			return nil, fmt.Errorf("webhook: failed to decode dead letter: %w", err)
+		}
+		letters = append(letters, letter)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("webhook: failed to read dead-letter file: %w", err)
+	}
+	return letters, nil
+}
+
+// Close closes the queue's files. Call it after Run has returned.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	return errors.Join(q.wal.Close(), q.dead.Close())
+}
+
+// wake signals the worker of a sink that there may be work
+func (q *Queue) wake(name string) {
+	select {
+	case q.notify[name] <- struct{}{}:
+	default:
+	}
+}
+
+// work delivers events to one sink until ctx is done
+func (q *Queue) work(ctx context.Context, name string) {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		q.mu.Lock()
+		d, wait := q.nextDue(name)
+		q.mu.Unlock()
+
+		if d != nil {
+			q.deliver(ctx, d)
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer.Reset(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.notify[name]:
+		case <-timeout:
+		}
+		timer.Stop()
+	}
+}
+
+// nextDue returns the oldest delivery for a sink that is due, or how long
+// until the next one is. q.mu must be held.
+func (q *Queue) nextDue(name string) (*delivery, time.Duration) {
+	now := time.Now()
+	var wait time.Duration
+	for _, d := range q.pending[name] {
+		if !d.next.After(now) {
+			return d, 0
+		}
+		if until := d.next.Sub(now); wait == 0 || until < wait {
+			wait = until
+		}
+	}
+	return nil, wait
+}
+
+// deliver sends an event to a sink and logs the outcome
+func (q *Queue) deliver(ctx context.Context, d *delivery) {
+	err := q.sinks[d.sink].Send(withDelivery(ctx, &Delivery{Body: d.body, Header: d.header.Clone()}), d.event)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down; the delivery is retried on the next run
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err == nil {
+		q.finish(d, walRecord{Op: opAck, ID: d.id, Sink: d.sink})
+		return
+	}
+
+	d.attempts++
+	if IsPermanent(err) || d.attempts >= q.maxAttempts {
+		if q.logger != nil {
+			q.logger.ErrorContext(ctx, "hostex webhook event dead-lettered",
+				slog.String("sink", d.sink),
+				slog.Int("attempts", d.attempts),
+				slog.String("error", err.Error()))
+		}
+		if derr := q.deadLetter(d, err.Error()); derr != nil {
+			q.logError(ctx, derr)
+			// Keep the delivery so it is retried rather than lost
+			d.next = time.Now().Add(q.maxDelay)
+			return
+		}
+		q.finish(d, walRecord{Op: opDead, ID: d.id, Sink: d.sink})
+		return
+	}
+
+	d.next = time.Now().Add(q.backoff(d.attempts))
+	if q.logger != nil {
+		q.logger.WarnContext(ctx, "hostex webhook event delivery failed",
+			slog.String("sink", d.sink),
+			slog.Int("attempts", d.attempts),
+			slog.Duration("retry_in", time.Until(d.next)),
+			slog.String("error", err.Error()))
+	}
+	if werr := q.append(walRecord{Op: opRetry, ID: d.id, Sink: d.sink, Attempts: d.attempts, Next: d.next}); werr != nil {
+		q.logError(ctx, werr)
+	}
+}
+
+// finish logs the end of a delivery and drops it. q.mu must be held.
+func (q *Queue) finish(d *delivery, record walRecord) {
+	if err := q.append(record); err != nil {
+		// The delivery is repeated after a restart, which is allowed
+		q.logError(context.Background(), err)
+	}
+	q.pending[d.sink] = slices.DeleteFunc(q.pending[d.sink], func(p *delivery) bool { return p == d })
+
+	if q.records-q.base >= compactAfter {
+		if err := q.compact(); err != nil {
+			q.logError(context.Background(), err)
+		}
+	}
+}
+
+// pendingLocked counts pending deliveries. q.mu must be held.
+func (q *Queue) pendingLocked() int {
+	n := 0
+	for _, deliveries := range q.pending {
+		n += len(deliveries)
+	}
+	return n
+}
+
+// backoff returns the delay before the given retry (1 for the first retry)
+func (q *Queue) backoff(retry int) time.Duration {
+	d := q.baseDelay
+	for i := 1; i < retry && d < q.maxDelay; i++ {
+		d *= 2
+	}
+	return min(d, q.maxDelay)
+}
+
+// logError logs a failure to write the queue's files
+func (q *Queue) logError(ctx context.Context, err error) {
+	if q.logger != nil {
+		q.logger.ErrorContext(ctx, "hostex webhook queue error", slog.String("error", err.Error()))
+	}
+}
+
+// deadLetter appends a delivery to the dead-letter file. q.mu must be held.
+func (q *Queue) deadLetter(d *delivery, reason string) error {
+	line, err := json.Marshal(DeadLetter{
+		Sink:     d.sink,
+		Attempts: d.attempts,
+		Error:    reason,
+		FailedAt: time.Now(),
+		Event:    d.body,
+		Header:   d.header,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook: failed to encode dead letter: %w", err)
+	}
+	if _, err := q.dead.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("webhook: failed to write dead letter: %w", err)
+	}
+	if err := q.dead.Sync(); err != nil {
+		return fmt.Errorf("webhook: failed to sync dead-letter file: %w", err)
+	}
+	return nil
+}
+
+// append writes a record to the log and syncs it. q.mu must be held.
+func (q *Queue) append(record walRecord) error {
+	if q.wal == nil {
+		return errors.New("webhook: queue log is not open")
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("webhook: failed to encode queue record: %w", err)
+	}
+	if _, err := q.wal.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("webhook: failed to write queue log: %w", err)
+	}
+	if err := q.wal.Sync(); err != nil {
+		return fmt.Errorf("webhook: failed to sync queue log: %w", err)
+	}
+	q.records++
+	return nil
+}
+
+// recover replays the log into the pending deliveries. It returns the
+// deliveries for sinks that are no longer configured.
+func (q *Queue) recover() ([]*delivery, error) {
+	f, err := os.Open(filepath.Join(q.dir, queueLogFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to open queue log: %w", err)
+	}
+	defer f.Close()
+
+	byID := make(map[uint64]map[string]*delivery)
+	var order []uint64
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var record walRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A torn final line from a crash mid-write is skipped
+			continue
+		}
+		q.seq = max(q.seq, record.ID)
+
+		switch record.Op {
+		case opEnqueue:
+			event, err := Parse(record.Body)
+			if err != nil {
+				q.logError(context.Background(), fmt.Errorf("webhook: dropping unreadable queued event %d: %w", record.ID, err))
+				continue
+			}
+			deliveries := make(map[string]*delivery)
+			for _, name := range record.Sinks {
+				deliveries[name] = &delivery{id: record.ID, sink: name, body: record.Body, header: record.Header, event: event}
+			}
+			byID[record.ID] = deliveries
+			order = append(order, record.ID)
+		case opRetry:
+			if d := byID[record.ID][record.Sink]; d != nil {
+				d.attempts = record.Attempts
+				d.next = record.Next
+			}
+		case opAck, opDead:
+			delete(byID[record.ID], record.Sink)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("webhook: failed to read queue log: %w", err)
+	}
+
+	var orphans []*delivery
+	for _, id := range order {
+		for _, name := range slices.Sorted(maps.Keys(byID[id])) {
+			d := byID[id][name]
+			if _, ok := q.sinks[name]; !ok {
+				orphans = append(orphans, d)
+				continue
+			}
+			q.pending[name] = append(q.pending[name], d)
+		}
+	}
+	return orphans, nil
+}
+
+// compact rewrites the log with only the pending deliveries and reopens it
+// for appending. q.mu must be held or the queue not yet shared.
+func (q *Queue) compact() error {
+	path := filepath.Join(q.dir, queueLogFile)
+	tmp, err := os.CreateTemp(q.dir, queueLogFile+".tmp*")
+	if err != nil {
+		return fmt.Errorf("webhook: failed to compact queue log: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// Regroup the pending deliveries by event
+	sinks := make(map[uint64][]string)
+	var ids []uint64
+	var deliveries []*delivery
+	for _, name := range q.names {
+		for _, d := range q.pending[name] {
+			if _, ok := sinks[d.id]; !ok {
+				ids = append(ids, d.id)
+			}
+			sinks[d.id] = append(sinks[d.id], name)
+			deliveries = append(deliveries, d)
+		}
+	}
+	slices.Sort(ids)
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	records := 0
+	write := func(record walRecord) {
+		if err == nil {
+			err = enc.Encode(record)
+			records++
+		}
+	}
+	for _, id := range ids {
+		for _, d := range deliveries {
+			if d.id == id {
+				write(walRecord{Op: opEnqueue, ID: id, Sinks: sinks[id], Body: d.body, Header: d.header})
+				break
+			}
+		}
+	}
+	for _, d := range deliveries {
+		if d.attempts > 0 {
+			write(walRecord{Op: opRetry, ID: d.id, Sink: d.sink, Attempts: d.attempts, Next: d.next})
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("webhook: failed to compact queue log: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("webhook: failed to compact queue log: %w", err)
+	}
+	if q.wal != nil {
+		q.wal.Close()
+		q.wal = nil
+	}
+	wal, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("webhook: failed to open queue log: %w", err)
+	}
+	q.wal = wal
+	q.records = records
+	q.base = records
+	return nil
+}
@@ -0,0 +1,388 @@
+package webhook_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keithah/hostex-go/webhook"
+)
+
+func mustParse(t *testing.T, body string) webhook.Event {
+	t.Helper()
+	event, err := webhook.Parse([]byte(body))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return event
+}
+
+func newQueue(t *testing.T, dir string, sinks map[string]webhook.Sink) *webhook.Queue {
+	t.Helper()
+	q, err := webhook.NewQueue(webhook.QueueOptions{
+		Dir:         dir,
+		Sinks:       sinks,
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewQueue failed: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+// run runs the queue until every delivery is made or dead-lettered
+func run(t *testing.T, q *webhook.Queue) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Pending() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out with %d pending deliveries", q.Pending())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	event := mustParse(t, reservationCreated)
+	body, err := webhook.Marshal(event)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var env map[string]json.RawMessage
+	json.Unmarshal(body, &env)
+	var data map[string]json.RawMessage
+	json.Unmarshal(env["data"], &data)
+	if _, ok := data["id"]; ok || data["reservation"] == nil {
+		t.Errorf("Expected data to hold only the reservation, got %s", env["data"])
+	}
+
+	again := mustParse(t, string(body)).(*webhook.ReservationEvent)
+	if again.Meta() != event.Meta() || again.Reservation.ReservationCode != "HMABC123" {
+		t.Errorf("Round trip changed the event: %+v", again)
+	}
+}
+
+func TestQueue_Sinks(t *testing.T) {
+	dir := t.TempDir()
+
+	fileSink, err := webhook.NewFileSink(filepath.Join(dir, "out", "events.jsonl"))
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	defer fileSink.Close()
+
+	ch := make(chan webhook.Event, 10)
+	publisher := &webhook.MemoryPublisher{}
+
+	var forwarded atomic.Int32
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if _, err := webhook.Parse(mustRead(t, r)); err == nil {
+			forwarded.Add(1)
+		}
+	}))
+	defer downstream.Close()
+
+	q := newQueue(t, filepath.Join(dir, "queue"), map[string]webhook.Sink{
+		"file":    fileSink,
+		"channel": webhook.NewChannelSink(ch),
+		"broker":  &webhook.PublisherSink{Publisher: publisher},
+		"http":    &webhook.HTTPSink{URL: downstream.URL, Header: http.Header{"Authorization": {"Bearer secret"}}},
+	})
+
+	h := webhook.NewHandler(webhook.Options{})
+	h.OnUnhandled(q.Enqueue)
+	for _, body := range []string{reservationCreated, messageReceived} {
+		if rec := deliver(h, http.MethodPost, body); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+	}
+	run(t, q)
+
+	if len(ch) != 2 {
+		t.Errorf("Expected 2 events on the channel, got %d", len(ch))
+	}
+	if msgs := publisher.Messages(); len(msgs) != 2 || msgs[0].Subject != "hostex.reservation_created" {
+		t.Errorf("Unexpected published messages: %+v", msgs)
+	}
+	if forwarded.Load() != 2 {
+		t.Errorf("Expected 2 forwarded events, got %d", forwarded.Load())
+	}
+	if lines := readLines(t, filepath.Join(dir, "out", "events.jsonl")); len(lines) != 2 {
+		t.Errorf("Expected 2 lines in the file sink, got %d", len(lines))
+	}
+}
+
+func TestQueue_RetryAndDeadLetter(t *testing.T) {
+	var flakyCalls atomic.Int32
+	flaky := webhook.SinkFunc(func(ctx context.Context, e webhook.Event) error {
+		if flakyCalls.Add(1) <= 2 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	var downCalls atomic.Int32
+	down := webhook.SinkFunc(func(ctx context.Context, e webhook.Event) error {
+		downCalls.Add(1)
+		return errors.New("service unavailable")
+	})
+	rejecting := webhook.SinkFunc(func(ctx context.Context, e webhook.Event) error {
+		return webhook.Permanent(errors.New("schema mismatch"))
+	})
+
+	q := newQueue(t, t.TempDir(), map[string]webhook.Sink{"flaky": flaky, "down": down, "rejecting": rejecting})
+	if err := q.Enqueue(context.Background(), mustParse(t, reservationCreated)); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	run(t, q)
+
+	if flakyCalls.Load() != 3 {
+		t.Errorf("Expected flaky sink to succeed on the third attempt, got %d calls", flakyCalls.Load())
+	}
+	if downCalls.Load() != 3 {
+		t.Errorf("Expected 3 attempts on the failing sink, got %d", downCalls.Load())
+	}
+
+	letters, err := q.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters failed: %v", err)
+	}
+	attempts := make(map[string]int)
+	for _, l := range letters {
+		attempts[l.Sink] = l.Attempts
+		if e, err := l.Decode(); err != nil || e.Meta().ID != "evt-1" {
+			t.Errorf("Expected dead letter to hold evt-1, got %v, %v", e, err)
+		}
+	}
+	if len(letters) != 2 || attempts["down"] != 3 || attempts["rejecting"] != 1 {
+		t.Errorf("Unexpected dead letters: %+v", letters)
+	}
+}
+
+func TestQueue_Recovers(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	var received []string
+	sink := webhook.SinkFunc(func(ctx context.Context, e webhook.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, e.Meta().ID)
+		return nil
+	})
+
+	// Events enqueued before a crash are delivered after the restart
+	first, err := webhook.NewQueue(webhook.QueueOptions{Dir: dir, Sinks: map[string]webhook.Sink{"app": sink}})
+	if err != nil {
+		t.Fatalf("NewQueue failed: %v", err)
+	}
+	first.Enqueue(context.Background(), mustParse(t, reservationCreated))
+	first.Enqueue(context.Background(), mustParse(t, messageReceived))
+	first.Close()
+
+	q := newQueue(t, dir, map[string]webhook.Sink{"app": sink})
+	if q.Pending() != 2 {
+		t.Fatalf("Expected 2 recovered deliveries, got %d", q.Pending())
+	}
+	run(t, q)
+	if len(received) != 2 || received[0] != "evt-1" || received[1] != "evt-2" {
+		t.Errorf("Unexpected deliveries: %v", received)
+	}
+	q.Close()
+
+	// Delivered events are not delivered again
+	again := newQueue(t, dir, map[string]webhook.Sink{"app": sink})
+	if again.Pending() != 0 {
+		t.Errorf("Expected no pending deliveries, got %d", again.Pending())
+	}
+}
+
+func TestQueue_RemovedSinkDeadLetters(t *testing.T) {
+	dir := t.TempDir()
+	noop := webhook.SinkFunc(func(ctx context.Context, e webhook.Event) error { return nil })
+
+	first := newQueue(t, dir, map[string]webhook.Sink{"old": noop, "new": noop})
+	first.Enqueue(context.Background(), mustParse(t, reservationCreated))
+	first.Close()
+
+	q := newQueue(t, dir, map[string]webhook.Sink{"new": noop})
+	if q.Pending() != 1 {
+		t.Errorf("Expected 1 pending delivery, got %d", q.Pending())
+	}
+	letters, err := q.DeadLetters()
+	if err != nil || len(letters) != 1 || letters[0].Sink != "old" {
+		t.Errorf("Expected the removed sink's delivery to be dead-lettered, got %+v, %v", letters, err)
+	}
+}
+
+func TestQueue_KeepsRawDelivery(t *testing.T) {
+	dir := t.TempDir()
+	body := strings.Replace(reservationCreated, `"status": "accepted"`, `"status": "accepted",
+			"loyalty_tier": "gold"`, 1)
+
+	// The delivery is stored as Hostex sent it, headers included
+	noop := webhook.SinkFunc(func(ctx context.Context, e webhook.Event) error { return nil })
+	first := newQueue(t, dir, map[string]webhook.Sink{"app": noop, "broker": noop})
+	h := webhook.NewHandler(webhook.Options{})
+	h.OnUnhandled(first.Enqueue)
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.Header.Set("X-Hostex-Signature", "sig-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	first.Close()
+
+	var got *webhook.Delivery
+	publisher := &webhook.MemoryPublisher{}
+	q := newQueue(t, dir, map[string]webhook.Sink{
+		"app": webhook.SinkFunc(func(ctx context.Context, e webhook.Event) error {
+			got, _ = webhook.DeliveryFromContext(ctx)
+			return webhook.Permanent(errors.New("schema mismatch"))
+		}),
+		"broker": &webhook.PublisherSink{Publisher: publisher},
+	})
+	run(t, q)
+
+	if got == nil || string(got.Body) != body || got.Header.Get("X-Hostex-Signature") != "sig-1" {
+		t.Errorf("Expected the sink to see the raw delivery, got %+v", got)
+	}
+	if msgs := publisher.Messages(); len(msgs) != 1 || string(msgs[0].Data) != body {
+		t.Errorf("Expected the raw body to be published, got %+v", msgs)
+	}
+	letters, err := q.DeadLetters()
+	if err != nil || len(letters) != 1 || !strings.Contains(string(letters[0].Event), `"loyalty_tier":"gold"`) || letters[0].Header.Get("X-Hostex-Signature") != "sig-1" {
+		t.Errorf("Expected the dead letter to keep the raw delivery, got %+v, %v", letters, err)
+	}
+}
+
+func TestQueue_CompactsWhileBusy(t *testing.T) {
+	dir := t.TempDir()
+	var delivered atomic.Int32
+	q := newQueue(t, dir, map[string]webhook.Sink{
+		"fast": webhook.SinkFunc(func(ctx context.Context, e webhook.Event) error {
+			delivered.Add(1)
+			return nil
+		}),
+		"stuck": webhook.SinkFunc(func(ctx context.Context, e webhook.Event) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	})
+
+	const events = 600
+	event := mustParse(t, reservationCreated)
+	for range events {
+		if err := q.Enqueue(context.Background(), event); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for delivered.Load() < events {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out after %d deliveries", delivered.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// The acknowledged deliveries were compacted away although the stuck
+	// sink still has work pending
+	if lines := readLines(t, filepath.Join(dir, "queue.wal")); len(lines) >= 2*events {
+		t.Errorf("Expected the log to be compacted, got %d lines", len(lines))
+	}
+	if q.Pending() != events {
+		t.Errorf("Expected %d pending deliveries, got %d", events, q.Pending())
+	}
+}
+
+func TestHTTPSink_Errors(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := &webhook.HTTPSink{URL: srv.URL}
+	event := mustParse(t, reservationCreated)
+
+	tests := []struct {
+		status    int
+		wantErr   bool
+		permanent bool
+	}{
+		{http.StatusAccepted, false, false},
+		{http.StatusBadRequest, true, true},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusBadGateway, true, false},
+	}
+	for _, tt := range tests {
+		status = tt.status
+		err := sink.Send(context.Background(), event)
+		if (err != nil) != tt.wantErr || webhook.IsPermanent(err) != tt.permanent {
+			t.Errorf("Status %d: got error %v (permanent %v)", tt.status, err, webhook.IsPermanent(err))
+		}
+	}
+}
+
+func mustRead(t *testing.T, r *http.Request) []byte {
+	t.Helper()
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		t.Errorf("Failed to read forwarded body: %v", err)
+	}
+	return body
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
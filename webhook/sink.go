@@ -0,0 +1,244 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Sink receives events forwarded by a Queue. A Sink may be called again with
+// an event it has already received if a delivery is retried. The raw body
+// and headers of the delivery are available from DeliveryFromContext.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// SinkFunc adapts a function to the Sink interface
+type SinkFunc func(ctx context.Context, event Event) error
+
+// Send calls f
+func (f SinkFunc) Send(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// permanentError marks an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks a Sink error as not worth retrying, so the Queue moves the
+// event to the dead-letter file straight away
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// deliveryBody returns the raw delivery body of an event, or the event
+// re-encoded in the webhook delivery format if ctx does not carry it
+func deliveryBody(ctx context.Context, event Event) ([]byte, error) {
+	if d, ok := rawDelivery(ctx, event); ok {
+		return d.Body, nil
+	}
+	return Marshal(event)
+}
+
+// FileSink appends each event to a file as a line of JSON in the webhook
+// delivery format
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens path for appending, creating the file and its directory
+// if needed
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("webhook: failed to create sink directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to open sink file: %w", err)
+	}
+	return &FileSink{file: f}, nil
+}
+
+// Send implements Sink
+func (s *FileSink) Send(ctx context.Context, event Event) error {
+	body, err := deliveryBody(ctx, event)
+	if err != nil {
+		return Permanent(err)
+	}
+	var line bytes.Buffer
+	if err := json.Compact(&line, body); err != nil {
+		return Permanent(fmt.Errorf("webhook: failed to encode event: %w", err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(line.Bytes(), '\n')); err != nil {
+		return fmt.Errorf("webhook: failed to write event: %w", err)
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// ChannelSink sends events to a Go channel for in-process consumers. Send
+// blocks until the event is received or the context is done.
+type ChannelSink struct {
+	ch chan<- Event
+}
+
+// NewChannelSink creates a sink that sends to ch
+func NewChannelSink(ch chan<- Event) *ChannelSink {
+	return &ChannelSink{ch: ch}
+}
+
+// Send implements Sink
+func (s *ChannelSink) Send(ctx context.Context, event Event) error {
+	select {
+	case s.ch <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HTTPSink forwards events to another service by POSTing the delivery body
+// as Hostex sent it. 2xx responses are successes; 4xx responses other than 408
+// and 429 are permanent failures, anything else is retried.
+type HTTPSink struct {
+	// URL receives the events
+	URL string
+
+	// Header is added to every request, e.g. for authentication (optional)
+	Header http.Header
+
+	// Client sends the requests (optional, defaults to http.DefaultClient)
+	Client *http.Client
+}
+
+// Send implements Sink
+func (s *HTTPSink) Send(ctx context.Context, event Event) error {
+	body, err := deliveryBody(ctx, event)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("webhook: failed to create request: %w", err))
+	}
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: failed to forward event: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("webhook: forwarding event to %s failed with status %d", s.URL, resp.StatusCode)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+// Publisher is the subset of a message broker client, such as NATS or Redis
+// pub/sub, needed to publish events
+type Publisher interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+}
+
+// PublisherSink publishes the delivery body of each event as Hostex sent it
+// through a Publisher
+type PublisherSink struct {
+	Publisher Publisher
+
+	// Subject returns the subject to publish an event to (optional,
+	// defaults to "hostex.<event type>", e.g. "hostex.reservation_created")
+	Subject func(event Event) string
+}
+
+// Send implements Sink
+func (s *PublisherSink) Send(ctx context.Context, event Event) error {
+	data, err := deliveryBody(ctx, event)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	subject := "hostex." + string(event.Type())
+	if s.Subject != nil {
+		subject = s.Subject(event)
+	}
+	return s.Publisher.Publish(ctx, subject, data)
+}
+
+// PublishedMessage is a message published to a MemoryPublisher
+type PublishedMessage struct {
+	Subject string
+	Data    []byte
+}
+
+// MemoryPublisher is an in-process Publisher that records what is published.
+// It stands in for a real broker in tests and local development.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []PublishedMessage
+}
+
+// Publish implements Publisher
+func (p *MemoryPublisher) Publish(ctx context.Context, subject string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, PublishedMessage{Subject: subject, Data: bytes.Clone(data)})
+	return nil
+}
+
+// Messages returns the published messages, in order
+func (p *MemoryPublisher) Messages() []PublishedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PublishedMessage(nil), p.messages...)
+}
@@ -2,8 +2,11 @@ package hostex
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 // WebhooksResponse represents the response from listing webhooks
@@ -13,7 +16,11 @@ type WebhooksResponse struct {
 
 // ListWebhooks retrieves a list of configured webhooks
 func (c *Client) ListWebhooks(ctx context.Context) (*WebhooksResponse, error) {
-	resp, err := c.doRequest(ctx, "GET", "/webhooks", nil, nil)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "ListWebhooks",
+		Method:    "GET",
+		Endpoint:  "/webhooks",
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +44,12 @@ func (c *Client) CreateWebhook(ctx context.Context, webhookURL string) (*CreateW
 		"url": webhookURL,
 	}
 
-	resp, err := c.doRequest(ctx, "POST", "/webhooks", nil, body)
+	resp, err := c.doRequest(ctx, &Request{
+		Operation: "CreateWebhook",
+		Method:    "POST",
+		Endpoint:  "/webhooks",
+		Body:      body,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +64,232 @@ func (c *Client) CreateWebhook(ctx context.Context, webhookURL string) (*CreateW
 
 // DeleteWebhook deletes a webhook by ID
 func (c *Client) DeleteWebhook(ctx context.Context, webhookID int) error {
-	_, err := c.doRequest(ctx, "DELETE", "/webhooks/"+strconv.Itoa(webhookID), nil, nil)
+	_, err := c.doRequest(ctx, &Request{
+		Operation: "DeleteWebhook",
+		Method:    "DELETE",
+		Endpoint:  "/webhooks/" + strconv.Itoa(webhookID),
+	})
 	return err
 }
+
+// WebhookAction is a step in reconciling webhooks
+type WebhookAction string
+
+// Webhook actions
+const (
+	// WebhookKeep leaves a desired webhook that already exists in place
+	WebhookKeep WebhookAction = "keep"
+
+	// WebhookCreate registers a desired webhook that does not exist
+	WebhookCreate WebhookAction = "create"
+
+	// WebhookDelete removes a webhook that is not desired
+	WebhookDelete WebhookAction = "delete"
+
+	// WebhookSkip leaves a webhook that is not desired in place, because it
+	// is not manageable through the API or KeepStale is set
+	WebhookSkip WebhookAction = "skip"
+)
+
+// EnsureWebhooksOptions configures EnsureWebhooks
+type EnsureWebhooksOptions struct {
+	// DryRun plans the changes without making them
+	DryRun bool
+
+	// KeepStale leaves webhooks that are not desired in place instead of
+	// deleting them
+	KeepStale bool
+
+	// AllowDeleteAll allows an empty desiredURLs, which deletes every
+	// manageable webhook. Without it an empty list is rejected.
+	AllowDeleteAll bool
+}
+
+// WebhookChange is one planned or applied reconciliation step
+type WebhookChange struct {
+	Action WebhookAction
+	URL    string
+
+	// Webhook is the existing webhook, or the created one once applied
+	Webhook Webhook
+
+	// Reason explains why a webhook is skipped, or why a delete was not
+	// carried out
+	Reason string
+
+	// Applied reports whether a create or delete was carried out
+	Applied bool
+
+	// Err is the error from carrying out the change, if any
+	Err error
+}
+
+// EnsureWebhooksReport lists the steps taken by EnsureWebhooks, in the
+// order they were carried out
+type EnsureWebhooksReport struct {
+	DryRun  bool
+	Changes []WebhookChange
+}
+
+// HasChanges reports whether any webhook needs to be created or deleted
+func (r *EnsureWebhooksReport) HasChanges() bool {
+	for _, ch := range r.Changes {
+		if ch.Action == WebhookCreate || ch.Action == WebhookDelete {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the report as a plan, one change per line
+func (r *EnsureWebhooksReport) String() string {
+	var b strings.Builder
+	for _, ch := range r.Changes {
+		switch ch.Action {
+		case WebhookCreate:
+			fmt.Fprintf(&b, "+ create %s", ch.URL)
+		case WebhookDelete:
+			fmt.Fprintf(&b, "- delete %s (id %d)", ch.URL, ch.Webhook.ID)
+		case WebhookKeep:
+			fmt.Fprintf(&b, "= keep   %s (id %d)", ch.URL, ch.Webhook.ID)
+		default:
+			fmt.Fprintf(&b, "! skip   %s (id %d): %s", ch.URL, ch.Webhook.ID, ch.Reason)
+		}
+		switch {
+		case ch.Err != nil:
+			fmt.Fprintf(&b, ": failed: %v", ch.Err)
+		case ch.Action == WebhookDelete && ch.Reason != "":
+			fmt.Fprintf(&b, ": not done: %s", ch.Reason)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// webhookURLs is the desired URL list passed to EnsureWebhooks
+type webhookURLs []string
+
+// Validate checks that every URL is an absolute http or https URL
+func (urls webhookURLs) Validate() error {
+	var v validator
+	for i, raw := range urls {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			fmt.Sprintf("desired_urls[%d]", i), "must be an absolute http or https URL")
+	}
+	return v.err("EnsureWebhooks")
+}
+
+// EnsureWebhooks reconciles the registered webhooks with desiredURLs:
+// missing URLs are created and webhooks for other URLs are deleted when they
+// are manageable. Creates happen before deletes, so events keep flowing when
+// a URL is replaced, and if any create fails no webhook is deleted. An
+// empty desiredURLs is rejected unless AllowDeleteAll or KeepStale is set.
+//
+// The report lists every step, including failed ones; the returned error
+// joins the failures. With DryRun the report is the plan and nothing is
+// changed.
+func (c *Client) EnsureWebhooks(ctx context.Context, desiredURLs []string, opts *EnsureWebhooksOptions) (*EnsureWebhooksReport, error) {
+	if opts == nil {
+		opts = &EnsureWebhooksOptions{}
+	}
+	// The URLs are checked even with DisableValidation, as a bad list
+	// would delete every registered webhook
+	if err := webhookURLs(desiredURLs).Validate(); err != nil {
+		return nil, err
+	}
+	if len(desiredURLs) == 0 && !opts.AllowDeleteAll && !opts.KeepStale {
+		return nil, &ValidationError{Type: "EnsureWebhooks", Fields: []FieldError{
+			{Field: "desired_urls", Message: "is empty; set AllowDeleteAll to delete every webhook"},
+		}}
+	}
+
+	existing, err := c.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := planWebhooks(existing.Webhooks, desiredURLs, opts)
+	if opts.DryRun {
+		return report, nil
+	}
+
+	// The plan lists every create before the deletes
+	var errs []error
+	createFailed := false
+	for i := range report.Changes {
+		ch := &report.Changes[i]
+		switch ch.Action {
+		case WebhookCreate:
+			resp, err := c.CreateWebhook(ctx, ch.URL)
+			if err != nil {
+				ch.Err = err
+				errs = append(errs, fmt.Errorf("failed to create webhook %s: %w", ch.URL, err))
+				createFailed = true
+				continue
+			}
+			ch.Webhook = resp.Webhook
+			ch.Applied = true
+		case WebhookDelete:
+			if createFailed {
+				// Deleting could leave no webhook to receive events
+				ch.Reason = "a create failed"
+				continue
+			}
+			if err := c.DeleteWebhook(ctx, ch.Webhook.ID); err != nil {
+				ch.Err = err
+				errs = append(errs, fmt.Errorf("failed to delete webhook %d: %w", ch.Webhook.ID, err))
+				continue
+			}
+			ch.Applied = true
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// planWebhooks diffs the existing webhooks against the desired URLs. Desired
+// URLs come first, in the given order, followed by the stale webhooks.
+func planWebhooks(existing []Webhook, desiredURLs []string, opts *EnsureWebhooksOptions) *EnsureWebhooksReport {
+	report := &EnsureWebhooksReport{DryRun: opts.DryRun}
+
+	byURL := make(map[string]Webhook)
+	for _, wh := range existing {
+		if _, ok := byURL[wh.URL]; !ok {
+			byURL[wh.URL] = wh
+		}
+	}
+
+	desired := make(map[string]bool)
+	for _, raw := range desiredURLs {
+		u := strings.TrimSpace(raw)
+		if desired[u] {
+			continue
+		}
+		desired[u] = true
+
+		if wh, ok := byURL[u]; ok {
+			report.Changes = append(report.Changes, WebhookChange{Action: WebhookKeep, URL: u, Webhook: wh})
+		} else {
+			report.Changes = append(report.Changes, WebhookChange{Action: WebhookCreate, URL: u})
+		}
+	}
+
+	for _, wh := range existing {
+		// Duplicates of a kept webhook are stale too
+		if desired[wh.URL] && byURL[wh.URL].ID == wh.ID {
+			continue
+		}
+
+		ch := WebhookChange{Action: WebhookDelete, URL: wh.URL, Webhook: wh}
+		switch {
+		case !wh.Manageable:
+			ch.Action, ch.Reason = WebhookSkip, "not manageable through the API"
+		case opts.KeepStale:
+			ch.Action, ch.Reason = WebhookSkip, "stale webhooks are kept"
+		}
+		report.Changes = append(report.Changes, ch)
+	}
+
+	return report
+}
@@ -0,0 +1,203 @@
+package hostex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/keithah/hostex-go"
+	"github.com/keithah/hostex-go/hostextest"
+)
+
+func newWebhookServer(t *testing.T) *hostextest.Server {
+	t.Helper()
+
+	srv := hostextest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Seed(hostextest.Fixtures{Webhooks: []hostex.Webhook{
+		{ID: 1, URL: "https://example.com/hooks/v1", Manageable: true},
+		{ID: 2, URL: "https://example.com/hooks/v2", Manageable: true},
+		{ID: 3, URL: "https://legacy.example.com/hook", Manageable: false},
+		{ID: 4, URL: "https://example.com/hooks/v2", Manageable: true},
+	}})
+	return srv
+}
+
+func actions(report *hostex.EnsureWebhooksReport) []string {
+	var got []string
+	for _, ch := range report.Changes {
+		got = append(got, string(ch.Action)+" "+ch.URL)
+	}
+	return got
+}
+
+func TestEnsureWebhooks(t *testing.T) {
+	srv := newWebhookServer(t)
+	client := srv.Client()
+	desired := []string{"https://example.com/hooks/v2", "https://example.com/hooks/v3", "https://example.com/hooks/v3"}
+
+	report, err := client.EnsureWebhooks(context.Background(), desired, nil)
+	if err != nil {
+		t.Fatalf("EnsureWebhooks failed: %v", err)
+	}
+
+	want := []string{
+		"keep https://example.com/hooks/v2",
+		"create https://example.com/hooks/v3",
+		"delete https://example.com/hooks/v1",
+		"skip https://legacy.example.com/hook",
+		"delete https://example.com/hooks/v2",
+	}
+	if got := actions(report); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if created := report.Changes[1]; !created.Applied || created.Webhook.ID == 0 {
+		t.Errorf("Expected created webhook in report, got %+v", created)
+	}
+
+	var urls []string
+	for _, wh := range srv.Webhooks() {
+		urls = append(urls, wh.URL)
+	}
+	if strings.Join(urls, " ") != "https://example.com/hooks/v2 https://legacy.example.com/hook https://example.com/hooks/v3" {
+		t.Errorf("Unexpected webhooks after reconciling: %v", urls)
+	}
+
+	// Reconciling again is a no-op
+	report, err = client.EnsureWebhooks(context.Background(), desired, nil)
+	if err != nil {
+		t.Fatalf("EnsureWebhooks failed: %v", err)
+	}
+	if report.HasChanges() {
+		t.Errorf("Expected no changes, got:\n%s", report)
+	}
+}
+
+func TestEnsureWebhooks_DryRun(t *testing.T) {
+	srv := newWebhookServer(t)
+
+	report, err := srv.Client().EnsureWebhooks(context.Background(), []string{"https://example.com/hooks/v3"}, &hostex.EnsureWebhooksOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("EnsureWebhooks failed: %v", err)
+	}
+
+	srv.AssertNotCalled(t, "POST /webhooks")
+	srv.AssertNotCalled(t, "DELETE /webhooks/{id}")
+
+	plan := report.String()
+	for _, line := range []string{
+		"+ create https://example.com/hooks/v3",
+		"- delete https://example.com/hooks/v1 (id 1)",
+		"! skip   https://legacy.example.com/hook (id 3): not manageable through the API",
+	} {
+		if !strings.Contains(plan, line) {
+			t.Errorf("Expected plan to contain %q, got:\n%s", line, plan)
+		}
+	}
+}
+
+func TestEnsureWebhooks_KeepStale(t *testing.T) {
+	srv := newWebhookServer(t)
+
+	report, err := srv.Client().EnsureWebhooks(context.Background(), []string{"https://example.com/hooks/v1"}, &hostex.EnsureWebhooksOptions{KeepStale: true})
+	if err != nil {
+		t.Fatalf("EnsureWebhooks failed: %v", err)
+	}
+	if report.HasChanges() {
+		t.Errorf("Expected stale webhooks to be kept, got:\n%s", report)
+	}
+	if n := len(srv.Webhooks()); n != 4 {
+		t.Errorf("Expected 4 webhooks, got %d", n)
+	}
+}
+
+func TestEnsureWebhooks_PartialFailure(t *testing.T) {
+	srv := newWebhookServer(t)
+	srv.Fail("DELETE /webhooks/{id}", hostextest.Failure{StatusCode: http.StatusInternalServerError})
+
+	report, err := srv.Client().EnsureWebhooks(context.Background(), []string{"https://example.com/hooks/v3"}, nil)
+	if err == nil {
+		t.Fatal("Expected an error for the failed delete")
+	}
+
+	var failed, applied int
+	for _, ch := range report.Changes {
+		if ch.Err != nil {
+			failed++
+		}
+		if ch.Applied {
+			applied++
+		}
+	}
+	// The create and one of the three deletes succeed
+	if failed != 1 || applied != 3 {
+		t.Errorf("Expected 1 failed and 3 applied changes, got %d and %d:\n%s", failed, applied, report)
+	}
+}
+
+func TestEnsureWebhooks_FailedCreateKeepsOldWebhooks(t *testing.T) {
+	srv := newWebhookServer(t)
+	srv.Fail("POST /webhooks", hostextest.Failure{StatusCode: http.StatusInternalServerError})
+
+	report, err := srv.Client().EnsureWebhooks(context.Background(), []string{"https://example.com/hooks/v3"}, nil)
+	if err == nil {
+		t.Fatal("Expected an error for the failed create")
+	}
+	srv.AssertNotCalled(t, "DELETE /webhooks/{id}")
+	if n := len(srv.Webhooks()); n != 4 {
+		t.Errorf("Expected every webhook to be kept, got %d", n)
+	}
+	for _, ch := range report.Changes {
+		if ch.Action == hostex.WebhookDelete && (ch.Applied || ch.Reason == "") {
+			t.Errorf("Expected the delete to be reported as not done, got %+v", ch)
+		}
+	}
+	if plan := report.String(); !strings.Contains(plan, "- delete https://example.com/hooks/v1 (id 1): not done: a create failed") {
+		t.Errorf("Expected the report to show the delete was not done, got:\n%s", plan)
+	}
+}
+
+func TestEnsureWebhooks_EmptyList(t *testing.T) {
+	srv := newWebhookServer(t)
+	client := srv.Client()
+
+	if _, err := client.EnsureWebhooks(context.Background(), nil, nil); !errors.Is(err, hostex.ErrValidation) {
+		t.Fatalf("Expected an empty list to be rejected, got %v", err)
+	}
+	srv.AssertNotCalled(t, "GET /webhooks")
+
+	if _, err := client.EnsureWebhooks(context.Background(), nil, &hostex.EnsureWebhooksOptions{AllowDeleteAll: true}); err != nil {
+		t.Fatalf("EnsureWebhooks failed: %v", err)
+	}
+	// Only the webhook that is not manageable is left
+	if webhooks := srv.Webhooks(); len(webhooks) != 1 || webhooks[0].ID != 3 {
+		t.Errorf("Expected only the legacy webhook to remain, got %+v", webhooks)
+	}
+}
+
+func TestEnsureWebhooks_InvalidURL(t *testing.T) {
+	srv := newWebhookServer(t)
+
+	_, err := srv.Client().EnsureWebhooks(context.Background(), []string{"https://example.com/ok", "/relative"}, nil)
+	if !errors.Is(err, hostex.ErrValidation) {
+		t.Fatalf("Expected ErrValidation, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "desired_urls[1]") {
+		t.Errorf("Expected error to name the bad URL, got %v", err)
+	}
+	srv.AssertNotCalled(t, "GET /webhooks")
+
+	// The URLs are checked even with validation disabled
+	config := srv.Config()
+	config.DisableValidation = true
+	client, err := hostex.NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := client.EnsureWebhooks(context.Background(), []string{"not a url"}, nil); !errors.Is(err, hostex.ErrValidation) {
+		t.Errorf("Expected ErrValidation with validation disabled, got %v", err)
+	}
+	srv.AssertNotCalled(t, "GET /webhooks")
+}